@@ -0,0 +1,92 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RefundCutoff ระยะเวลาก่อนรอบฉายเริ่มที่ยังขอคืนเงินได้ (REFUND_CUTOFF_MINUTES, ค่าเริ่มต้น 120 นาที)
+func RefundCutoff() time.Duration {
+	return time.Duration(getEnvInt("REFUND_CUTOFF_MINUTES", 120)) * time.Minute
+}
+
+// PaymentGateway ชื่อผู้ให้บริการชำระเงินที่ใช้ (PAYMENT_GATEWAY เช่น "fake")
+func PaymentGateway() string {
+	return os.Getenv("PAYMENT_GATEWAY")
+}
+
+// PaymentWebhookSecret secret สำหรับตรวจลายเซ็น webhook จาก payment gateway (PAYMENT_WEBHOOK_SECRET)
+func PaymentWebhookSecret() string {
+	return os.Getenv("PAYMENT_WEBHOOK_SECRET")
+}
+
+// PromptPayID หมายเลข PromptPay ที่ใช้สร้าง QR รับชำระเงิน (PROMPTPAY_ID)
+func PromptPayID() string {
+	return os.Getenv("PROMPTPAY_ID")
+}
+
+// AllowFakePaymentGateway อนุญาตให้ใช้ fake gateway ที่เก็บรายการไว้ในหน่วยความจำ (PAYMENT_ALLOW_FAKE_GATEWAY=true สำหรับ dev เท่านั้น)
+func AllowFakePaymentGateway() bool {
+	return os.Getenv("PAYMENT_ALLOW_FAKE_GATEWAY") == "true"
+}
+
+// getEnvInt อ่านค่าตัวเลขจาก environment ถ้าไม่มีหรือไม่ถูกต้องจะใช้ค่าเริ่มต้น
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// SeatHoldDuration เวลาถือที่นั่งหลังสร้างการจอง (SEAT_HOLD_MINUTES, ค่าเริ่มต้น 15 นาที)
+// สาขาที่ตั้ง cinemas.hold_minutes ไว้จะใช้ค่านั้นแทน
+func SeatHoldDuration() time.Duration {
+	return time.Duration(getEnvInt("SEAT_HOLD_MINUTES", 15)) * time.Minute
+}
+
+// SeatHoldExtension เวลาที่ขยายได้เมื่อลูกค้าขอต่อเวลาถือที่นั่ง (SEAT_HOLD_EXTENSION_MINUTES, ค่าเริ่มต้น 5 นาที)
+func SeatHoldExtension() time.Duration {
+	return time.Duration(getEnvInt("SEAT_HOLD_EXTENSION_MINUTES", 5)) * time.Minute
+}
+
+// CronSchedule อ่าน cron expression ของ job จาก environment ถ้าไม่ได้ตั้งจะใช้ค่าเริ่มต้น
+func CronSchedule(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// CancelledBookingRetention ระยะเวลาที่เก็บการจองที่ยกเลิกไว้ก่อน archive (RETENTION_DAYS, ค่าเริ่มต้น 30 วัน)
+func CancelledBookingRetention() time.Duration {
+	return time.Duration(getEnvInt("RETENTION_DAYS", 30)) * 24 * time.Hour
+}
+
+// SeatSelectionTTL อายุของล็อก "selecting" ถ้า client ไม่ต่ออายุ (SEAT_SELECTION_TTL_SECONDS, ค่าเริ่มต้น 30 วินาที)
+func SeatSelectionTTL() time.Duration {
+	return time.Duration(getEnvInt("SEAT_SELECTION_TTL_SECONDS", 30)) * time.Second
+}
+
+// WaitlistOfferDuration เวลาที่ถือที่นั่งไว้ให้คิวแรกในรายชื่อรอก่อนส่งต่อให้คิวถัดไป (WAITLIST_OFFER_MINUTES, ค่าเริ่มต้น 15 นาที)
+func WaitlistOfferDuration() time.Duration {
+	return time.Duration(getEnvInt("WAITLIST_OFFER_MINUTES", 15)) * time.Minute
+}
+
+// ShowtimeCleaningBuffer เวลาทำความสะอาดโรงระหว่างรอบฉาย รอบถัดไปในโรงเดียวกันเริ่มได้หลังจากนี้ (SHOWTIME_CLEANING_MINUTES, ค่าเริ่มต้น 15 นาที)
+func ShowtimeCleaningBuffer() time.Duration {
+	return time.Duration(getEnvInt("SHOWTIME_CLEANING_MINUTES", 15)) * time.Minute
+}
+
+// ShowtimeTrailerPadding เวลาตัวอย่างหนังและโฆษณาก่อนเริ่มภาพยนตร์ ใช้คำนวณ end_time เมื่อไม่ได้ระบุ (SHOWTIME_TRAILER_MINUTES, ค่าเริ่มต้น 15 นาที)
+func ShowtimeTrailerPadding() time.Duration {
+	return time.Duration(getEnvInt("SHOWTIME_TRAILER_MINUTES", 15)) * time.Minute
+}
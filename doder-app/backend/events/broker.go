@@ -0,0 +1,115 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// สถานะที่นั่งที่ส่งให้ client
+const (
+	SeatReserved = "reserved"
+	SeatBooked   = "booked"
+	SeatReleased = "released"
+
+	// สถานะชั่วคราวขณะลูกค้ากำลังเลือกที่นั่ง (ไม่อยู่ใน seat_status)
+	// deselected = เลิกเลือกแล้ว ให้ client กลับไปแสดงสถานะจริงของที่นั่ง
+	SeatSelecting  = "selecting"
+	SeatDeselected = "deselected"
+)
+
+// SeatEvent ที่นั่งในรอบฉายเปลี่ยนสถานะ (ส่งหลัง transaction commit แล้วเท่านั้น)
+type SeatEvent struct {
+	ShowtimeID int       `json:"showtime_id"`
+	SeatIDs    []int     `json:"seat_ids"`
+	Status     string    `json:"status"`
+	At         time.Time `json:"at"`
+}
+
+// Broker กระจาย SeatEvent ให้ผู้ที่ subscribe รอบฉายนั้นอยู่
+// ตอนนี้มีแค่ MemoryBroker (ใน process เดียว) ถ้ามีหลาย replica ให้ทำ implementation ที่ใช้ Postgres LISTEN/NOTIFY
+type Broker interface {
+	Publish(event SeatEvent)
+	// Subscribe คืน channel ของ event และฟังก์ชันยกเลิก channel จะถูกปิดเมื่อยกเลิก, broker ปิด หรือ subscriber อ่านไม่ทัน
+	Subscribe(showtimeID int) (<-chan SeatEvent, func())
+	Close()
+}
+
+// subscriberBuffer จำนวน event ที่ค้างได้ก่อนถือว่า subscriber อ่านไม่ทัน
+const subscriberBuffer = 32
+
+type subscriber struct {
+	ch chan SeatEvent
+}
+
+// MemoryBroker Broker ที่ทำงานภายใน process
+type MemoryBroker struct {
+	mu     sync.Mutex
+	subs   map[int]map[*subscriber]struct{}
+	closed bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: map[int]map[*subscriber]struct{}{}}
+}
+
+// Publish ส่ง event ให้ทุก subscriber ของรอบฉาย ไม่ block ผู้เรียก
+// subscriber ที่ buffer เต็มจะถูกตัดออก (client ต่อใหม่แล้วโหลดผังที่นั่งใหม่ได้)
+func (b *MemoryBroker) Publish(event SeatEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[event.ShowtimeID] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.removeLocked(event.ShowtimeID, sub)
+		}
+	}
+}
+
+func (b *MemoryBroker) Subscribe(showtimeID int) (<-chan SeatEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscriber{ch: make(chan SeatEvent, subscriberBuffer)}
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	if b.subs[showtimeID] == nil {
+		b.subs[showtimeID] = map[*subscriber]struct{}{}
+	}
+	b.subs[showtimeID][sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.removeLocked(showtimeID, sub)
+	}
+}
+
+// Close ปิดทุก subscription (ใช้ตอน shutdown ให้ stream ที่เปิดค้างจบ)
+func (b *MemoryBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for showtimeID, subs := range b.subs {
+		for sub := range subs {
+			b.removeLocked(showtimeID, sub)
+		}
+	}
+}
+
+func (b *MemoryBroker) removeLocked(showtimeID int, sub *subscriber) {
+	subs := b.subs[showtimeID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.ch)
+	if len(subs) == 0 {
+		delete(b.subs, showtimeID)
+	}
+}
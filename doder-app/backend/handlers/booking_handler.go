@@ -2,22 +2,27 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
-	"time"
 
 	"movie-booking-system/models"
+	"movie-booking-system/payments"
+	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type BookingHandler struct {
-	db *sql.DB
+	db             *sql.DB
+	bookingService *services.BookingService
 }
 
-func NewBookingHandler(db *sql.DB) *BookingHandler {
-	return &BookingHandler{db: db}
+func NewBookingHandler(db *sql.DB, bookingService *services.BookingService) *BookingHandler {
+	return &BookingHandler{db: db, bookingService: bookingService}
 }
 
 // CreateBooking สร้างการจองตั๋วใหม่
@@ -32,172 +37,60 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
-	// ตรวจสอบ showtime
-	var price float64
-	var availableSeats int
-	query := "SELECT price, available_seats FROM showtimes WHERE showtime_id = $1 AND is_active = TRUE"
-	err := h.db.QueryRow(query, req.ShowtimeID).Scan(&price, &availableSeats)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Success: false,
-			Error:   "Showtime not found",
-		})
-		return
-	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to fetch showtime",
-		})
-		return
-	}
-
-	// ตรวจสอบจำนวนที่นั่งว่างพอหรือไม่
-	if len(req.SeatIDs) > availableSeats {
+	seats := req.SeatRequests()
+	if len(seats) == 0 {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Not enough available seats",
+			Error:   "seat_ids or seats is required",
 		})
 		return
 	}
 
-	// ตรวจสอบว่าผู้ใช้จองที่นั่งเดียวกันซ้ำหลังจากคอนเฟิร์มแล้วหรือไม่
 	userID := c.GetInt("user_id")
-	for _, seatID := range req.SeatIDs {
-		var confirmedCount int
-		confirmedQuery := `
-			SELECT COUNT(*) FROM booking_seats bs
-			JOIN bookings b ON bs.booking_id = b.booking_id
-			WHERE b.user_id = $1 AND b.showtime_id = $2 AND bs.seat_id = $3 AND b.booking_status = 'confirmed'
-		`
-		err := h.db.QueryRow(confirmedQuery, userID, req.ShowtimeID, seatID).Scan(&confirmedCount)
-		if err == nil && confirmedCount > 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Success: false,
-				Error:   fmt.Sprintf("You have already confirmed booking for seat %d in this showtime", seatID),
-			})
-			return
-		}
-	}
-
-	// สร้าง booking code
-	bookingCode := fmt.Sprintf("BK%d%d", 1, time.Now().Unix())
-
-	// เริ่มต้น transaction
-	tx, err := h.db.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to start transaction",
-		})
-		return
-	}
-	defer tx.Rollback()
-
-	// สร้าง booking
-	totalAmount := price * float64(len(req.SeatIDs))
-	var bookingID int
-	bookingQuery := `
-		INSERT INTO bookings (user_id, showtime_id, total_amount, booking_code, booking_status, payment_status)
-		VALUES ($1, $2, $3, $4, 'pending', 'pending')
-		RETURNING booking_id
-	`
-	err = tx.QueryRow(bookingQuery, userID, req.ShowtimeID, totalAmount, bookingCode).Scan(&bookingID)
+	result, err := h.bookingService.Reserve(c.Request.Context(), userID, req.ShowtimeID, seats, req.Items, req.PromoCode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to create booking",
-		})
+		respondBookingError(c, err, "Failed to create booking")
 		return
 	}
 
-	// เพิ่ม booking seats
-	for _, seatID := range req.SeatIDs {
-		// ตรวจสอบว่าที่นั่งถูกจองและยืนยันแล้วหรือไม่ (confirmed booking)
-		var confirmedBookingCount int
-		confirmedSeatQuery := `
-			SELECT COUNT(*) FROM booking_seats bs
-			JOIN bookings b ON bs.booking_id = b.booking_id
-			WHERE b.showtime_id = $1 AND bs.seat_id = $2 AND b.booking_status = 'confirmed'
-		`
-		err := tx.QueryRow(confirmedSeatQuery, req.ShowtimeID, seatID).Scan(&confirmedBookingCount)
-		if err == nil && confirmedBookingCount > 0 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Seat %d has already been booked and confirmed", seatID),
-			})
-			return
-		}
-
-		// ตรวจสอบว่าที่นั่งว่างหรือไม่ (seat_status)
-		var status string
-		seatStatusQuery := "SELECT status FROM seat_status WHERE showtime_id = $1 AND seat_id = $2"
-		err = tx.QueryRow(seatStatusQuery, req.ShowtimeID, seatID).Scan(&status)
-		if err == nil && status == "booked" {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Seat %d is already booked", seatID),
-			})
-			return
-		}
-
-		// เพิ่ม booking seat
-		bookingSeatQuery := `
-			INSERT INTO booking_seats (booking_id, seat_id, price)
-			VALUES ($1, $2, $3)
-		`
-		_, err = tx.Exec(bookingSeatQuery, bookingID, seatID, price)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Success: false,
-				Error:   "Failed to add seat to booking",
-			})
-			return
-		}
-
-		// อัปเดต seat status เป็น reserved
-		updateSeatStatusQuery := `
-			UPDATE seat_status 
-			SET status = 'reserved', booking_id = $1, reserved_until = $2
-			WHERE showtime_id = $3 AND seat_id = $4
-		`
-		_, err = tx.Exec(updateSeatStatusQuery, bookingID, time.Now().Add(15*time.Minute), req.ShowtimeID, seatID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Success: false,
-				Error:   "Failed to update seat status",
-			})
-			return
-		}
-	}
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Booking created successfully",
+		Data: gin.H{
+			"booking_id":      result.BookingID,
+			"booking_code":    result.BookingCode,
+			"total_amount":    result.TotalAmount,
+			"discount_amount": result.DiscountAmount,
+			"promo_code":      result.PromoCode,
+			"expires_at":      result.ReservedUntil,
+		},
+	})
+}
 
-	// ลดจำนวน available seats
-	updateShowtimeQuery := "UPDATE showtimes SET available_seats = available_seats - $1 WHERE showtime_id = $2"
-	_, err = tx.Exec(updateShowtimeQuery, len(req.SeatIDs), req.ShowtimeID)
+// ExtendHold ต่อเวลาถือที่นั่งของการจองที่รอชำระเงิน (ได้ครั้งเดียว)
+// POST /api/bookings/:id/extend
+func (h *BookingHandler) ExtendHold(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Failed to update available seats",
+			Error:   "Invalid booking ID",
 		})
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to commit transaction",
-		})
+	expiresAt, err := h.bookingService.Extend(c.Request.Context(), bookingID)
+	if err != nil {
+		respondBookingError(c, err, "Failed to extend seat hold")
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.Response{
+	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Booking created successfully",
+		Message: "Seat hold extended",
 		Data: gin.H{
-			"booking_id":   bookingID,
-			"booking_code": bookingCode,
-			"total_amount": totalAmount,
+			"booking_id": bookingID,
+			"expires_at": expiresAt,
 		},
 	})
 }
@@ -219,23 +112,28 @@ func (h *BookingHandler) GetBooking(c *gin.Context) {
 			b.booking_id, b.booking_code, b.user_id, b.showtime_id,
 			m.title, c.cinema_name, t.theater_name, 
 			s.show_date, s.show_time, 
-			b.total_amount, b.booking_status, b.payment_status, b.booking_date
+			b.total_amount, b.discount_amount, pc.code,
+			b.booking_status, b.payment_status, b.booking_date
 		FROM bookings b
 		JOIN showtimes s ON b.showtime_id = s.showtime_id
 		JOIN movies m ON s.movie_id = m.movie_id
 		JOIN theaters t ON s.theater_id = t.theater_id
 		JOIN cinemas c ON t.cinema_id = c.cinema_id
+		LEFT JOIN promo_redemptions pr ON pr.booking_id = b.booking_id
+		LEFT JOIN promo_codes pc ON pr.promo_id = pc.promo_id
 		WHERE b.booking_id = $1
 	`
 
 	var booking models.Booking
 	var movieTitle, cinemaName, theaterName string
 	var showDate, showTime sql.NullTime
+	var promoCode *string
 	err = h.db.QueryRow(query, bookingID).Scan(
 		&booking.BookingID, &booking.BookingCode, &booking.UserID, &booking.ShowtimeID,
 		&movieTitle, &cinemaName, &theaterName,
 		&showDate, &showTime,
-		&booking.TotalAmount, &booking.BookingStatus, &booking.PaymentStatus, &booking.BookingDate,
+		&booking.TotalAmount, &booking.DiscountAmount, &promoCode,
+		&booking.BookingStatus, &booking.PaymentStatus, &booking.BookingDate,
 	)
 
 	if err == sql.ErrNoRows {
@@ -256,7 +154,7 @@ func (h *BookingHandler) GetBooking(c *gin.Context) {
 
 	// Fetch seats for this booking
 	seatsQuery := `
-		SELECT s.seat_id, s.seat_row, s.seat_number
+		SELECT s.seat_id, s.seat_row, s.seat_number, bs.ticket_type, bs.price
 		FROM booking_seats bs
 		JOIN seats s ON bs.seat_id = s.seat_id
 		WHERE bs.booking_id = $1
@@ -277,16 +175,29 @@ func (h *BookingHandler) GetBooking(c *gin.Context) {
 		var seatID int
 		var seatRow string
 		var seatNumber int
-		if err := seatsRows.Scan(&seatID, &seatRow, &seatNumber); err != nil {
+		var ticketType string
+		var price float64
+		if err := seatsRows.Scan(&seatID, &seatRow, &seatNumber, &ticketType, &price); err != nil {
 			continue
 		}
 		seats = append(seats, gin.H{
 			"seat_id":     seatID,
 			"seat_row":    seatRow,
 			"seat_number": seatNumber,
+			"ticket_type": ticketType,
+			"price":       price,
 		})
 	}
 
+	items, err := h.bookingItems(bookingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch booking items",
+		})
+		return
+	}
+
 	// Format show_date and show_time properly
 	var showDateStr, showTimeStr interface{}
 	if showDate.Valid {
@@ -299,20 +210,23 @@ func (h *BookingHandler) GetBooking(c *gin.Context) {
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
 		Data: gin.H{
-			"booking_id":     booking.BookingID,
-			"booking_code":   booking.BookingCode,
-			"user_id":        booking.UserID,
-			"showtime_id":    booking.ShowtimeID,
-			"movie_title":    movieTitle,
-			"cinema_name":    cinemaName,
-			"theater_name":   theaterName,
-			"show_date":      showDateStr,
-			"show_time":      showTimeStr,
-			"total_amount":   booking.TotalAmount,
-			"booking_status": booking.BookingStatus,
-			"payment_status": booking.PaymentStatus,
-			"booking_date":   booking.BookingDate,
-			"seats":          seats,
+			"booking_id":      booking.BookingID,
+			"booking_code":    booking.BookingCode,
+			"user_id":         booking.UserID,
+			"showtime_id":     booking.ShowtimeID,
+			"movie_title":     movieTitle,
+			"cinema_name":     cinemaName,
+			"theater_name":    theaterName,
+			"show_date":       showDateStr,
+			"show_time":       showTimeStr,
+			"total_amount":    booking.TotalAmount,
+			"discount_amount": booking.DiscountAmount,
+			"promo_code":      promoCode,
+			"booking_status":  booking.BookingStatus,
+			"payment_status":  booking.PaymentStatus,
+			"booking_date":    booking.BookingDate,
+			"seats":           seats,
+			"items":           items,
 		},
 	})
 }
@@ -329,108 +243,8 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	// ตรวจสอบ status ว่าสามารถยกเลิกได้หรือไม่
-	var bookingStatus string
-	query := "SELECT booking_status FROM bookings WHERE booking_id = $1"
-	err = h.db.QueryRow(query, bookingID).Scan(&bookingStatus)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Success: false,
-			Error:   "Booking not found",
-		})
-		return
-	}
-
-	if bookingStatus == "cancelled" || bookingStatus == "confirmed" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Error:   "Cannot cancel this booking",
-		})
-		return
-	}
-
-	// เริ่มต้น transaction
-	tx, err := h.db.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to start transaction",
-		})
-		return
-	}
-	defer tx.Rollback()
-
-	// อัปเดต booking status
-	updateQuery := "UPDATE bookings SET booking_status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE booking_id = $1"
-	_, err = tx.Exec(updateQuery, bookingID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to cancel booking",
-		})
-		return
-	}
-
-	// ดึงข้อมูลที่นั่งและ showtime
-	seatsQuery := `
-		SELECT bs.seat_id, b.showtime_id FROM booking_seats bs
-		JOIN bookings b ON bs.booking_id = b.booking_id
-		WHERE bs.booking_id = $1
-	`
-	rows, err := tx.Query(seatsQuery, bookingID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to fetch seats",
-		})
-		return
-	}
-	defer rows.Close()
-
-	var showtimeID int
-	var seatCount int
-	seats := []int{}
-	for rows.Next() {
-		var seatID int
-		rows.Scan(&seatID, &showtimeID)
-		seats = append(seats, seatID)
-		seatCount++
-	}
-
-	// อัปเดต seat status กลับเป็น available
-	for _, seatID := range seats {
-		updateSeatQuery := `
-			UPDATE seat_status 
-			SET status = 'available', booking_id = NULL, reserved_until = NULL
-			WHERE showtime_id = $1 AND seat_id = $2
-		`
-		_, err = tx.Exec(updateSeatQuery, showtimeID, seatID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Success: false,
-				Error:   "Failed to update seat status",
-			})
-			return
-		}
-	}
-
-	// เพิ่มจำนวน available seats กลับ
-	updateShowtimeQuery := "UPDATE showtimes SET available_seats = available_seats + $1 WHERE showtime_id = $2"
-	_, err = tx.Exec(updateShowtimeQuery, seatCount, showtimeID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to update available seats",
-		})
-		return
-	}
-
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to commit transaction",
-		})
+	if err := h.bookingService.Cancel(c.Request.Context(), bookingID); err != nil {
+		respondBookingError(c, err, "Failed to cancel booking")
 		return
 	}
 
@@ -486,8 +300,8 @@ func (h *BookingHandler) GetAllBookings(c *gin.Context) {
 	})
 }
 
-// ConfirmPayment ยืนยันการชำระเงิน
-// PUT /api/bookings/:id/confirm-payment
+// ConfirmPayment (Admin) ยืนยันการชำระเงินที่เคาน์เตอร์
+// PUT /api/admin/bookings/:id/confirm-payment
 func (h *BookingHandler) ConfirmPayment(c *gin.Context) {
 	bookingID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -498,124 +312,68 @@ func (h *BookingHandler) ConfirmPayment(c *gin.Context) {
 		return
 	}
 
-	// ตรวจสอบว่าการจองมีอยู่และยังไม่ได้ชำระเงิน
-	var bookingStatus, paymentStatus string
-	var showtimeID int
-	query := "SELECT booking_status, payment_status, showtime_id FROM bookings WHERE booking_id = $1"
-	err = h.db.QueryRow(query, bookingID).Scan(&bookingStatus, &paymentStatus, &showtimeID)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+	// body เป็น optional ถ้าไม่ระบุวิธีชำระเงินจะใช้ PromptPay
+	var req models.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Booking not found",
+			Error:   err.Error(),
 		})
 		return
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to fetch booking",
-		})
-		return
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = payments.MethodPromptPay
 	}
 
-	// ตรวจสอบสถานะ
-	if bookingStatus == "cancelled" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Error:   "Cannot confirm payment for cancelled booking",
-		})
+	// client ที่ retry หลัง timeout ส่ง Idempotency-Key เดิมมาเพื่อรับผลลัพธ์เดิม
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	result, err := h.bookingService.Confirm(c.Request.Context(), bookingID, req.PaymentMethod, idempotencyKey)
+	if err != nil {
+		respondBookingError(c, err, "Failed to confirm payment")
 		return
 	}
 
-	if paymentStatus == "paid" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Error:   "This booking has already been paid",
-		})
-		return
+	if result.Replayed {
+		c.Header("Idempotent-Replayed", "true")
 	}
 
-	// ตรวจสอบว่าที่นั่งในการจองนี้ถูก confirm โดยคนอื่นไปแล้วหรือไม่
-	conflictQuery := `
-		SELECT bs.seat_id FROM booking_seats bs
-		JOIN bookings b ON bs.booking_id = b.booking_id
-		WHERE bs.seat_id IN (SELECT seat_id FROM booking_seats WHERE booking_id = $1)
-		AND b.showtime_id = $2
-		AND b.booking_status = 'confirmed'
-		AND b.booking_id != $1
-		LIMIT 1
-	`
-	var conflictSeatID int
-	err = h.db.QueryRow(conflictQuery, bookingID, showtimeID).Scan(&conflictSeatID)
-	if err == nil {
-		// มีที่นั่งถูก confirm ไปแล้วโดยคนอื่น → ยกเลิกการจองนี้โดยอัตโนมัติ
-		cancelQuery := `
-			UPDATE bookings 
-			SET booking_status = 'cancelled', updated_at = CURRENT_TIMESTAMP
-			WHERE booking_id = $1
-		`
-		h.db.Exec(cancelQuery, bookingID)
-
-		// คืน seat_status กลับเป็น available
-		releaseSeatQuery := `
-			UPDATE seat_status 
-			SET status = 'available', booking_id = NULL, reserved_until = NULL
-			WHERE booking_id = $1
-		`
-		h.db.Exec(releaseSeatQuery, bookingID)
-
-		c.JSON(http.StatusConflict, models.ErrorResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Seat %d has already been confirmed by another booking. Your booking has been automatically cancelled.", conflictSeatID),
-		})
-		return
-	}
-	// ถ้า err == sql.ErrNoRows แปลว่าไม่มี conflict ให้ทำต่อได้
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Payment confirmed successfully",
+		Data: gin.H{
+			"booking_id":     bookingID,
+			"payment_status": "paid",
+			"booking_status": "confirmed",
+			"payment_id":     result.PaymentID,
+			"payment_method": result.PaymentMethod,
+			"amount":         result.Amount,
+		},
+	})
+}
 
-	// อัปเดต payment_status และ booking_status
-	updateQuery := `
-		UPDATE bookings 
-		SET payment_status = 'paid', booking_status = 'confirmed', updated_at = CURRENT_TIMESTAMP
-		WHERE booking_id = $1
-	`
-	result, err := h.db.Exec(updateQuery, bookingID)
+// ReconcileAvailableSeats (Admin) ตรวจและแก้ available_seats ของทุกรอบฉายให้ตรงกับที่นั่งว่างจริง
+// ?dry_run=true รายงานอย่างเดียวโดยไม่แก้
+// POST /api/admin/showtimes/reconcile-seats
+func (h *BookingHandler) ReconcileAvailableSeats(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+	drifts, err := h.bookingService.ReconcileAvailableSeats(c.Request.Context(), dryRun)
 	if err != nil {
+		log.Printf("Reconcile available seats error: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
-			Error:   "Failed to confirm payment",
+			Error:   "Failed to reconcile available seats",
 		})
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to update booking",
-		})
-		return
+	message := fmt.Sprintf("Fixed available seats of %d showtimes", len(drifts))
+	if dryRun {
+		message = fmt.Sprintf("Found %d showtimes with drifted available seats", len(drifts))
 	}
-
-	// อัปเดต seat_status เป็น 'booked' สำหรับที่นั่งทั้งหมดในการจองนี้
-	updateSeatStatusQuery := `
-		UPDATE seat_status 
-		SET status = 'booked', reserved_until = NULL
-		WHERE booking_id = $1
-	`
-	_, err = h.db.Exec(updateSeatStatusQuery, bookingID)
-	if err != nil {
-		// Log error but don't fail the request since booking is already confirmed
-		fmt.Printf("Warning: Failed to update seat status for booking %d: %v\n", bookingID, err)
-	}
-
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Payment confirmed successfully",
-		Data: gin.H{
-			"booking_id":     bookingID,
-			"payment_status": "paid",
-			"booking_status": "confirmed",
-		},
+		Message: message,
+		Data:    drifts,
 	})
 }
 
@@ -629,12 +387,15 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 			b.booking_id, b.booking_code, b.user_id, b.showtime_id,
 			m.title, c.cinema_name, t.theater_name, 
 			s.show_date, s.show_time, 
-			b.total_amount, b.booking_status, b.payment_status, b.booking_date
+			b.total_amount, b.discount_amount, pc.code,
+			b.booking_status, b.payment_status, b.booking_date
 		FROM bookings b
 		JOIN showtimes s ON b.showtime_id = s.showtime_id
 		JOIN movies m ON s.movie_id = m.movie_id
 		JOIN theaters t ON s.theater_id = t.theater_id
 		JOIN cinemas c ON t.cinema_id = c.cinema_id
+		LEFT JOIN promo_redemptions pr ON pr.booking_id = b.booking_id
+		LEFT JOIN promo_codes pc ON pr.promo_id = pc.promo_id
 		WHERE b.user_id = $1 AND b.booking_status IN ('pending', 'confirmed')
 		ORDER BY b.booking_date DESC
 	`
@@ -662,6 +423,8 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 		var showDate sql.NullTime
 		var showTime sql.NullString
 		var totalAmount float64
+		var discountAmount float64
+		var promoCode *string
 		var bookingStatus string
 		var paymentStatus string
 		var bookingDate sql.NullTime
@@ -670,7 +433,8 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 			&bookingID, &bookingCode, &userID, &showtimeID,
 			&movieTitle, &cinemaName, &theaterName,
 			&showDate, &showTime,
-			&totalAmount, &bookingStatus, &paymentStatus, &bookingDate,
+			&totalAmount, &discountAmount, &promoCode,
+			&bookingStatus, &paymentStatus, &bookingDate,
 		)
 		if err != nil {
 			continue
@@ -678,20 +442,23 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 
 		if _, exists := bookingsMap[bookingID]; !exists {
 			bookingsMap[bookingID] = &models.BookingWithDetails{
-				BookingID:     bookingID,
-				BookingCode:   bookingCode,
-				UserID:        userID,
-				ShowtimeID:    showtimeID,
-				MovieTitle:    movieTitle,
-				CinemaName:    cinemaName,
-				TheaterName:   theaterName,
-				ShowDate:      showDate.Time.Format("2006-01-02"),
-				ShowTime:      showTime.String,
-				TotalAmount:   totalAmount,
-				BookingStatus: bookingStatus,
-				PaymentStatus: paymentStatus,
-				BookingDate:   bookingDate.Time,
-				Seats:         []models.SeatInfo{},
+				BookingID:      bookingID,
+				BookingCode:    bookingCode,
+				UserID:         userID,
+				ShowtimeID:     showtimeID,
+				MovieTitle:     movieTitle,
+				CinemaName:     cinemaName,
+				TheaterName:    theaterName,
+				ShowDate:       showDate.Time.Format("2006-01-02"),
+				ShowTime:       showTime.String,
+				TotalAmount:    totalAmount,
+				DiscountAmount: discountAmount,
+				PromoCode:      promoCode,
+				BookingStatus:  bookingStatus,
+				PaymentStatus:  paymentStatus,
+				BookingDate:    bookingDate.Time,
+				Seats:          []models.SeatInfo{},
+				Items:          []models.BookingItem{},
 			}
 		}
 	}
@@ -699,7 +466,7 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 	// ดึงข้อมูลที่นั่งสำหรับแต่ละการจอง
 	for bookingID := range bookingsMap {
 		seatsQuery := `
-			SELECT bs.seat_id, s.seat_row, s.seat_number, bs.price
+			SELECT bs.seat_id, s.seat_row, s.seat_number, bs.ticket_type, bs.price
 			FROM booking_seats bs
 			JOIN seats s ON bs.seat_id = s.seat_id
 			WHERE bs.booking_id = $1
@@ -713,18 +480,24 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 				var seatID int
 				var seatRow string
 				var seatNumber int
+				var ticketType string
 				var price float64
-				err := seatRows.Scan(&seatID, &seatRow, &seatNumber, &price)
+				err := seatRows.Scan(&seatID, &seatRow, &seatNumber, &ticketType, &price)
 				if err == nil {
 					bookingsMap[bookingID].Seats = append(bookingsMap[bookingID].Seats, models.SeatInfo{
 						SeatID:     seatID,
 						SeatRow:    seatRow,
 						SeatNumber: seatNumber,
+						TicketType: ticketType,
 						Price:      price,
 					})
 				}
 			}
 		}
+
+		if items, err := h.bookingItems(bookingID); err == nil {
+			bookingsMap[bookingID].Items = items
+		}
 	}
 
 	bookings := make([]models.BookingWithDetails, 0, len(bookingsMap))
@@ -737,3 +510,182 @@ func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 		Data:    bookings,
 	})
 }
+
+// bookingItems ดึงสินค้าหน้าโรงของการจอง
+func (h *BookingHandler) bookingItems(bookingID int) ([]models.BookingItem, error) {
+	rows, err := h.db.Query(`
+		SELECT bi.concession_id, c.name, bi.quantity, bi.unit_price
+		FROM booking_items bi
+		JOIN concessions c ON bi.concession_id = c.concession_id
+		WHERE bi.booking_id = $1
+		ORDER BY bi.booking_item_id
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.BookingItem{}
+	for rows.Next() {
+		var item models.BookingItem
+		if err := rows.Scan(&item.ConcessionID, &item.Name, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, err
+		}
+		item.Subtotal = item.UnitPrice * float64(item.Quantity)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// respondBookingError แปลง error จาก BookingService เป็น HTTP response
+func respondBookingError(c *gin.Context, err error, fallback string) {
+	var seatConflict *services.SeatConflictError
+	var alreadyConfirmed *services.SeatAlreadyConfirmedError
+	var confirmConflict *services.ConfirmConflictError
+	var promoErr *services.PromoCodeError
+	var ticketErr *services.TicketTypeError
+	var concessionErr *services.ConcessionError
+	var groupErr *services.GroupBookingError
+
+	switch {
+	case errors.As(err, &seatConflict):
+		c.JSON(http.StatusConflict, models.Response{
+			Success: false,
+			Error:   "Some seats are no longer available",
+			Data: gin.H{
+				"seat_ids": seatConflict.SeatIDs,
+			},
+		})
+	case errors.Is(err, services.ErrSeatConflict):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Seats are being booked by another customer, please try again",
+		})
+	case errors.As(err, &alreadyConfirmed):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("You have already confirmed booking for seat %d in this showtime", alreadyConfirmed.SeatID),
+		})
+	case errors.As(err, &confirmConflict):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Seat %d has already been confirmed by another booking. Your booking has been automatically cancelled.", confirmConflict.SeatID),
+		})
+	case errors.As(err, &promoErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Promo code cannot be applied: " + promoErr.Reason,
+		})
+	case errors.As(err, &ticketErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Ticket type %q cannot be used: %s", ticketErr.TicketType, ticketErr.Reason),
+		})
+	case errors.As(err, &concessionErr):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Concession %d cannot be ordered: %s", concessionErr.ConcessionID, concessionErr.Reason),
+		})
+	case errors.As(err, &groupErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Cannot create group booking: " + groupErr.Reason,
+		})
+	case errors.Is(err, services.ErrGroupExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "This booking is already a group booking",
+		})
+	case errors.Is(err, services.ErrNotGroupBooking):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "This booking is not a group booking",
+		})
+	case errors.Is(err, services.ErrGroupBooking):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Group bookings are paid per member via /api/bookings/:id/shares/payment",
+		})
+	case errors.Is(err, services.ErrShareNotFound):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Error:   "You have no share in this booking",
+		})
+	case errors.Is(err, services.ErrShareAlreadyPaid):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Your share has already been paid",
+		})
+	case errors.Is(err, services.ErrShowtimeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+	case errors.Is(err, services.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Booking not found",
+		})
+	case errors.Is(err, services.ErrNotEnoughSeats):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Not enough available seats",
+		})
+	case errors.Is(err, services.ErrInvalidSeats):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Some seats do not belong to this showtime's theater",
+		})
+	case errors.Is(err, services.ErrBookingNotCancellable):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Cannot cancel this booking",
+		})
+	case errors.Is(err, services.ErrBookingCancelled):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Cannot confirm payment for cancelled booking",
+		})
+	case errors.Is(err, services.ErrAlreadyPaid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "This booking has already been paid",
+		})
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Success: false,
+			Error:   "Idempotency-Key has already been used for another booking",
+		})
+	case errors.Is(err, payments.ErrUnsupportedMethod):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Unsupported payment method",
+		})
+	case errors.Is(err, services.ErrBookingNotPending):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Booking is not awaiting payment",
+		})
+	case errors.Is(err, services.ErrHoldExpired):
+		c.JSON(http.StatusGone, models.ErrorResponse{
+			Success: false,
+			Error:   "Seat hold has expired",
+		})
+	case errors.Is(err, services.ErrHoldAlreadyExtended):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Seat hold can only be extended once",
+		})
+	case errors.Is(err, services.ErrPaymentFailed):
+		c.JSON(http.StatusPaymentRequired, models.ErrorResponse{
+			Success: false,
+			Error:   "Payment was not successful",
+		})
+	default:
+		log.Printf("Booking error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
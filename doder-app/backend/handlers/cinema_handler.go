@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"movie-booking-system/models"
+	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,21 +31,36 @@ func (h *CinemaHandler) CreateCinema(c *gin.Context) {
 		return
 	}
 
+	if req.OpensAt == "" {
+		req.OpensAt = "10:00"
+	}
+	if req.ClosesAt == "" {
+		req.ClosesAt = "01:00"
+	}
+
 	query := `
-		INSERT INTO cinemas (cinema_name, address, city, is_active)
-		VALUES ($1, $2, $3, TRUE)
-		RETURNING cinema_id, created_at, updated_at
+		INSERT INTO cinemas (cinema_name, address, city, hold_minutes, opens_at, closes_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+		RETURNING cinema_id, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'), created_at, updated_at
 	`
 
 	var cinema models.Cinema
 	cinema.CinemaName = req.CinemaName
 	cinema.Address = req.Address
 	cinema.City = req.City
+	cinema.HoldMinutes = req.HoldMinutes
 	cinema.IsActive = true
 
-	err := h.db.QueryRow(query, req.CinemaName, req.Address, req.City).
-		Scan(&cinema.CinemaID, &cinema.CreatedAt, &cinema.UpdatedAt)
+	err := h.db.QueryRow(query, req.CinemaName, req.Address, req.City, req.HoldMinutes, req.OpensAt, req.ClosesAt).
+		Scan(&cinema.CinemaID, &cinema.OpensAt, &cinema.ClosesAt, &cinema.CreatedAt, &cinema.UpdatedAt)
 
+	if services.IsInvalidDateTime(err) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "opens_at and closes_at must be HH:MM",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
@@ -65,7 +81,11 @@ func (h *CinemaHandler) CreateCinema(c *gin.Context) {
 func (h *CinemaHandler) GetAllCinemas(c *gin.Context) {
 	isActiveParam := c.Query("is_active")
 
-	query := "SELECT cinema_id, cinema_name, address, city, is_active, created_at, updated_at FROM cinemas"
+	query := `
+		SELECT cinema_id, cinema_name, address, city, hold_minutes, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'),
+		       is_active, created_at, updated_at
+		FROM cinemas
+	`
 	args := []interface{}{}
 
 	if isActiveParam != "" {
@@ -96,6 +116,9 @@ func (h *CinemaHandler) GetAllCinemas(c *gin.Context) {
 			&cinema.CinemaName,
 			&cinema.Address,
 			&cinema.City,
+			&cinema.HoldMinutes,
+			&cinema.OpensAt,
+			&cinema.ClosesAt,
 			&cinema.IsActive,
 			&cinema.CreatedAt,
 			&cinema.UpdatedAt,
@@ -126,7 +149,8 @@ func (h *CinemaHandler) GetCinemaByID(c *gin.Context) {
 	}
 
 	query := `
-		SELECT cinema_id, cinema_name, address, city, is_active, created_at, updated_at
+		SELECT cinema_id, cinema_name, address, city, hold_minutes, to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'),
+		       is_active, created_at, updated_at
 		FROM cinemas
 		WHERE cinema_id = $1
 	`
@@ -137,6 +161,9 @@ func (h *CinemaHandler) GetCinemaByID(c *gin.Context) {
 		&cinema.CinemaName,
 		&cinema.Address,
 		&cinema.City,
+		&cinema.HoldMinutes,
+		&cinema.OpensAt,
+		&cinema.ClosesAt,
 		&cinema.IsActive,
 		&cinema.CreatedAt,
 		&cinema.UpdatedAt,
@@ -205,6 +232,21 @@ func (h *CinemaHandler) UpdateCinema(c *gin.Context) {
 		args = append(args, *req.City)
 		argIndex++
 	}
+	if req.HoldMinutes != nil {
+		query += ", hold_minutes = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.HoldMinutes)
+		argIndex++
+	}
+	if req.OpensAt != nil {
+		query += ", opens_at = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.OpensAt)
+		argIndex++
+	}
+	if req.ClosesAt != nil {
+		query += ", closes_at = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.ClosesAt)
+		argIndex++
+	}
 	if req.IsActive != nil {
 		query += ", is_active = $" + strconv.Itoa(argIndex)
 		args = append(args, *req.IsActive)
@@ -215,6 +257,13 @@ func (h *CinemaHandler) UpdateCinema(c *gin.Context) {
 	args = append(args, cinemaID)
 
 	result, err := h.db.Exec(query, args...)
+	if services.IsInvalidDateTime(err) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "opens_at and closes_at must be HH:MM",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
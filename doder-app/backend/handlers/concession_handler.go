@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConcessionHandler struct {
+	db *sql.DB
+}
+
+func NewConcessionHandler(db *sql.DB) *ConcessionHandler {
+	return &ConcessionHandler{db: db}
+}
+
+const concessionColumns = `
+	concession_id, cinema_id, name, description, category, price, stock_quantity, is_active, created_at, updated_at
+`
+
+// GetCinemaConcessions ดึงสินค้าหน้าโรงที่เปิดขายของสาขา
+// GET /api/cinemas/:id/concessions
+func (h *ConcessionHandler) GetCinemaConcessions(c *gin.Context) {
+	cinemaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid cinema ID",
+		})
+		return
+	}
+
+	h.respondConcessions(c, `
+		SELECT `+concessionColumns+`
+		FROM concessions
+		WHERE cinema_id = $1 AND is_active = TRUE
+		ORDER BY category, name
+	`, cinemaID)
+}
+
+// GetAllConcessions ดึงสินค้าหน้าโรงทั้งหมด กรองตามสาขาได้ด้วย ?cinema_id= (Admin only)
+// GET /api/admin/concessions
+func (h *ConcessionHandler) GetAllConcessions(c *gin.Context) {
+	query := "SELECT " + concessionColumns + " FROM concessions"
+	args := []interface{}{}
+	if cinemaIDParam := c.Query("cinema_id"); cinemaIDParam != "" {
+		cinemaID, err := strconv.Atoi(cinemaIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error:   "Invalid cinema ID",
+			})
+			return
+		}
+		query += " WHERE cinema_id = $1"
+		args = append(args, cinemaID)
+	}
+	query += " ORDER BY cinema_id, category, name"
+
+	h.respondConcessions(c, query, args...)
+}
+
+func (h *ConcessionHandler) respondConcessions(c *gin.Context, query string, args ...interface{}) {
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch concessions",
+		})
+		return
+	}
+	defer rows.Close()
+
+	concessions := []models.Concession{}
+	for rows.Next() {
+		var item models.Concession
+		var description sql.NullString
+		err := rows.Scan(
+			&item.ConcessionID, &item.CinemaID, &item.Name, &description, &item.Category,
+			&item.Price, &item.StockQuantity, &item.IsActive, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to scan concession",
+			})
+			return
+		}
+		if description.Valid {
+			item.Description = &description.String
+		}
+		concessions = append(concessions, item)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    concessions,
+	})
+}
+
+// CreateConcession เพิ่มสินค้าหน้าโรงให้สาขา (Admin only)
+// POST /api/admin/concessions
+func (h *ConcessionHandler) CreateConcession(c *gin.Context) {
+	var req models.CreateConcessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var cinemaExists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM cinemas WHERE cinema_id = $1)", req.CinemaID).Scan(&cinemaExists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch cinema",
+		})
+		return
+	}
+	if !cinemaExists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Cinema not found",
+		})
+		return
+	}
+
+	var concessionID int
+	err = h.db.QueryRow(`
+		INSERT INTO concessions (cinema_id, name, description, category, price, stock_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING concession_id
+	`, req.CinemaID, req.Name, req.Description, req.Category, req.Price, req.StockQuantity).Scan(&concessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to create concession",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Concession created successfully",
+		Data:    gin.H{"concession_id": concessionID},
+	})
+}
+
+// UpdateConcession แก้ไขสินค้าหน้าโรง รวมถึงปรับจำนวนคงเหลือ (Admin only)
+// PUT /api/admin/concessions/:id
+func (h *ConcessionHandler) UpdateConcession(c *gin.Context) {
+	concessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid concession ID",
+		})
+		return
+	}
+
+	var req models.UpdateConcessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	query := "UPDATE concessions SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.Name != nil {
+		query += ", name = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Name)
+		argIndex++
+	}
+	if req.Description != nil {
+		query += ", description = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Description)
+		argIndex++
+	}
+	if req.Category != nil {
+		query += ", category = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Category)
+		argIndex++
+	}
+	if req.Price != nil {
+		query += ", price = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Price)
+		argIndex++
+	}
+	if req.StockQuantity != nil {
+		query += ", stock_quantity = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.StockQuantity)
+		argIndex++
+	}
+	if req.IsActive != nil {
+		query += ", is_active = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.IsActive)
+		argIndex++
+	}
+
+	query += " WHERE concession_id = $" + strconv.Itoa(argIndex)
+	args = append(args, concessionID)
+
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to update concession",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Concession not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Concession updated successfully",
+	})
+}
+
+// DeleteConcession เลิกขายสินค้าหน้าโรง (soft delete) (Admin only)
+// DELETE /api/admin/concessions/:id
+func (h *ConcessionHandler) DeleteConcession(c *gin.Context) {
+	concessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid concession ID",
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE concessions SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE concession_id = $1
+	`, concessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to delete concession",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Concession not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Concession deleted successfully",
+	})
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 
 	"movie-booking-system/models"
+	"movie-booking-system/scheduler"
 	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
@@ -20,7 +24,15 @@ func NewCronHandler(cronService *services.CronService) *CronHandler {
 // GetCronStatus ดูสถานะ cronjob
 // GET /api/admin/cron/status
 func (h *CronHandler) GetCronStatus(c *gin.Context) {
-	status := h.cronService.GetCronStatus()
+	status, err := h.cronService.GetCronStatus(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to fetch cron status: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch cron status",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
@@ -31,10 +43,55 @@ func (h *CronHandler) GetCronStatus(c *gin.Context) {
 // TriggerCancelExpiredReservations เรียกใช้ cronjob ด้วยตนเอง
 // POST /api/admin/cron/cancel-expired
 func (h *CronHandler) TriggerCancelExpiredReservations(c *gin.Context) {
-	go h.cronService.CancelExpiredReservations()
+	h.triggerJob(c, services.JobCancelExpiredReservations, "Cancelled expired reservations")
+}
+
+// RunRetention archive และลบการจองที่ยกเลิกเก่าๆ ด้วยตนเอง (?dry_run=true ดูจำนวนที่จะถูกย้ายโดยไม่แก้ไขข้อมูล)
+// POST /api/admin/cron/retention?dry_run=true
+func (h *CronHandler) RunRetention(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	if !dryRun {
+		h.triggerJob(c, services.JobCancelledBookingRetention, "Archived old cancelled bookings")
+		return
+	}
+
+	result, err := h.cronService.CleanOldCancelledBookings(c.Request.Context(), true)
+	if err != nil {
+		log.Printf("Retention dry run failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to preview retention",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Dry run: no bookings were archived",
+		Data:    result,
+	})
+}
+
+func (h *CronHandler) triggerJob(c *gin.Context, name, message string) {
+	err := h.cronService.TriggerJob(c.Request.Context(), name)
+	if errors.Is(err, scheduler.ErrJobLocked) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Job is already running",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Job %s failed: %v", name, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Job failed: " + err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Triggered auto-cancel expired reservations",
+		Message: message,
 	})
 }
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GroupBookingHandler struct {
+	bookingService *services.BookingService
+}
+
+func NewGroupBookingHandler(bookingService *services.BookingService) *GroupBookingHandler {
+	return &GroupBookingHandler{bookingService: bookingService}
+}
+
+// CreateGroupBooking แบ่งที่นั่งของการจองให้ผู้ใช้อื่น (ค้นจากเบอร์โทร) จ่ายส่วนของตัวเอง
+// POST /api/bookings/:id/group
+func (h *GroupBookingHandler) CreateGroupBooking(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	var req models.CreateGroupBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	group, err := h.bookingService.CreateGroup(c.Request.Context(), bookingID, c.GetInt("user_id"), req.Invites)
+	if err != nil {
+		respondBookingError(c, err, "Failed to create group booking")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Group booking created, each member must pay their share before the hold expires",
+		Data:    group,
+	})
+}
+
+// GetGroupBooking ดูสถานะการจ่ายของแต่ละคนในการจองแบบกลุ่ม (เฉพาะสมาชิกในกลุ่มหรือ admin)
+// GET /api/bookings/:id/group
+func (h *GroupBookingHandler) GetGroupBooking(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	group, err := h.bookingService.Group(c.Request.Context(), bookingID)
+	if err != nil {
+		respondBookingError(c, err, "Failed to fetch group booking")
+		return
+	}
+
+	if c.GetString("role") != "admin" && !isGroupMember(group, c.GetInt("user_id")) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Error:   "You do not have permission to access this booking",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    group,
+	})
+}
+
+// GetMyShares ดึงส่วนที่ผู้ใช้ต้องจ่ายในการจองแบบกลุ่ม (รวมคำเชิญที่ยังไม่ได้จ่าย)
+// GET /api/bookings/my-shares
+func (h *GroupBookingHandler) GetMyShares(c *gin.Context) {
+	shares, err := h.bookingService.UserShares(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		respondBookingError(c, err, "Failed to fetch booking shares")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    shares,
+	})
+}
+
+// PayShare เริ่มชำระเงินส่วนของผู้ใช้ในการจองแบบกลุ่ม ยืนยันผ่าน webhook เหมือนการจองปกติ
+// POST /api/bookings/:id/shares/payment
+func (h *GroupBookingHandler) PayShare(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	// body เป็น optional ถ้าไม่ระบุวิธีชำระเงินจะใช้ PromptPay
+	var req models.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = payments.MethodPromptPay
+	}
+
+	start, err := h.bookingService.StartSharePayment(c.Request.Context(), bookingID, c.GetInt("user_id"), req.PaymentMethod)
+	if err != nil {
+		respondBookingError(c, err, "Failed to start share payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Payment started, waiting for provider confirmation",
+		Data: gin.H{
+			"payment_id":     start.PaymentID,
+			"booking_id":     start.BookingID,
+			"provider":       start.Provider,
+			"provider_ref":   start.ProviderRef,
+			"payment_method": start.Method,
+			"amount":         start.Amount,
+			"status":         start.Status,
+		},
+	})
+}
+
+func isGroupMember(group *models.GroupBooking, userID int) bool {
+	for _, share := range group.Shares {
+		if share.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,10 @@ import (
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		// browser ใส่ header ตอนเปิด WebSocket ไม่ได้ จึงรับ token ผ่าน query string แทน (เฉพาะ WebSocket)
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Success: false,
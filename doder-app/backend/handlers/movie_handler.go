@@ -2,21 +2,24 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strconv"
 
 	"movie-booking-system/models"
+	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 )
 
 type MovieHandler struct {
-	db *sql.DB
+	db              *sql.DB
+	showtimeService *services.ShowtimeService
 }
 
-func NewMovieHandler(db *sql.DB) *MovieHandler {
-	return &MovieHandler{db: db}
+func NewMovieHandler(db *sql.DB, showtimeService *services.ShowtimeService) *MovieHandler {
+	return &MovieHandler{db: db, showtimeService: showtimeService}
 }
 
 // CreateMovie สร้างหนังใหม่
@@ -37,8 +40,8 @@ func (h *MovieHandler) CreateMovie(c *gin.Context) {
 	}
 
 	query := `
-		INSERT INTO movies (title, description, duration, genres, language, subtitle, poster_url, release_date, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, TRUE)
+		INSERT INTO movies (title, description, duration, genres, language, subtitle, poster_url, release_date, age_rating, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE)
 		RETURNING movie_id, created_at, updated_at
 	`
 
@@ -51,12 +54,13 @@ func (h *MovieHandler) CreateMovie(c *gin.Context) {
 	movie.Subtitle = req.Subtitle
 	movie.PosterURL = req.PosterURL
 	movie.ReleaseDate = req.ReleaseDate
+	movie.AgeRating = req.AgeRating
 	movie.IsActive = true
 
 	err := h.db.QueryRow(
 		query,
 		req.Title, req.Description, req.Duration, pq.Array(genres),
-		req.Language, req.Subtitle, req.PosterURL, req.ReleaseDate,
+		req.Language, req.Subtitle, req.PosterURL, req.ReleaseDate, req.AgeRating,
 	).Scan(&movie.MovieID, &movie.CreatedAt, &movie.UpdatedAt)
 
 	if err != nil {
@@ -82,7 +86,7 @@ func (h *MovieHandler) GetAllMovies(c *gin.Context) {
 
 	query := `
 		SELECT movie_id, title, description, duration, genres, language, subtitle, 
-		       poster_url, release_date, is_active, created_at, updated_at
+		       poster_url, release_date, age_rating, is_active, created_at, updated_at
 		FROM movies
 		WHERE 1=1
 	`
@@ -129,6 +133,7 @@ func (h *MovieHandler) GetAllMovies(c *gin.Context) {
 			&movie.Subtitle,
 			&movie.PosterURL,
 			&movie.ReleaseDate,
+			&movie.AgeRating,
 			&movie.IsActive,
 			&movie.CreatedAt,
 			&movie.UpdatedAt,
@@ -160,7 +165,7 @@ func (h *MovieHandler) GetMovieByID(c *gin.Context) {
 
 	query := `
 		SELECT movie_id, title, description, duration, genres, language, subtitle,
-		       poster_url, release_date, is_active, created_at, updated_at
+		       poster_url, release_date, age_rating, is_active, created_at, updated_at
 		FROM movies
 		WHERE movie_id = $1
 	`
@@ -176,6 +181,7 @@ func (h *MovieHandler) GetMovieByID(c *gin.Context) {
 		&movie.Subtitle,
 		&movie.PosterURL,
 		&movie.ReleaseDate,
+		&movie.AgeRating,
 		&movie.IsActive,
 		&movie.CreatedAt,
 		&movie.UpdatedAt,
@@ -225,6 +231,34 @@ func (h *MovieHandler) UpdateMovie(c *gin.Context) {
 		return
 	}
 
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// ความยาวเดิม ใช้เลื่อนเวลาจบของรอบฉายในอนาคตตามส่วนต่าง
+	var oldDuration int
+	err = tx.QueryRow("SELECT duration FROM movies WHERE movie_id = $1 FOR UPDATE", movieID).Scan(&oldDuration)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Movie not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch movie",
+		})
+		return
+	}
+
 	query := "UPDATE movies SET updated_at = CURRENT_TIMESTAMP"
 	args := []interface{}{}
 	argIndex := 1
@@ -269,6 +303,11 @@ func (h *MovieHandler) UpdateMovie(c *gin.Context) {
 		args = append(args, *req.ReleaseDate)
 		argIndex++
 	}
+	if req.AgeRating != nil {
+		query += ", age_rating = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.AgeRating)
+		argIndex++
+	}
 	if req.IsActive != nil {
 		query += ", is_active = $" + strconv.Itoa(argIndex)
 		args = append(args, *req.IsActive)
@@ -278,8 +317,7 @@ func (h *MovieHandler) UpdateMovie(c *gin.Context) {
 	query += " WHERE movie_id = $" + strconv.Itoa(argIndex)
 	args = append(args, movieID)
 
-	result, err := h.db.Exec(query, args...)
-	if err != nil {
+	if _, err := tx.Exec(query, args...); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
 			Error:   "Failed to update movie",
@@ -287,19 +325,38 @@ func (h *MovieHandler) UpdateMovie(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+	var reschedule *models.ShowtimeReschedule
+	if req.Duration != nil {
+		reschedule, err = h.showtimeService.RescheduleMovieShowtimes(c.Request.Context(), tx, movieID, *req.Duration-oldDuration)
+		if err != nil {
+			log.Printf("Reschedule showtimes of movie %d error: %v", movieID, err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to update showtimes for new duration",
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Success: false,
-			Error:   "Movie not found",
+			Error:   "Failed to update movie",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Response{
+	// รอบฉายที่ปรับเวลาไม่ได้ถูกตั้ง needs_review ไว้ ต้องให้ admin ย้ายเอง
+	response := models.Response{
 		Success: true,
 		Message: "Movie updated successfully",
-	})
+	}
+	if reschedule != nil {
+		response.Data = gin.H{
+			"showtimes": reschedule,
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteMovie ลบหนัง (soft delete)
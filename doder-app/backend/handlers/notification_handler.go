@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	db *sql.DB
+}
+
+func NewNotificationHandler(db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+// GetMyNotifications ดึงการแจ้งเตือนล่าสุดของผู้ใช้ (?unread=true เฉพาะที่ยังไม่อ่าน)
+// GET /api/notifications
+func (h *NotificationHandler) GetMyNotifications(c *gin.Context) {
+	query := `
+		SELECT notification_id, user_id, type, title, message, data, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1
+	`
+	if c.Query("unread") == "true" {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC LIMIT 50"
+
+	rows, err := h.db.Query(query, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch notifications",
+		})
+		return
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var data []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.NotificationID, &n.UserID, &n.Type, &n.Title, &n.Message, &data, &readAt, &n.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to scan notification",
+			})
+			return
+		}
+		n.Data = data
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    notifications,
+	})
+}
+
+// MarkNotificationRead ทำเครื่องหมายว่าอ่านการแจ้งเตือนแล้ว
+// PUT /api/notifications/:id/read
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid notification ID",
+		})
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE notification_id = $1 AND user_id = $2
+	`, notificationID, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to update notification",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Notification not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Notification marked as read",
+	})
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+type PaymentHandler struct {
+	bookingService *services.BookingService
+	promptPayID    string
+}
+
+func NewPaymentHandler(bookingService *services.BookingService, promptPayID string) *PaymentHandler {
+	return &PaymentHandler{bookingService: bookingService, promptPayID: promptPayID}
+}
+
+// GetPromptPayQR สร้าง PromptPay QR สำหรับการจองที่รอชำระเงิน
+// GET /api/bookings/:id/payment/promptpay?format=png
+func (h *PaymentHandler) GetPromptPayQR(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	if h.promptPayID == "" {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Success: false,
+			Error:   "PromptPay is not configured",
+		})
+		return
+	}
+
+	pending, err := h.bookingService.PendingPayment(c.Request.Context(), bookingID)
+	if errors.Is(err, services.ErrBookingNotPending) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Booking is not awaiting payment",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrHoldExpired) {
+		c.JSON(http.StatusGone, models.ErrorResponse{
+			Success: false,
+			Error:   "Seat hold has expired",
+		})
+		return
+	}
+	if err != nil {
+		respondBookingError(c, err, "Failed to fetch booking")
+		return
+	}
+
+	reference := payments.PromptPayReference(pending.BookingCode)
+	payload, err := payments.PromptPayPayload(h.promptPayID, pending.TotalAmount, reference)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to build PromptPay payload",
+		})
+		return
+	}
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, 320)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to generate QR code",
+		})
+		return
+	}
+
+	// QR ใช้ได้จนกว่าการถือที่นั่งจะหมดอายุ ห้าม cache เกินเวลานั้น
+	c.Header("Cache-Control", "no-store")
+	c.Header("Expires", pending.ReservedUntil.UTC().Format(http.TimeFormat))
+
+	if c.Query("format") == "png" {
+		c.Data(http.StatusOK, "image/png", png)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"booking_id":   pending.BookingID,
+			"booking_code": pending.BookingCode,
+			"amount":       pending.TotalAmount,
+			"reference":    reference,
+			"payload":      payload,
+			"qr_code":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+			"expires_at":   pending.ReservedUntil.Format(time.RFC3339),
+		},
+	})
+}
+
+// StartPayment เริ่มชำระเงินกับผู้ให้บริการ การจองจะยืนยันเมื่อได้รับ webhook
+// POST /api/bookings/:id/payment
+func (h *PaymentHandler) StartPayment(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	// body เป็น optional ถ้าไม่ระบุวิธีชำระเงินจะใช้ PromptPay
+	var req models.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = payments.MethodPromptPay
+	}
+
+	start, err := h.bookingService.StartPayment(c.Request.Context(), bookingID, req.PaymentMethod)
+	if errors.Is(err, services.ErrBookingNotPending) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Booking is not awaiting payment",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrHoldExpired) {
+		c.JSON(http.StatusGone, models.ErrorResponse{
+			Success: false,
+			Error:   "Seat hold has expired",
+		})
+		return
+	}
+	if err != nil {
+		respondBookingError(c, err, "Failed to start payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Payment started, waiting for provider confirmation",
+		Data: gin.H{
+			"payment_id":     start.PaymentID,
+			"booking_id":     start.BookingID,
+			"provider":       start.Provider,
+			"provider_ref":   start.ProviderRef,
+			"payment_method": start.Method,
+			"amount":         start.Amount,
+			"status":         start.Status,
+		},
+	})
+}
+
+// HandleWebhook รับ webhook จากผู้ให้บริการชำระเงิน (ตรวจสอบ HMAC signature ก่อนประมวลผล)
+// POST /api/payments/webhook/:provider
+func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
+	provider := c.Param("provider")
+	gateway, err := h.bookingService.Gateway(provider)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Unknown payment provider",
+		})
+		return
+	}
+
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to read webhook payload",
+		})
+		return
+	}
+
+	event, err := gateway.VerifyWebhook(payload, c.GetHeader("X-Signature"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid webhook signature",
+		})
+		return
+	}
+
+	result, err := h.bookingService.HandleWebhook(c.Request.Context(), provider, event)
+	switch {
+	case errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Payment not found",
+		})
+		return
+	case errors.Is(err, services.ErrAmountMismatch):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Success: false,
+			Error:   "Paid amount does not match booking total",
+		})
+		return
+	case errors.Is(err, services.ErrPaymentCancelled):
+		// ตอบ 200 เพื่อไม่ให้ผู้ให้บริการส่งซ้ำ เงินถูกคืนแล้วหรือรอ job retry_refunds ส่งซ้ำ (ดู status)
+		c.JSON(http.StatusOK, models.Response{
+			Success: true,
+			Message: "Booking can no longer be confirmed, payment is being refunded",
+			Data: gin.H{
+				"booking_id": result.BookingID,
+				"payment_id": result.PaymentID,
+				"status":     result.Status,
+			},
+		})
+		return
+	case err != nil:
+		log.Printf("Failed to process %s webhook for %s: %v", provider, event.ProviderRef, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to process webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Webhook processed",
+		Data: gin.H{
+			"booking_id": result.BookingID,
+			"payment_id": result.PaymentID,
+			"status":     result.Status,
+			"duplicate":  result.Duplicate,
+		},
+	})
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	pricingService *services.PricingService
+}
+
+func NewPricingHandler(pricingService *services.PricingService) *PricingHandler {
+	return &PricingHandler{pricingService: pricingService}
+}
+
+// GetAllPricingRules ดึงกฎราคาทั้งหมดเรียงตามลำดับที่ใช้ (Admin only)
+// GET /api/admin/pricing-rules
+func (h *PricingHandler) GetAllPricingRules(c *gin.Context) {
+	rules, err := h.pricingService.ListRules(c.Request.Context())
+	if err != nil {
+		respondPricingError(c, err, "Failed to fetch pricing rules")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    rules,
+	})
+}
+
+// GetPricingRule ดึงกฎราคาตาม ID (Admin only)
+// GET /api/admin/pricing-rules/:id
+func (h *PricingHandler) GetPricingRule(c *gin.Context) {
+	ruleID, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.pricingService.GetRule(c.Request.Context(), ruleID)
+	if err != nil {
+		respondPricingError(c, err, "Failed to fetch pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    rule,
+	})
+}
+
+// CreatePricingRule สร้างกฎราคาใหม่ (Admin only)
+// POST /api/admin/pricing-rules
+func (h *PricingHandler) CreatePricingRule(c *gin.Context) {
+	var req models.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.pricingService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		respondPricingError(c, err, "Failed to create pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Pricing rule created successfully",
+		Data:    rule,
+	})
+}
+
+// UpdatePricingRule แก้ไขกฎราคา โดยแทนที่ทั้งกฎด้วยค่าที่ส่งมา (Admin only)
+// PUT /api/admin/pricing-rules/:id
+func (h *PricingHandler) UpdatePricingRule(c *gin.Context) {
+	ruleID, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.pricingService.UpdateRule(c.Request.Context(), ruleID, req)
+	if err != nil {
+		respondPricingError(c, err, "Failed to update pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Pricing rule updated successfully",
+		Data:    rule,
+	})
+}
+
+// DeletePricingRule ลบกฎราคา (Admin only)
+// DELETE /api/admin/pricing-rules/:id
+func (h *PricingHandler) DeletePricingRule(c *gin.Context) {
+	ruleID, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.pricingService.DeleteRule(c.Request.Context(), ruleID); err != nil {
+		respondPricingError(c, err, "Failed to delete pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Pricing rule deleted successfully",
+	})
+}
+
+// PreviewShowtimePrice ดูราคาของทุกประเภทที่นั่งในรอบฉายตามกฎราคาปัจจุบัน พร้อมกฎที่ถูกใช้ (Admin only)
+// GET /api/admin/showtimes/:id/price-preview
+func (h *PricingHandler) PreviewShowtimePrice(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+
+	preview, err := h.pricingService.Preview(c.Request.Context(), showtimeID)
+	if err != nil {
+		respondPricingError(c, err, "Failed to preview showtime price")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    preview,
+	})
+}
+
+// GetAllHolidays ดึงวันหยุดทั้งหมดที่ใช้กับกฎราคา (Admin only)
+// GET /api/admin/holidays
+func (h *PricingHandler) GetAllHolidays(c *gin.Context) {
+	holidays, err := h.pricingService.ListHolidays(c.Request.Context())
+	if err != nil {
+		respondPricingError(c, err, "Failed to fetch holidays")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    holidays,
+	})
+}
+
+// CreateHoliday เพิ่มวันหยุด (Admin only)
+// POST /api/admin/holidays
+func (h *PricingHandler) CreateHoliday(c *gin.Context) {
+	var req models.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	holiday, err := h.pricingService.AddHoliday(c.Request.Context(), req)
+	if err != nil {
+		respondPricingError(c, err, "Failed to create holiday")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Holiday created successfully",
+		Data:    holiday,
+	})
+}
+
+// DeleteHoliday ลบวันหยุด (Admin only)
+// DELETE /api/admin/holidays/:date
+func (h *PricingHandler) DeleteHoliday(c *gin.Context) {
+	date := c.Param("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid date format. Use YYYY-MM-DD",
+		})
+		return
+	}
+
+	if err := h.pricingService.DeleteHoliday(c.Request.Context(), date); err != nil {
+		respondPricingError(c, err, "Failed to delete holiday")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Holiday deleted successfully",
+	})
+}
+
+func pricingRuleID(c *gin.Context) (int, bool) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid pricing rule ID",
+		})
+		return 0, false
+	}
+	return ruleID, true
+}
+
+// respondPricingError แปลง error จาก PricingService เป็น HTTP response
+func respondPricingError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrShowtimeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+	case errors.Is(err, services.ErrPricingRuleNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Pricing rule not found",
+		})
+	case errors.Is(err, services.ErrInvalidPricingRule):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	case errors.Is(err, services.ErrHolidayNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Holiday not found",
+		})
+	case errors.Is(err, services.ErrHolidayExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Holiday already exists",
+		})
+	default:
+		log.Printf("Pricing error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromoHandler struct {
+	promoService *services.PromoService
+}
+
+func NewPromoHandler(promoService *services.PromoService) *PromoHandler {
+	return &PromoHandler{promoService: promoService}
+}
+
+// ValidatePromoCode ตรวจโค้ดส่วนลดกับที่นั่งที่เลือกและคืนยอดหลังหักส่วนลด (ยังไม่ใช้สิทธิ์)
+// POST /api/promo-codes/validate
+func (h *PromoHandler) ValidatePromoCode(c *gin.Context) {
+	var req models.ValidatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if len(req.SeatRequests()) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "seat_ids or seats is required",
+		})
+		return
+	}
+
+	quote, err := h.promoService.Quote(c.Request.Context(), c.GetInt("user_id"), req)
+	if err != nil {
+		respondPromoError(c, err, "Failed to validate promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    quote,
+	})
+}
+
+// GetAllPromoCodes ดึงโค้ดส่วนลดทั้งหมด (Admin only)
+// GET /api/admin/promo-codes
+func (h *PromoHandler) GetAllPromoCodes(c *gin.Context) {
+	promos, err := h.promoService.ListPromoCodes(c.Request.Context())
+	if err != nil {
+		respondPromoError(c, err, "Failed to fetch promo codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    promos,
+	})
+}
+
+// GetPromoCode ดึงโค้ดส่วนลดตาม ID (Admin only)
+// GET /api/admin/promo-codes/:id
+func (h *PromoHandler) GetPromoCode(c *gin.Context) {
+	promoID, ok := promoCodeID(c)
+	if !ok {
+		return
+	}
+
+	promo, err := h.promoService.GetPromoCode(c.Request.Context(), promoID)
+	if err != nil {
+		respondPromoError(c, err, "Failed to fetch promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    promo,
+	})
+}
+
+// CreatePromoCode สร้างโค้ดส่วนลด (Admin only)
+// POST /api/admin/promo-codes
+func (h *PromoHandler) CreatePromoCode(c *gin.Context) {
+	var req models.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.DiscountType == "percentage" && req.DiscountValue > 100 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Percentage discount cannot exceed 100",
+		})
+		return
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && req.ValidUntil.Before(*req.ValidFrom) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "valid_until must be after valid_from",
+		})
+		return
+	}
+
+	promo, err := h.promoService.CreatePromoCode(c.Request.Context(), req)
+	if err != nil {
+		respondPromoError(c, err, "Failed to create promo code")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Promo code created successfully",
+		Data:    promo,
+	})
+}
+
+// UpdatePromoCode แก้ไขโค้ดส่วนลด (Admin only)
+// PUT /api/admin/promo-codes/:id
+func (h *PromoHandler) UpdatePromoCode(c *gin.Context) {
+	promoID, ok := promoCodeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	promo, err := h.promoService.UpdatePromoCode(c.Request.Context(), promoID, req)
+	if err != nil {
+		respondPromoError(c, err, "Failed to update promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Promo code updated successfully",
+		Data:    promo,
+	})
+}
+
+// DeletePromoCode ปิดใช้งานโค้ดส่วนลด (soft delete) (Admin only)
+// DELETE /api/admin/promo-codes/:id
+func (h *PromoHandler) DeletePromoCode(c *gin.Context) {
+	promoID, ok := promoCodeID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.promoService.DeactivatePromoCode(c.Request.Context(), promoID); err != nil {
+		respondPromoError(c, err, "Failed to delete promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Promo code deleted successfully",
+	})
+}
+
+// GetPromoRedemptions รายงานการใช้โค้ดส่วนลด (Admin only)
+// GET /api/admin/promo-codes/:id/redemptions
+func (h *PromoHandler) GetPromoRedemptions(c *gin.Context) {
+	promoID, ok := promoCodeID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.promoService.RedemptionReport(c.Request.Context(), promoID)
+	if err != nil {
+		respondPromoError(c, err, "Failed to fetch promo redemptions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    report,
+	})
+}
+
+func promoCodeID(c *gin.Context) (int, bool) {
+	promoID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid promo code ID",
+		})
+		return 0, false
+	}
+	return promoID, true
+}
+
+// respondPromoError แปลง error จาก PromoService เป็น HTTP response
+func respondPromoError(c *gin.Context, err error, fallback string) {
+	var promoErr *services.PromoCodeError
+	var ticketErr *services.TicketTypeError
+
+	switch {
+	case errors.As(err, &promoErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Promo code cannot be applied: " + promoErr.Reason,
+		})
+	case errors.As(err, &ticketErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Ticket type %q cannot be used: %s", ticketErr.TicketType, ticketErr.Reason),
+		})
+	case errors.Is(err, services.ErrPromoCodeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Promo code not found",
+		})
+	case errors.Is(err, services.ErrPromoCodeExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Promo code already exists",
+		})
+	case errors.Is(err, services.ErrShowtimeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+	case errors.Is(err, services.ErrInvalidSeats):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Some seats do not belong to this showtime's theater",
+		})
+	default:
+		log.Printf("Promo code error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	refundService *services.RefundService
+}
+
+func NewRefundHandler(refundService *services.RefundService) *RefundHandler {
+	return &RefundHandler{refundService: refundService}
+}
+
+// RequestRefund ลูกค้าขอคืนเงินสำหรับการจองที่ชำระเงินแล้ว
+// POST /api/bookings/:id/refund
+func (h *RefundHandler) RequestRefund(c *gin.Context) {
+	bookingID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid booking ID",
+		})
+		return
+	}
+
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.refundService.RequestRefund(c.Request.Context(), bookingID, c.GetInt("user_id"), req.Reason)
+	if err != nil {
+		respondRefundError(c, err, "Failed to create refund request")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Refund request submitted",
+		Data:    refund,
+	})
+}
+
+// GetAllRefunds ดึงคำขอคืนเงินทั้งหมด (Admin only)
+// GET /api/admin/refunds?status=pending
+func (h *RefundHandler) GetAllRefunds(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != "pending" && status != "refunding" && status != "refunded" && status != "rejected" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid status filter",
+		})
+		return
+	}
+
+	refunds, err := h.refundService.ListRefunds(c.Request.Context(), status)
+	if err != nil {
+		respondRefundError(c, err, "Failed to fetch refund requests")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    refunds,
+	})
+}
+
+// ApproveRefund อนุมัติคำขอคืนเงิน คืนเงินผ่าน gateway และคืนที่นั่ง (Admin only)
+// PUT /api/admin/refunds/:id/approve
+func (h *RefundHandler) ApproveRefund(c *gin.Context) {
+	h.review(c, h.refundService.ApproveRefund, "Refund approved")
+}
+
+// RejectRefund ปฏิเสธคำขอคืนเงิน (Admin only)
+// PUT /api/admin/refunds/:id/reject
+func (h *RefundHandler) RejectRefund(c *gin.Context) {
+	h.review(c, h.refundService.RejectRefund, "Refund rejected")
+}
+
+type refundReviewFunc func(ctx context.Context, refundID, adminID int, note *string) (*models.RefundRequest, error)
+
+func (h *RefundHandler) review(c *gin.Context, reviewFn refundReviewFunc, message string) {
+	refundID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid refund ID",
+		})
+		return
+	}
+
+	var req models.ReviewRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	refund, err := reviewFn(c.Request.Context(), refundID, c.GetInt("user_id"), req.AdminNote)
+	if err != nil {
+		respondRefundError(c, err, "Failed to review refund request")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: message,
+		Data:    refund,
+	})
+}
+
+// respondRefundError แปลง error จาก RefundService เป็น HTTP response
+func respondRefundError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Booking not found",
+		})
+	case errors.Is(err, services.ErrRefundNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Refund request not found",
+		})
+	case errors.Is(err, services.ErrBookingNotRefundable):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Only confirmed and paid bookings can be refunded",
+		})
+	case errors.Is(err, services.ErrRefundCutoffPassed):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Refunds are no longer available for this showtime",
+		})
+	case errors.Is(err, services.ErrRefundAlreadyRequested):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "A refund request for this booking is already pending",
+		})
+	case errors.Is(err, services.ErrRefundAlreadyReviewed):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "This refund request has already been reviewed",
+		})
+	case errors.Is(err, services.ErrPaymentFailed):
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Success: false,
+			Error:   "Payment provider refused the refund",
+		})
+	default:
+		log.Printf("Refund error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
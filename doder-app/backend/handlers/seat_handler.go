@@ -6,16 +6,19 @@ import (
 	"strconv"
 
 	"movie-booking-system/models"
+	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type SeatHandler struct {
-	db *sql.DB
+	db             *sql.DB
+	selections     *services.SeatSelections
+	pricingService *services.PricingService
 }
 
-func NewSeatHandler(db *sql.DB) *SeatHandler {
-	return &SeatHandler{db: db}
+func NewSeatHandler(db *sql.DB, selections *services.SeatSelections, pricingService *services.PricingService) *SeatHandler {
+	return &SeatHandler{db: db, selections: selections, pricingService: pricingService}
 }
 
 // CreateSeat สร้างที่นั่งทีละตัว
@@ -477,6 +480,20 @@ func (h *SeatHandler) GetSeatStatusByShowtime(c *gin.Context) {
 		return
 	}
 
+	// ราคาของแต่ละที่นั่งตามประเภทที่นั่งและกฎราคา
+	seatPrices, err := h.pricingService.SeatPrices(c.Request.Context(), showtimeID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch seat prices",
+		})
+		return
+	}
+	priceBySeat := make(map[int]float64, len(seatPrices))
+	for _, p := range seatPrices {
+		priceBySeat[p.SeatID] = p.Price
+	}
+
 	// ดึงที่นั่งทั้งหมดของ theater พร้อมสถานะจาก seat_status
 	query := `
 		SELECT 
@@ -499,13 +516,17 @@ func (h *SeatHandler) GetSeatStatusByShowtime(c *gin.Context) {
 	defer rows.Close()
 
 	type SeatWithStatus struct {
-		SeatID     int    `json:"seat_id"`
-		SeatRow    string `json:"seat_row"`
-		SeatNumber int    `json:"seat_number"`
-		SeatType   string `json:"seat_type"`
-		Status     string `json:"status"`
+		SeatID     int     `json:"seat_id"`
+		SeatRow    string  `json:"seat_row"`
+		SeatNumber int     `json:"seat_number"`
+		SeatType   string  `json:"seat_type"`
+		Status     string  `json:"status"`
+		Price      float64 `json:"price"`
 	}
 
+	// ที่นั่งว่างที่มีคนกำลังเลือกอยู่ (ล็อกชั่วคราว ไม่อยู่ใน seat_status) แสดงเป็น selecting
+	selecting := h.selections.Selecting(showtimeID)
+
 	seats := []SeatWithStatus{}
 	for rows.Next() {
 		var seat SeatWithStatus
@@ -519,6 +540,10 @@ func (h *SeatHandler) GetSeatStatusByShowtime(c *gin.Context) {
 		if err != nil {
 			continue
 		}
+		seat.Price = priceBySeat[seat.SeatID]
+		if seat.Status == "available" && selecting[seat.SeatID] {
+			seat.Status = "selecting"
+		}
 		seats = append(seats, seat)
 	}
 
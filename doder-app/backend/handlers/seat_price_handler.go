@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seatPriceScope ระดับที่กำหนดราคา (theater หรือ showtime)
+type seatPriceScope struct {
+	column string // คอลัมน์ใน seat_prices
+	table  string // ตารางที่ต้องมี id อยู่จริง
+	name   string // ใช้ในข้อความ error
+}
+
+var (
+	theaterPriceScope  = seatPriceScope{column: "theater_id", table: "theaters", name: "Theater"}
+	showtimePriceScope = seatPriceScope{column: "showtime_id", table: "showtimes", name: "Showtime"}
+)
+
+type SeatPriceHandler struct {
+	db             *sql.DB
+	pricingService *services.PricingService
+}
+
+func NewSeatPriceHandler(db *sql.DB, pricingService *services.PricingService) *SeatPriceHandler {
+	return &SeatPriceHandler{db: db, pricingService: pricingService}
+}
+
+// GetShowtimeSeatPrices ดึงราคาที่ใช้จริงของแต่ละประเภทที่นั่งในรอบฉาย (หลังใช้กฎราคาแล้ว)
+// GET /api/showtimes/:id/seat-prices
+func (h *SeatPriceHandler) GetShowtimeSeatPrices(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM showtimes WHERE showtime_id = $1 AND is_active = TRUE)", showtimeID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch showtime",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+		return
+	}
+
+	preview, err := h.pricingService.Preview(c.Request.Context(), showtimeID)
+	if err != nil {
+		respondPricingError(c, err, "Failed to fetch seat prices")
+		return
+	}
+
+	prices := make([]models.EffectiveSeatPrice, 0, len(preview.Prices))
+	for _, p := range preview.Prices {
+		prices = append(prices, models.EffectiveSeatPrice{
+			SeatType:     p.SeatType,
+			BasePrice:    p.BasePrice,
+			Price:        p.FinalPrice,
+			TicketPrices: p.TicketPrices,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    prices,
+	})
+}
+
+// GetTheaterSeatPrices ดึงราคาตามประเภทที่นั่งที่กำหนดไว้ระดับโรง
+// GET /api/admin/theaters/:id/seat-prices
+func (h *SeatPriceHandler) GetTheaterSeatPrices(c *gin.Context) {
+	h.listPrices(c, theaterPriceScope)
+}
+
+// SetTheaterSeatPrices กำหนดราคาตามประเภทที่นั่งระดับโรง (ทับราคาเดิมของประเภทที่ส่งมา)
+// PUT /api/admin/theaters/:id/seat-prices
+func (h *SeatPriceHandler) SetTheaterSeatPrices(c *gin.Context) {
+	h.setPrices(c, theaterPriceScope)
+}
+
+// DeleteTheaterSeatPrice ลบราคาระดับโรงของประเภทที่นั่ง (กลับไปใช้ showtimes.price)
+// DELETE /api/admin/theaters/:id/seat-prices/:seat_type
+func (h *SeatPriceHandler) DeleteTheaterSeatPrice(c *gin.Context) {
+	h.deletePrice(c, theaterPriceScope)
+}
+
+// GetShowtimeSeatPriceOverrides ดึงราคาตามประเภทที่นั่งที่กำหนดไว้เฉพาะรอบฉาย
+// GET /api/admin/showtimes/:id/seat-prices
+func (h *SeatPriceHandler) GetShowtimeSeatPriceOverrides(c *gin.Context) {
+	h.listPrices(c, showtimePriceScope)
+}
+
+// SetShowtimeSeatPrices กำหนดราคาตามประเภทที่นั่งเฉพาะรอบฉาย (มีผลเหนือราคาระดับโรง)
+// PUT /api/admin/showtimes/:id/seat-prices
+func (h *SeatPriceHandler) SetShowtimeSeatPrices(c *gin.Context) {
+	h.setPrices(c, showtimePriceScope)
+}
+
+// DeleteShowtimeSeatPrice ลบราคาเฉพาะรอบฉายของประเภทที่นั่ง (กลับไปใช้ราคาระดับโรง)
+// DELETE /api/admin/showtimes/:id/seat-prices/:seat_type
+func (h *SeatPriceHandler) DeleteShowtimeSeatPrice(c *gin.Context) {
+	h.deletePrice(c, showtimePriceScope)
+}
+
+func (h *SeatPriceHandler) scopeID(c *gin.Context, scope seatPriceScope) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid " + scope.name + " ID",
+		})
+		return 0, false
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM "+scope.table+" WHERE "+scope.column+" = $1)", id).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch " + scope.table,
+		})
+		return 0, false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   scope.name + " not found",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *SeatPriceHandler) queryPrices(scope seatPriceScope, id int) ([]models.SeatPrice, error) {
+	rows, err := h.db.Query(`
+		SELECT seat_price_id, theater_id, showtime_id, seat_type, price, created_at, updated_at
+		FROM seat_prices
+		WHERE `+scope.column+` = $1
+		ORDER BY seat_type
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []models.SeatPrice{}
+	for rows.Next() {
+		var p models.SeatPrice
+		if err := rows.Scan(&p.SeatPriceID, &p.TheaterID, &p.ShowtimeID, &p.SeatType, &p.Price, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+func (h *SeatPriceHandler) listPrices(c *gin.Context, scope seatPriceScope) {
+	id, ok := h.scopeID(c, scope)
+	if !ok {
+		return
+	}
+
+	prices, err := h.queryPrices(scope, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch seat prices",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    prices,
+	})
+}
+
+func (h *SeatPriceHandler) setPrices(c *gin.Context, scope seatPriceScope) {
+	id, ok := h.scopeID(c, scope)
+	if !ok {
+		return
+	}
+
+	var req models.SetSeatPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// ON CONFLICT ต้องระบุเงื่อนไขของ partial unique index ด้วย
+	upsert := `
+		INSERT INTO seat_prices (` + scope.column + `, seat_type, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (` + scope.column + `, seat_type) WHERE ` + scope.column + ` IS NOT NULL
+		DO UPDATE SET price = EXCLUDED.price, updated_at = CURRENT_TIMESTAMP
+	`
+	for _, p := range req.Prices {
+		if _, err := tx.Exec(upsert, id, p.SeatType, p.Price); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to save seat prices",
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to commit transaction",
+		})
+		return
+	}
+
+	prices, err := h.queryPrices(scope, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch seat prices",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Seat prices updated successfully",
+		Data:    prices,
+	})
+}
+
+func (h *SeatPriceHandler) deletePrice(c *gin.Context, scope seatPriceScope) {
+	id, ok := h.scopeID(c, scope)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM seat_prices WHERE "+scope.column+" = $1 AND seat_type = $2", id, c.Param("seat_type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to delete seat price",
+		})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Seat price not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Seat price deleted successfully",
+	})
+}
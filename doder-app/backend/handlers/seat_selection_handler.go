@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// ข้อความจาก client
+type seatSelectionMessage struct {
+	Type    string `json:"type"` // "select", "deselect", "ping"
+	SeatIDs []int  `json:"seat_ids"`
+}
+
+// ข้อความที่ส่งกลับไปหา client
+type seatSelectionReply struct {
+	Type       string            `json:"type"` // "welcome", "selected", "deselected", "pong", "seat", "error"
+	SessionID  string            `json:"session_id,omitempty"`
+	TTLSeconds int               `json:"ttl_seconds,omitempty"`
+	SeatIDs    []int             `json:"seat_ids,omitempty"`
+	Rejected   []int             `json:"rejected,omitempty"`
+	Selecting  []int             `json:"selecting,omitempty"`
+	Event      *events.SeatEvent `json:"event,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type SeatSelectionHandler struct {
+	bookingService *services.BookingService
+	selections     *services.SeatSelections
+	broker         events.Broker
+}
+
+func NewSeatSelectionHandler(bookingService *services.BookingService, selections *services.SeatSelections, broker events.Broker) *SeatSelectionHandler {
+	return &SeatSelectionHandler{bookingService: bookingService, selections: selections, broker: broker}
+}
+
+// SelectSeats WebSocket สำหรับเลือกที่นั่งชั่วคราวก่อนจอง ที่นั่งที่เลือกจะแสดงเป็น "selecting" ให้คนอื่นเห็น
+// ต้องส่งข้อความ (อย่างน้อย ping) ภายใน ttl_seconds ไม่อย่างนั้น session จะถูกตัดและที่นั่งถูกปล่อย
+// GET /api/showtimes/:id/seats/select (WebSocket, ส่ง token ผ่าน ?token= ได้)
+func (h *SeatSelectionHandler) SelectSeats(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveSession(c, ws, showtimeID, userID)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *SeatSelectionHandler) serveSession(c *gin.Context, ws *websocket.Conn, showtimeID, userID int) {
+	defer ws.Close()
+
+	sessionID, err := newSessionID()
+	if err != nil {
+		log.Printf("Failed to create seat selection session: %v", err)
+		return
+	}
+	// ปล่อยทุกที่นั่งที่เลือกไว้เมื่อ connection หลุด
+	defer h.selections.ReleaseSession(showtimeID, sessionID)
+
+	seatEvents, unsubscribe := h.broker.Subscribe(showtimeID)
+	defer unsubscribe()
+
+	ttl := h.selections.TTL()
+	err = websocket.JSON.Send(ws, seatSelectionReply{
+		Type:       "welcome",
+		SessionID:  sessionID,
+		TTLSeconds: int(ttl.Seconds()),
+		Selecting:  sortedSeatIDs(h.selections.Selecting(showtimeID)),
+	})
+	if err != nil {
+		return
+	}
+
+	// อ่านข้อความใน goroutine แยก ส่วนการเขียนทั้งหมดอยู่ใน loop ด้านล่าง
+	messages := make(chan seatSelectionMessage)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(messages)
+		for {
+			ws.SetReadDeadline(time.Now().Add(ttl))
+			var msg seatSelectionMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		var reply seatSelectionReply
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			reply = h.handleMessage(c, showtimeID, sessionID, userID, msg)
+		case event, ok := <-seatEvents:
+			if !ok {
+				return
+			}
+			reply = seatSelectionReply{Type: "seat", Event: &event}
+		}
+
+		if err := websocket.JSON.Send(ws, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (h *SeatSelectionHandler) handleMessage(c *gin.Context, showtimeID int, sessionID string, userID int, msg seatSelectionMessage) seatSelectionReply {
+	// ทุกข้อความนับเป็นการต่ออายุ selection ของ session
+	h.selections.Renew(showtimeID, sessionID)
+
+	switch msg.Type {
+	case "select":
+		rejected, err := h.bookingService.SelectSeats(c.Request.Context(), showtimeID, sessionID, userID, msg.SeatIDs)
+		if errors.Is(err, services.ErrInvalidSeats) {
+			return seatSelectionReply{Type: "error", Error: "Some seats do not belong to this showtime's theater"}
+		}
+		if errors.Is(err, services.ErrTooManySeatsSelected) {
+			return seatSelectionReply{Type: "error", Error: "Too many seats selected", Rejected: rejected}
+		}
+		if err != nil {
+			log.Printf("Seat selection error: %v", err)
+			return seatSelectionReply{Type: "error", Error: "Failed to select seats"}
+		}
+		return seatSelectionReply{Type: "selected", SeatIDs: excludeSeatIDs(msg.SeatIDs, rejected), Rejected: rejected}
+	case "deselect":
+		h.selections.Deselect(showtimeID, sessionID, msg.SeatIDs)
+		return seatSelectionReply{Type: "deselected", SeatIDs: msg.SeatIDs}
+	case "ping":
+		return seatSelectionReply{Type: "pong"}
+	}
+	return seatSelectionReply{Type: "error", Error: "Unknown message type"}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func excludeSeatIDs(seatIDs, excluded []int) []int {
+	skip := make(map[int]bool, len(excluded))
+	for _, seatID := range excluded {
+		skip[seatID] = true
+	}
+	result := []int{}
+	for _, seatID := range seatIDs {
+		if !skip[seatID] {
+			result = append(result, seatID)
+		}
+	}
+	return result
+}
+
+func sortedSeatIDs(seats map[int]bool) []int {
+	seatIDs := make([]int, 0, len(seats))
+	for seatID := range seats {
+		seatIDs = append(seatIDs, seatID)
+	}
+	sort.Ints(seatIDs)
+	return seatIDs
+}
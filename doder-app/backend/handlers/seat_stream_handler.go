@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seatStreamHeartbeat ส่ง comment เป็นระยะกัน proxy ตัด connection ที่เงียบนานเกินไป
+const seatStreamHeartbeat = 25 * time.Second
+
+type SeatStreamHandler struct {
+	db     *sql.DB
+	broker events.Broker
+}
+
+func NewSeatStreamHandler(db *sql.DB, broker events.Broker) *SeatStreamHandler {
+	return &SeatStreamHandler{db: db, broker: broker}
+}
+
+// StreamSeatStatus ส่งการเปลี่ยนสถานะที่นั่งของรอบฉายแบบ real-time ผ่าน Server-Sent Events
+// event: ready (เริ่ม subscribe แล้ว ให้ client โหลดผังที่นั่งจาก /seats ใหม่), reserved, booked, released
+// GET /api/showtimes/:id/seats/stream
+func (h *SeatStreamHandler) StreamSeatStatus(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM showtimes WHERE showtime_id = $1 AND is_active = TRUE)", showtimeID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch showtime",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+		return
+	}
+
+	seatEvents, unsubscribe := h.broker.Subscribe(showtimeID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // ปิด buffering ของ nginx
+
+	heartbeat := time.NewTicker(seatStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	// subscribe ก่อนแล้วค่อยบอก client ให้โหลดผังที่นั่ง จะได้ไม่พลาด event ที่เกิดระหว่างนั้น
+	c.SSEvent("ready", gin.H{"showtime_id": showtimeID})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-seatEvents:
+			if !ok {
+				// broker ปิดหรืออ่านไม่ทัน client จะต่อใหม่เองและโหลดผังที่นั่งใหม่
+				return false
+			}
+			c.SSEvent(event.Status, event)
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
+}
@@ -2,20 +2,27 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 
 	"movie-booking-system/models"
+	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ShowtimeHandler struct {
-	db *sql.DB
+	db              *sql.DB
+	showtimeService *services.ShowtimeService
+	bookingService  *services.BookingService // ยกเลิกการจองทั้งหมดเมื่อยกเลิกรอบฉาย
 }
 
-func NewShowtimeHandler(db *sql.DB) *ShowtimeHandler {
-	return &ShowtimeHandler{db: db}
+func NewShowtimeHandler(db *sql.DB, showtimeService *services.ShowtimeService, bookingService *services.BookingService) *ShowtimeHandler {
+	return &ShowtimeHandler{db: db, showtimeService: showtimeService, bookingService: bookingService}
 }
 
 // CreateShowtime สร้างรอบฉายใหม่
@@ -30,42 +37,9 @@ func (h *ShowtimeHandler) CreateShowtime(c *gin.Context) {
 		return
 	}
 
-	var totalSeats int
-	err := h.db.QueryRow("SELECT total_seats FROM theaters WHERE theater_id = $1", req.TheaterID).Scan(&totalSeats)
+	showtime, err := h.showtimeService.Create(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Error:   "Theater not found",
-		})
-		return
-	}
-
-	query := `
-		INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE)
-		RETURNING showtime_id, created_at, updated_at
-	`
-
-	var showtime models.Showtime
-	showtime.MovieID = req.MovieID
-	showtime.TheaterID = req.TheaterID
-	showtime.ShowDate = req.ShowDate
-	showtime.ShowTime = req.ShowTime
-	showtime.EndTime = req.EndTime
-	showtime.Price = req.Price
-	showtime.AvailableSeats = totalSeats
-	showtime.IsActive = true
-
-	err = h.db.QueryRow(
-		query,
-		req.MovieID, req.TheaterID, req.ShowDate, req.ShowTime, req.EndTime, req.Price, totalSeats,
-	).Scan(&showtime.ShowtimeID, &showtime.CreatedAt, &showtime.UpdatedAt)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Success: false,
-			Error:   "Failed to create showtime",
-		})
+		respondShowtimeError(c, err, "Failed to create showtime")
 		return
 	}
 
@@ -77,19 +51,20 @@ func (h *ShowtimeHandler) CreateShowtime(c *gin.Context) {
 }
 
 // GetAllShowtimes ดึงข้อมูลรอบฉายทั้งหมด พร้อมข้อมูล movie และ theater
-// GET /api/showtimes?movie_id=1&theater_id=1&show_date=2025-11-22&is_active=true
+// GET /api/showtimes?movie_id=1&theater_id=1&show_date=2025-11-22&is_active=true&needs_review=true
 func (h *ShowtimeHandler) GetAllShowtimes(c *gin.Context) {
 	movieIDParam := c.Query("movie_id")
 	theaterIDParam := c.Query("theater_id")
 	showDateParam := c.Query("show_date")
 	isActiveParam := c.Query("is_active")
+	needsReviewParam := c.Query("needs_review")
 
 	query := `
 		SELECT 
 			s.showtime_id, s.movie_id, m.title as movie_title,
 			s.theater_id, t.theater_name, t.cinema_id, c.cinema_name,
 			s.show_date, s.show_time, s.end_time, s.price, s.available_seats,
-			s.is_active, s.created_at, s.updated_at
+			s.is_active, s.needs_review, s.created_at, s.updated_at
 		FROM showtimes s
 		JOIN movies m ON s.movie_id = m.movie_id
 		JOIN theaters t ON s.theater_id = t.theater_id
@@ -132,6 +107,16 @@ func (h *ShowtimeHandler) GetAllShowtimes(c *gin.Context) {
 		}
 	}
 
+	// รอบฉายที่ต้องให้ admin ย้ายเองหลังความยาวภาพยนตร์เปลี่ยน
+	if needsReviewParam != "" {
+		needsReview, err := strconv.ParseBool(needsReviewParam)
+		if err == nil {
+			query += " AND s.needs_review = $" + strconv.Itoa(argIndex)
+			args = append(args, needsReview)
+			argIndex++
+		}
+	}
+
 	query += " ORDER BY s.show_date, s.show_time"
 
 	rows, err := h.db.Query(query, args...)
@@ -161,6 +146,7 @@ func (h *ShowtimeHandler) GetAllShowtimes(c *gin.Context) {
 			&showtime.Price,
 			&showtime.AvailableSeats,
 			&showtime.IsActive,
+			&showtime.NeedsReview,
 			&showtime.CreatedAt,
 			&showtime.UpdatedAt,
 		)
@@ -194,7 +180,7 @@ func (h *ShowtimeHandler) GetShowtimeByID(c *gin.Context) {
 			s.showtime_id, s.movie_id, m.title as movie_title,
 			s.theater_id, t.theater_name, t.cinema_id, c.cinema_name,
 			s.show_date, s.show_time, s.end_time, s.price, s.available_seats,
-			s.is_active, s.created_at, s.updated_at
+			s.is_active, s.needs_review, s.created_at, s.updated_at
 		FROM showtimes s
 		JOIN movies m ON s.movie_id = m.movie_id
 		JOIN theaters t ON s.theater_id = t.theater_id
@@ -217,6 +203,7 @@ func (h *ShowtimeHandler) GetShowtimeByID(c *gin.Context) {
 		&showtime.Price,
 		&showtime.AvailableSeats,
 		&showtime.IsActive,
+		&showtime.NeedsReview,
 		&showtime.CreatedAt,
 		&showtime.UpdatedAt,
 	)
@@ -265,64 +252,43 @@ func (h *ShowtimeHandler) UpdateShowtime(c *gin.Context) {
 		return
 	}
 
-	query := "UPDATE showtimes SET updated_at = CURRENT_TIMESTAMP"
-	args := []interface{}{}
-	argIndex := 1
-
-	if req.ShowDate != nil {
-		query += ", show_date = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.ShowDate)
-		argIndex++
-	}
-	if req.ShowTime != nil {
-		query += ", show_time = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.ShowTime)
-		argIndex++
-	}
-	if req.EndTime != nil {
-		query += ", end_time = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.EndTime)
-		argIndex++
-	}
-	if req.Price != nil {
-		query += ", price = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.Price)
-		argIndex++
-	}
-	if req.IsActive != nil {
-		query += ", is_active = $" + strconv.Itoa(argIndex)
-		args = append(args, *req.IsActive)
-		argIndex++
+	if err := h.showtimeService.Update(c.Request.Context(), showtimeID, req); err != nil {
+		respondShowtimeError(c, err, "Failed to update showtime")
+		return
 	}
 
-	query += " WHERE showtime_id = $" + strconv.Itoa(argIndex)
-	args = append(args, showtimeID)
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Showtime updated successfully",
+	})
+}
 
-	result, err := h.db.Exec(query, args...)
+// GetCancellationImpact (Admin) สรุปการจอง ที่นั่ง ยอดเงินที่ต้องคืน และผู้ใช้ที่ได้รับผลกระทบ ก่อนยกเลิกรอบฉาย
+// GET /api/admin/showtimes/:id/cancellation-impact
+func (h *ShowtimeHandler) GetCancellationImpact(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Failed to update showtime",
+			Error:   "Invalid showtime ID",
 		})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Success: false,
-			Error:   "Showtime not found",
-		})
+	impact, err := h.bookingService.CancellationImpact(c.Request.Context(), showtimeID)
+	if err != nil {
+		respondShowtimeError(c, err, "Failed to summarize cancellation impact")
 		return
 	}
 
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Showtime updated successfully",
+		Data:    impact,
 	})
 }
 
-// DeleteShowtime ลบรอบฉาย (soft delete)
+// DeleteShowtime ยกเลิกรอบฉาย (soft delete) พร้อมยกเลิกและคืนเงินทุกการจอง คืนที่นั่ง และแจ้งลูกค้า
+// body (ไม่บังคับ): {"reason": "..."} แนบไปกับการแจ้งเตือน เรียกซ้ำได้ถ้ายังมีการจองที่ยกเลิกไม่สำเร็จ
 // DELETE /api/admin/showtimes/:id
 func (h *ShowtimeHandler) DeleteShowtime(c *gin.Context) {
 	id := c.Param("id")
@@ -335,27 +301,204 @@ func (h *ShowtimeHandler) DeleteShowtime(c *gin.Context) {
 		return
 	}
 
-	query := "UPDATE showtimes SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE showtime_id = $1"
-	result, err := h.db.Exec(query, showtimeID)
+	var req models.CancelShowtimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	cancellation, err := h.bookingService.CancelShowtime(c.Request.Context(), showtimeID, req.Reason)
+	if errors.Is(err, services.ErrShowtimeCancellationIncomplete) {
+		c.JSON(http.StatusBadGateway, models.Response{
+			Success: false,
+			Error:   fmt.Sprintf("Showtime deleted but %d bookings could not be cancelled, retry to finish", len(cancellation.FailedBookings)),
+			Data:    cancellation,
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		respondShowtimeError(c, err, "Failed to delete showtime")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: fmt.Sprintf("Showtime deleted successfully, %d bookings cancelled", len(cancellation.CancelledBookings)),
+		Data:    cancellation,
+	})
+}
+
+// GenerateShowtimes สร้างรอบฉายซ้ำตามแม่แบบ (โรง วันในสัปดาห์ เวลาเริ่ม) ในช่วงวันที่ (Admin only)
+// dry_run = true ดูรอบที่จะถูกสร้างโดยไม่บันทึก
+// POST /api/admin/showtimes/generate
+func (h *ShowtimeHandler) GenerateShowtimes(c *gin.Context) {
+	var req models.GenerateShowtimesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Failed to delete showtime",
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+	schedule, err := h.showtimeService.Generate(c.Request.Context(), req)
+	if errors.Is(err, services.ErrScheduleConflicts) {
+		c.JSON(http.StatusConflict, models.Response{
 			Success: false,
-			Error:   "Showtime not found",
+			Error:   fmt.Sprintf("%d showtimes overlap existing showtimes, nothing was created (set skip_conflicts to create the rest)", len(schedule.Skipped)),
+			Data:    schedule,
+		})
+		return
+	}
+	if err != nil {
+		respondShowtimeError(c, err, "Failed to generate showtimes")
+		return
+	}
+
+	status := http.StatusCreated
+	message := fmt.Sprintf("Created %d showtimes, skipped %d", len(schedule.Created), len(schedule.Skipped))
+	if schedule.DryRun {
+		status = http.StatusOK
+		message = fmt.Sprintf("Would create %d showtimes, skip %d", len(schedule.Created), len(schedule.Skipped))
+	}
+	c.JSON(status, models.Response{
+		Success: true,
+		Message: message,
+		Data:    schedule,
+	})
+}
+
+// PlanCinemaSchedule เสนอแผนรอบฉายหนึ่งวันของสาขาจากรายชื่อภาพยนตร์ น้ำหนัก และจำนวนรอบขั้นต่ำ (Admin only)
+// ยังไม่สร้างรอบฉาย ส่ง showtimes ของแผนไปที่ /schedule-plan/accept เพื่อสร้างจริง
+// POST /api/admin/cinemas/:id/schedule-plan
+func (h *ShowtimeHandler) PlanCinemaSchedule(c *gin.Context) {
+	cinemaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid cinema ID",
+		})
+		return
+	}
+
+	var req models.SchedulePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
 		})
 		return
 	}
 
+	plan, err := h.showtimeService.PlanDay(c.Request.Context(), cinemaID, req)
+	if err != nil {
+		respondShowtimeError(c, err, "Failed to plan schedule")
+		return
+	}
+
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "Showtime deleted successfully",
+		Message: fmt.Sprintf("Planned %d showtimes", len(plan.Showtimes)),
+		Data:    plan,
+	})
+}
+
+// AcceptCinemaSchedule สร้างรอบฉายตามแผนทั้งหมดใน transaction เดียว ถ้ามีรอบที่ทับจะไม่สร้างเลย (Admin only)
+// POST /api/admin/cinemas/:id/schedule-plan/accept
+func (h *ShowtimeHandler) AcceptCinemaSchedule(c *gin.Context) {
+	cinemaID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid cinema ID",
+		})
+		return
+	}
+
+	var req models.AcceptSchedulePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	showtimes, err := h.showtimeService.AcceptPlan(c.Request.Context(), cinemaID, req)
+	if err != nil {
+		respondShowtimeError(c, err, "Failed to create planned showtimes")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: fmt.Sprintf("Created %d showtimes", len(showtimes)),
+		Data:    showtimes,
 	})
 }
+
+// respondShowtimeError แปลง error จาก ShowtimeService เป็น HTTP response
+func respondShowtimeError(c *gin.Context, err error, fallback string) {
+	var conflictErr *services.ShowtimeConflictError
+	var tooShortErr *services.ShowtimeTooShortError
+	var scheduleErr *services.ScheduleRequestError
+	switch {
+	case errors.Is(err, services.ErrShowtimeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+	case errors.Is(err, services.ErrCinemaNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Cinema not found",
+		})
+	case errors.Is(err, services.ErrTheaterNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Theater not found",
+		})
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Movie not found",
+		})
+	case errors.Is(err, services.ErrInvalidSchedule):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid show_date, show_time or end_time",
+		})
+	case errors.As(err, &scheduleErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   scheduleErr.Reason,
+		})
+	case errors.As(err, &tooShortErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Showtime is %d minutes but the movie runs %d minutes", tooShortErr.ScheduledMinutes, tooShortErr.MovieDuration),
+		})
+	case errors.As(err, &conflictErr):
+		message := "Showtime overlaps another showtime in this theater"
+		if conflictErr.ShowtimeID != 0 {
+			message = fmt.Sprintf("Showtime overlaps showtime %d (%s, %s %s-%s) in this theater",
+				conflictErr.ShowtimeID, conflictErr.MovieTitle, conflictErr.ShowDate, conflictErr.ShowTime, conflictErr.EndTime)
+		}
+		c.JSON(http.StatusConflict, models.Response{
+			Success: false,
+			Error:   message,
+			Data: gin.H{
+				"conflicting_showtime_id": conflictErr.ShowtimeID,
+			},
+		})
+	default:
+		log.Printf("Showtime error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
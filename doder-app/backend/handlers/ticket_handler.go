@@ -0,0 +1,433 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type TicketHandler struct {
+	db *sql.DB
+}
+
+func NewTicketHandler(db *sql.DB) *TicketHandler {
+	return &TicketHandler{db: db}
+}
+
+// GetAllTicketTypes ดึงประเภทตั๋วที่เปิดใช้งาน
+// GET /api/ticket-types
+func (h *TicketHandler) GetAllTicketTypes(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT ticket_type, name, min_age, max_age, is_active, created_at, updated_at
+		FROM ticket_types
+		WHERE is_active = TRUE
+		ORDER BY ticket_type
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch ticket types",
+		})
+		return
+	}
+	defer rows.Close()
+
+	ticketTypes := []models.TicketType{}
+	for rows.Next() {
+		var t models.TicketType
+		var minAge, maxAge sql.NullInt64
+		if err := rows.Scan(&t.TicketType, &t.Name, &minAge, &maxAge, &t.IsActive, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to scan ticket type",
+			})
+			return
+		}
+		if minAge.Valid {
+			v := int(minAge.Int64)
+			t.MinAge = &v
+		}
+		if maxAge.Valid {
+			v := int(maxAge.Int64)
+			t.MaxAge = &v
+		}
+		ticketTypes = append(ticketTypes, t)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    ticketTypes,
+	})
+}
+
+// CreateTicketType สร้างประเภทตั๋ว (Admin only)
+// POST /api/admin/ticket-types
+func (h *TicketHandler) CreateTicketType(c *gin.Context) {
+	var req models.CreateTicketTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.MinAge != nil && req.MaxAge != nil && *req.MaxAge < *req.MinAge {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "max_age must not be less than min_age",
+		})
+		return
+	}
+
+	_, err := h.db.Exec(`
+		INSERT INTO ticket_types (ticket_type, name, min_age, max_age)
+		VALUES ($1, $2, $3, $4)
+	`, req.TicketType, req.Name, req.MinAge, req.MaxAge)
+	if err != nil {
+		if isDuplicateKey(err) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Success: false,
+				Error:   "Ticket type already exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to create ticket type",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Ticket type created successfully",
+		Data:    gin.H{"ticket_type": req.TicketType},
+	})
+}
+
+// UpdateTicketType แก้ไขประเภทตั๋ว (Admin only)
+// PUT /api/admin/ticket-types/:ticket_type
+func (h *TicketHandler) UpdateTicketType(c *gin.Context) {
+	ticketType := c.Param("ticket_type")
+
+	var req models.UpdateTicketTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.IsActive != nil && !*req.IsActive && ticketType == services.DefaultTicketType {
+		respondDefaultTicketType(c)
+		return
+	}
+
+	query := "UPDATE ticket_types SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.Name != nil {
+		query += ", name = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Name)
+		argIndex++
+	}
+	if req.MinAge != nil {
+		query += ", min_age = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.MinAge)
+		argIndex++
+	}
+	if req.MaxAge != nil {
+		query += ", max_age = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.MaxAge)
+		argIndex++
+	}
+	if req.IsActive != nil {
+		query += ", is_active = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.IsActive)
+		argIndex++
+	}
+
+	query += " WHERE ticket_type = $" + strconv.Itoa(argIndex)
+	args = append(args, ticketType)
+
+	result, err := h.db.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to update ticket type",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Ticket type not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Ticket type updated successfully",
+	})
+}
+
+// DeleteTicketType ปิดใช้งานประเภทตั๋ว (soft delete) (Admin only)
+// DELETE /api/admin/ticket-types/:ticket_type
+func (h *TicketHandler) DeleteTicketType(c *gin.Context) {
+	ticketType := c.Param("ticket_type")
+	if ticketType == services.DefaultTicketType {
+		respondDefaultTicketType(c)
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE ticket_types SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE ticket_type = $1
+	`, ticketType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to delete ticket type",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Ticket type not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Ticket type deleted successfully",
+	})
+}
+
+// respondDefaultTicketType ปิดใช้งานประเภทตั๋วเริ่มต้นไม่ได้ เพราะใช้กับที่นั่งที่ไม่ได้ระบุประเภทตั๋วและที่นั่งที่เสนอให้ waitlist
+func respondDefaultTicketType(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.ErrorResponse{
+		Success: false,
+		Error:   "The default ticket type cannot be deactivated",
+	})
+}
+
+// GetAllTicketFares ดึงตารางค่าตั๋วตามประเภทตั๋ว (Admin only)
+// GET /api/admin/ticket-fares
+func (h *TicketHandler) GetAllTicketFares(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT fare_id, ticket_type, cinema_id, seat_type, adjustment_type, adjustment_value, created_at, updated_at
+		FROM ticket_fares
+		ORDER BY ticket_type, cinema_id NULLS FIRST, seat_type NULLS FIRST
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch ticket fares",
+		})
+		return
+	}
+	defer rows.Close()
+
+	fares := []models.TicketFare{}
+	for rows.Next() {
+		var f models.TicketFare
+		var cinemaID sql.NullInt64
+		var seatType sql.NullString
+		if err := rows.Scan(&f.FareID, &f.TicketType, &cinemaID, &seatType, &f.AdjustmentType, &f.AdjustmentValue, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Failed to scan ticket fare",
+			})
+			return
+		}
+		if cinemaID.Valid {
+			v := int(cinemaID.Int64)
+			f.CinemaID = &v
+		}
+		if seatType.Valid {
+			f.SeatType = &seatType.String
+		}
+		fares = append(fares, f)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    fares,
+	})
+}
+
+// CreateTicketFare เพิ่มค่าตั๋วของประเภทตั๋ว (Admin only)
+// POST /api/admin/ticket-fares
+func (h *TicketHandler) CreateTicketFare(c *gin.Context) {
+	var req models.TicketFareRequest
+	if !bindTicketFare(c, &req) {
+		return
+	}
+
+	var fareID int
+	err := h.db.QueryRow(`
+		INSERT INTO ticket_fares (ticket_type, cinema_id, seat_type, adjustment_type, adjustment_value)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING fare_id
+	`, req.TicketType, req.CinemaID, req.SeatType, req.AdjustmentType, req.AdjustmentValue).Scan(&fareID)
+	if err != nil {
+		respondTicketFareError(c, err, "Failed to create ticket fare")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Ticket fare created successfully",
+		Data:    gin.H{"fare_id": fareID},
+	})
+}
+
+// UpdateTicketFare แก้ไขค่าตั๋ว (แทนที่ทั้งแถว) (Admin only)
+// PUT /api/admin/ticket-fares/:id
+func (h *TicketHandler) UpdateTicketFare(c *gin.Context) {
+	fareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ticket fare ID",
+		})
+		return
+	}
+
+	var req models.TicketFareRequest
+	if !bindTicketFare(c, &req) {
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE ticket_fares
+		SET ticket_type = $1, cinema_id = $2, seat_type = $3,
+		    adjustment_type = $4, adjustment_value = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE fare_id = $6
+	`, req.TicketType, req.CinemaID, req.SeatType, req.AdjustmentType, req.AdjustmentValue, fareID)
+	if err != nil {
+		respondTicketFareError(c, err, "Failed to update ticket fare")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Ticket fare not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Ticket fare updated successfully",
+	})
+}
+
+// DeleteTicketFare ลบค่าตั๋ว ประเภทตั๋วนั้นจะกลับไปใช้ราคาเต็ม (Admin only)
+// DELETE /api/admin/ticket-fares/:id
+func (h *TicketHandler) DeleteTicketFare(c *gin.Context) {
+	fareID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ticket fare ID",
+		})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM ticket_fares WHERE fare_id = $1", fareID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   "Failed to delete ticket fare",
+		})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Ticket fare not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Ticket fare deleted successfully",
+	})
+}
+
+func bindTicketFare(c *gin.Context, req *models.TicketFareRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return false
+	}
+	if req.AdjustmentType == "percentage" && (req.AdjustmentValue < -100 || req.AdjustmentValue > 100) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Percentage adjustment must be between -100 and 100",
+		})
+		return false
+	}
+	if req.AdjustmentType == "override" && req.AdjustmentValue < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Override price cannot be negative",
+		})
+		return false
+	}
+	return true
+}
+
+// respondTicketFareError แปลง error จากการบันทึก ticket_fares เป็น HTTP response
+func respondTicketFareError(c *gin.Context, err error, fallback string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Success: false,
+				Error:   "A fare for this ticket type, cinema and seat type already exists",
+			})
+			return
+		case "23503":
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error:   "Unknown ticket type or cinema",
+			})
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Success: false,
+		Error:   fallback,
+	})
+}
+
+// isDuplicateKey ตรวจว่า error มาจาก unique constraint หรือไม่
+func isDuplicateKey(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"movie-booking-system/models"
+	"movie-booking-system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WaitlistHandler struct {
+	waitlistService *services.WaitlistService
+}
+
+func NewWaitlistHandler(waitlistService *services.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{waitlistService: waitlistService}
+}
+
+// JoinWaitlist ลงชื่อรอที่นั่งของรอบฉายที่เต็ม
+// POST /api/showtimes/:id/waitlist
+func (h *WaitlistHandler) JoinWaitlist(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.waitlistService.Join(c.Request.Context(), c.GetInt("user_id"), showtimeID, req.SeatCount)
+	if err != nil {
+		respondWaitlistError(c, err, "Failed to join waitlist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "Joined waitlist, you will be notified when seats are held for you",
+		Data:    entry,
+	})
+}
+
+// GetMyWaitlist ดึงคิวรอที่นั่งของผู้ใช้
+// GET /api/waitlist/my-entries
+func (h *WaitlistHandler) GetMyWaitlist(c *gin.Context) {
+	entries, err := h.waitlistService.UserEntries(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		respondWaitlistError(c, err, "Failed to fetch waitlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// LeaveWaitlist ออกจากคิวรอที่นั่ง
+// DELETE /api/waitlist/:id
+func (h *WaitlistHandler) LeaveWaitlist(c *gin.Context) {
+	entryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid waitlist entry ID",
+		})
+		return
+	}
+
+	if err := h.waitlistService.Leave(c.Request.Context(), entryID, c.GetInt("user_id")); err != nil {
+		respondWaitlistError(c, err, "Failed to leave waitlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "Left waitlist successfully",
+	})
+}
+
+// GetWaitlistDepth ความยาวคิวรอที่นั่งของแต่ละรอบฉาย กรองด้วย ?showtime_id= ได้ (Admin only)
+// GET /api/admin/waitlist
+func (h *WaitlistHandler) GetWaitlistDepth(c *gin.Context) {
+	showtimeID := 0
+	if showtimeIDParam := c.Query("showtime_id"); showtimeIDParam != "" {
+		id, err := strconv.Atoi(showtimeIDParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error:   "Invalid showtime ID",
+			})
+			return
+		}
+		showtimeID = id
+	}
+
+	depths, err := h.waitlistService.Depth(c.Request.Context(), showtimeID)
+	if err != nil {
+		respondWaitlistError(c, err, "Failed to fetch waitlist depth")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    depths,
+	})
+}
+
+// GetShowtimeWaitlist ดึงคิวรอที่นั่งของรอบฉายตามลำดับ (Admin only)
+// GET /api/admin/showtimes/:id/waitlist
+func (h *WaitlistHandler) GetShowtimeWaitlist(c *gin.Context) {
+	showtimeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Invalid showtime ID",
+		})
+		return
+	}
+
+	entries, err := h.waitlistService.ShowtimeEntries(c.Request.Context(), showtimeID)
+	if err != nil {
+		respondWaitlistError(c, err, "Failed to fetch waitlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// respondWaitlistError แปลง error จาก WaitlistService เป็น HTTP response
+func respondWaitlistError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrShowtimeNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime not found",
+		})
+	case errors.Is(err, services.ErrShowtimeStarted):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "Showtime has already started",
+		})
+	case errors.Is(err, services.ErrSeatsAvailable):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Enough seats are available, please book directly",
+		})
+	case errors.Is(err, services.ErrAlreadyWaitlisted):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "You are already on the waitlist for this showtime",
+		})
+	case errors.Is(err, services.ErrWaitlistEntryNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   "Waitlist entry not found",
+		})
+	case errors.Is(err, services.ErrWaitlistEntryNotWaiting):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   "Waitlist entry is no longer waiting, cancel the offered booking instead",
+		})
+	default:
+		log.Printf("Waitlist error: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Success: false,
+			Error:   fallback,
+		})
+	}
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"movie-booking-system/config"
+	"movie-booking-system/events"
+	"movie-booking-system/payments"
 	"movie-booking-system/routes"
 	"movie-booking-system/services"
 
@@ -29,8 +37,9 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -53,11 +62,53 @@ func main() {
 	//Setup routes
 	db := config.GetDB()
 
+	// Payment gateway ตาม PAYMENT_GATEWAY (ตอนนี้มีแค่ fake gateway ที่ทำงานใน process ต้องเปิดด้วย PAYMENT_ALLOW_FAKE_GATEWAY)
+	paymentGateway, err := payments.NewGateway(config.PaymentGateway(), config.PaymentWebhookSecret(), config.AllowFakePaymentGateway())
+	if err != nil {
+		log.Fatal("Failed to set up payment gateway:", err)
+	}
+
+	// Broker กระจายการเปลี่ยนสถานะที่นั่งให้ SSE stream (ภายใน process)
+	seatBroker := events.NewMemoryBroker()
+
+	// ล็อกชั่วคราวขณะลูกค้ากำลังเลือกที่นั่งผ่าน WebSocket
+	seatSelections := services.NewSeatSelections(seatBroker, config.SeatSelectionTTL())
+
+	// คิวรอที่นั่ง ที่นั่งที่ถูกคืนจะถือไว้ให้คิวแรกตาม WAITLIST_OFFER_MINUTES
+	waitlistService := services.NewWaitlistService(db, seatSelections, config.WaitlistOfferDuration())
+
+	// Booking service (ใช้ร่วมกันระหว่าง handler และ cron)
+	bookingService := services.NewBookingService(db, paymentGateway, seatBroker, seatSelections, waitlistService, config.SeatHoldDuration(), config.SeatHoldExtension())
+
+	// Refund service (ขอคืนเงินได้ก่อนรอบฉายตาม REFUND_CUTOFF_MINUTES)
+	refundService := services.NewRefundService(db, paymentGateway, seatBroker, waitlistService, config.RefundCutoff())
+
+	// Pricing service (ราคาตามประเภทที่นั่งและกฎราคา)
+	pricingService := services.NewPricingService(db)
+
+	// Promo codes (ตรวจและใช้โค้ดส่วนลดตอนจอง)
+	promoService := services.NewPromoService(db)
+
+	// Showtime scheduling (คำนวณ end_time จากความยาวหนัง + SHOWTIME_TRAILER_MINUTES และกันรอบฉายทับกัน
+	// ในโรงเดียวกันโดยเว้นเวลาทำความสะอาดตาม SHOWTIME_CLEANING_MINUTES)
+	showtimeService := services.NewShowtimeService(db, config.ShowtimeTrailerPadding(), config.ShowtimeCleaningBuffer())
+
+	// หยุด server และ cron jobs อย่างนุ่มนวลเมื่อได้รับ SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	//Cron Jobs (Auto-cancel expired reservations)
-	cronService := services.NewCronService(db)
-	cronService.StartCronJobs()
+	cronService, err := services.NewCronService(db, bookingService, refundService)
+	if err != nil {
+		log.Fatal("Failed to set up cron jobs:", err)
+	}
+	if err := cronService.StartCronJobs(ctx); err != nil {
+		log.Fatal("Failed to start cron jobs:", err)
+	}
 
-	routes.SetupRoutes(r, db, cronService)
+	go seatSelections.Run(ctx)
+
+	routes.SetupRoutes(r, db, cronService, bookingService, refundService, pricingService, promoService, showtimeService, waitlistService, seatBroker, seatSelections)
 
 	// Start serevr
 	port := os.Getenv("PORT")
@@ -65,9 +116,25 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server is running on port %s", port)
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	// ปิด SSE stream ที่เปิดค้างไว้ ไม่อย่างนั้น Shutdown จะรอจนหมดเวลา
+	srv.RegisterOnShutdown(seatBroker.Close)
+	go func() {
+		log.Printf("Server is running on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
 	}
+
+	// รอให้ job ที่กำลังรันอยู่ทำจนเสร็จก่อนปิด database
+	cronService.Wait()
 }
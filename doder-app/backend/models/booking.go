@@ -3,47 +3,82 @@ package models
 import "time"
 
 type Booking struct {
-	BookingID     int       `json:"booking_id" db:"booking_id"`
-	UserID        int       `json:"user_id" db:"user_id"`
-	ShowtimeID    int       `json:"showtime_id" db:"showtime_id"`
-	BookingDate   time.Time `json:"booking_date" db:"booking_date"`
-	TotalAmount   float64   `json:"total_amount" db:"total_amount"`
-	BookingStatus string    `json:"booking_status" db:"booking_status"`
-	PaymentStatus string    `json:"payment_status" db:"payment_status"`
-	BookingCode   string    `json:"booking_code" db:"booking_code"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	BookingID      int       `json:"booking_id" db:"booking_id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	ShowtimeID     int       `json:"showtime_id" db:"showtime_id"`
+	BookingDate    time.Time `json:"booking_date" db:"booking_date"`
+	TotalAmount    float64   `json:"total_amount" db:"total_amount"`
+	DiscountAmount float64   `json:"discount_amount" db:"discount_amount"`
+	BookingStatus  string    `json:"booking_status" db:"booking_status"`
+	PaymentStatus  string    `json:"payment_status" db:"payment_status"`
+	BookingCode    string    `json:"booking_code" db:"booking_code"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type BookingWithDetails struct {
-	BookingID     int        `json:"booking_id"`
-	BookingCode   string     `json:"booking_code"`
-	UserID        int        `json:"user_id"`
-	ShowtimeID    int        `json:"showtime_id"`
-	MovieTitle    string     `json:"movie_title"`
-	CinemaName    string     `json:"cinema_name"`
-	TheaterName   string     `json:"theater_name"`
-	ShowDate      string     `json:"show_date"`
-	ShowTime      string     `json:"show_time"`
-	TotalAmount   float64    `json:"total_amount"`
-	BookingStatus string     `json:"booking_status"`
-	PaymentStatus string     `json:"payment_status"`
-	BookingDate   time.Time  `json:"booking_date"`
-	Seats         []SeatInfo `json:"seats"`
+	BookingID      int           `json:"booking_id"`
+	BookingCode    string        `json:"booking_code"`
+	UserID         int           `json:"user_id"`
+	ShowtimeID     int           `json:"showtime_id"`
+	MovieTitle     string        `json:"movie_title"`
+	CinemaName     string        `json:"cinema_name"`
+	TheaterName    string        `json:"theater_name"`
+	ShowDate       string        `json:"show_date"`
+	ShowTime       string        `json:"show_time"`
+	TotalAmount    float64       `json:"total_amount"`
+	DiscountAmount float64       `json:"discount_amount"`
+	PromoCode      *string       `json:"promo_code,omitempty"`
+	BookingStatus  string        `json:"booking_status"`
+	PaymentStatus  string        `json:"payment_status"`
+	BookingDate    time.Time     `json:"booking_date"`
+	Seats          []SeatInfo    `json:"seats"`
+	Items          []BookingItem `json:"items"`
 }
 
 type SeatInfo struct {
 	SeatID     int     `json:"seat_id"`
 	SeatRow    string  `json:"seat_row"`
 	SeatNumber int     `json:"seat_number"`
+	TicketType string  `json:"ticket_type"`
 	Price      float64 `json:"price"`
 }
 
+// BookingSeatRequest ที่นั่งหนึ่งที่พร้อมประเภทตั๋ว (ว่าง = adult)
+type BookingSeatRequest struct {
+	SeatID     int    `json:"seat_id" binding:"required"`
+	TicketType string `json:"ticket_type"`
+}
+
+// BookingItemRequest สินค้าหน้าโรงที่สั่งพร้อมการจอง
+type BookingItemRequest struct {
+	ConcessionID int `json:"concession_id" binding:"required"`
+	Quantity     int `json:"quantity" binding:"required,min=1"`
+}
+
+// CreateBookingRequest ส่ง seat_ids (ตั๋วผู้ใหญ่ทั้งหมด) หรือ seats (ระบุประเภทตั๋วรายที่นั่ง) อย่างน้อยหนึ่งอย่าง
+// items (ขนม/เครื่องดื่ม) ไม่บังคับ
 type CreateBookingRequest struct {
-	ShowtimeID int   `json:"showtime_id" binding:"required"`
-	SeatIDs    []int `json:"seat_ids" binding:"required,min=1"`
+	ShowtimeID int                  `json:"showtime_id" binding:"required"`
+	SeatIDs    []int                `json:"seat_ids"`
+	Seats      []BookingSeatRequest `json:"seats" binding:"dive"`
+	Items      []BookingItemRequest `json:"items" binding:"dive"`
+	PromoCode  string               `json:"promo_code"`
+}
+
+// SeatRequests รวม seat_ids และ seats เป็นรายการเดียว
+func (r CreateBookingRequest) SeatRequests() []BookingSeatRequest {
+	return mergeSeatRequests(r.SeatIDs, r.Seats)
+}
+
+func mergeSeatRequests(seatIDs []int, seats []BookingSeatRequest) []BookingSeatRequest {
+	merged := make([]BookingSeatRequest, 0, len(seatIDs)+len(seats))
+	for _, seatID := range seatIDs {
+		merged = append(merged, BookingSeatRequest{SeatID: seatID})
+	}
+	return append(merged, seats...)
 }
 
 type ConfirmPaymentRequest struct {
-	PaymentMethod string `json:"payment_method"` // 'credit_card', 'promptpay', 'cash'
+	PaymentMethod string `json:"payment_method" binding:"omitempty,oneof=credit_card promptpay cash"` // 'credit_card', 'promptpay', 'cash'
 }
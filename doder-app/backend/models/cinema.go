@@ -3,24 +3,33 @@ package models
 import "time"
 
 type Cinema struct {
-	CinemaID   int       `json:"cinema_id" db:"cinema_id"`
-	CinemaName string    `json:"cinema_name" db:"cinema_name"`
-	Address    string    `json:"address" db:"address"`
-	City       string    `json:"city" db:"city"`
-	IsActive   bool      `json:"is_active" db:"is_active"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	CinemaID    int       `json:"cinema_id" db:"cinema_id"`
+	CinemaName  string    `json:"cinema_name" db:"cinema_name"`
+	Address     string    `json:"address" db:"address"`
+	City        string    `json:"city" db:"city"`
+	HoldMinutes *int      `json:"hold_minutes" db:"hold_minutes"` // NULL = ใช้ค่าเริ่มต้นของระบบ
+	OpensAt     string    `json:"opens_at" db:"opens_at"`         // HH:MM
+	ClosesAt    string    `json:"closes_at" db:"closes_at"`       // HH:MM ไม่มากกว่า opens_at = ปิดหลังเที่ยงคืน
+	IsActive    bool      `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type CreateCinemaRequest struct {
-	CinemaName string `json:"cinema_name" binding:"required"`
-	Address    string `json:"address" binding:"required"`
-	City       string `json:"city" binding:"required"`
+	CinemaName  string `json:"cinema_name" binding:"required"`
+	Address     string `json:"address" binding:"required"`
+	City        string `json:"city" binding:"required"`
+	HoldMinutes *int   `json:"hold_minutes" binding:"omitempty,min=1"`
+	OpensAt     string `json:"opens_at"`  // HH:MM ว่าง = 10:00
+	ClosesAt    string `json:"closes_at"` // HH:MM ว่าง = 01:00
 }
 
 type UpdateCinemaRequest struct {
-	CinemaName *string `json:"cinema_name"`
-	Address    *string `json:"address"`
-	City       *string `json:"city"`
-	IsActive   *bool   `json:"is_active"`
+	CinemaName  *string `json:"cinema_name"`
+	Address     *string `json:"address"`
+	City        *string `json:"city"`
+	HoldMinutes *int    `json:"hold_minutes" binding:"omitempty,min=1"`
+	OpensAt     *string `json:"opens_at"`
+	ClosesAt    *string `json:"closes_at"`
+	IsActive    *bool   `json:"is_active"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Concession สินค้าหน้าโรง (ขนม เครื่องดื่ม คอมโบ) ของแต่ละสาขา
+type Concession struct {
+	ConcessionID  int       `json:"concession_id" db:"concession_id"`
+	CinemaID      int       `json:"cinema_id" db:"cinema_id"`
+	Name          string    `json:"name" db:"name"`
+	Description   *string   `json:"description,omitempty" db:"description"`
+	Category      string    `json:"category" db:"category"` // 'snack', 'drink', 'combo'
+	Price         float64   `json:"price" db:"price"`
+	StockQuantity int       `json:"stock_quantity" db:"stock_quantity"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateConcessionRequest struct {
+	CinemaID      int     `json:"cinema_id" binding:"required"`
+	Name          string  `json:"name" binding:"required"`
+	Description   *string `json:"description"`
+	Category      string  `json:"category" binding:"required,oneof=snack drink combo"`
+	Price         float64 `json:"price" binding:"min=0"`
+	StockQuantity int     `json:"stock_quantity" binding:"min=0"`
+}
+
+type UpdateConcessionRequest struct {
+	Name          *string  `json:"name"`
+	Description   *string  `json:"description"`
+	Category      *string  `json:"category" binding:"omitempty,oneof=snack drink combo"`
+	Price         *float64 `json:"price" binding:"omitempty,min=0"`
+	StockQuantity *int     `json:"stock_quantity" binding:"omitempty,min=0"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// BookingItem สินค้าหน้าโรงในการจอง
+type BookingItem struct {
+	ConcessionID int     `json:"concession_id"`
+	Name         string  `json:"name"`
+	Quantity     int     `json:"quantity"`
+	UnitPrice    float64 `json:"unit_price"`
+	Subtotal     float64 `json:"subtotal"`
+}
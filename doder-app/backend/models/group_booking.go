@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// GroupInviteRequest เชิญผู้ใช้ที่ลงทะเบียนแล้ว (ค้นจากเบอร์โทร) ให้จ่ายค่าที่นั่งของตัวเอง
+type GroupInviteRequest struct {
+	Phone   string `json:"phone" binding:"required"`
+	SeatIDs []int  `json:"seat_ids" binding:"required,min=1"`
+}
+
+// CreateGroupBookingRequest แบ่งที่นั่งของการจองให้ผู้ถูกเชิญ ที่นั่งที่เหลือเป็นของผู้จัด
+type CreateGroupBookingRequest struct {
+	Invites []GroupInviteRequest `json:"invites" binding:"required,min=1,dive"`
+}
+
+// BookingShare ส่วนที่ผู้ร่วมจองแต่ละคนต้องจ่าย
+type BookingShare struct {
+	ShareID     int        `json:"share_id"`
+	BookingID   int        `json:"booking_id"`
+	UserID      int        `json:"user_id"`
+	FirstName   string     `json:"first_name"`
+	LastName    string     `json:"last_name"`
+	IsOrganizer bool       `json:"is_organizer"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"` // 'pending', 'paid', 'refunded'
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	Seats       []SeatInfo `json:"seats"`
+}
+
+// GroupBooking การจองแบบกลุ่มพร้อมสถานะการจ่ายของแต่ละคน
+type GroupBooking struct {
+	BookingID     int            `json:"booking_id"`
+	BookingCode   string         `json:"booking_code"`
+	BookingStatus string         `json:"booking_status"`
+	TotalAmount   float64        `json:"total_amount"`
+	PaidAmount    float64        `json:"paid_amount"`
+	ReservedUntil *time.Time     `json:"reserved_until,omitempty"`
+	Shares        []BookingShare `json:"shares"`
+}
+
+// UserBookingShare ส่วนที่ผู้ใช้ต้องจ่ายพร้อมรายละเอียดรอบฉาย (ใช้แสดงคำเชิญ)
+type UserBookingShare struct {
+	BookingShare
+	BookingCode   string     `json:"booking_code"`
+	BookingStatus string     `json:"booking_status"`
+	MovieTitle    string     `json:"movie_title"`
+	CinemaName    string     `json:"cinema_name"`
+	ShowDate      string     `json:"show_date"`
+	ShowTime      string     `json:"show_time"`
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type JobStatus struct {
+	JobName        string     `json:"job_name" db:"job_name"`
+	Schedule       string     `json:"schedule" db:"schedule"`
+	Registered     bool       `json:"registered"` // job นี้ลงทะเบียนใน instance ที่ตอบ request หรือไม่
+	LastRunAt      *time.Time `json:"last_run_at" db:"last_run_at"`
+	LastDurationMs *int64     `json:"last_duration_ms" db:"last_duration_ms"`
+	LastStatus     *string    `json:"last_status" db:"last_status"` // 'succeeded', 'failed'
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+	LastInstance   *string    `json:"last_instance,omitempty" db:"last_instance"`
+	NextRunAt      *time.Time `json:"next_run_at" db:"next_run_at"`
+}
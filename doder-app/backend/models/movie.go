@@ -16,6 +16,7 @@ type Movie struct {
 	Subtitle    *string        `json:"subtitle,omitempty" db:"subtitle"`
 	PosterURL   *string        `json:"poster_url,omitempty" db:"poster_url"`
 	ReleaseDate *string        `json:"release_date,omitempty" db:"release_date"`
+	AgeRating   *string        `json:"age_rating,omitempty" db:"age_rating"` // 'G', '13+', '15+', '18+', '20+'
 	IsActive    bool           `json:"is_active" db:"is_active"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
@@ -30,16 +31,18 @@ type CreateMovieRequest struct {
 	Subtitle    *string  `json:"subtitle"`
 	PosterURL   *string  `json:"poster_url"`
 	ReleaseDate *string  `json:"release_date"`
+	AgeRating   *string  `json:"age_rating" binding:"omitempty,oneof=G 13+ 15+ 18+ 20+"`
 }
 
 type UpdateMovieRequest struct {
 	Title       *string  `json:"title"`
 	Description *string  `json:"description"`
-	Duration    *int     `json:"duration"`
+	Duration    *int     `json:"duration" binding:"omitempty,min=1"`
 	Genres      []string `json:"genres"`
 	Language    *string  `json:"language"`
 	Subtitle    *string  `json:"subtitle"`
 	PosterURL   *string  `json:"poster_url"`
 	ReleaseDate *string  `json:"release_date"`
+	AgeRating   *string  `json:"age_rating" binding:"omitempty,oneof=G 13+ 15+ 18+ 20+"`
 	IsActive    *bool    `json:"is_active"`
 }
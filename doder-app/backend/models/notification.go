@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification การแจ้งเตือนในแอป Data เก็บข้อมูลเพิ่มเติมตามประเภท (เช่น booking_id)
+type Notification struct {
+	NotificationID int             `json:"notification_id" db:"notification_id"`
+	UserID         int             `json:"user_id" db:"user_id"`
+	Type           string          `json:"type" db:"type"`
+	Title          string          `json:"title" db:"title"`
+	Message        string          `json:"message" db:"message"`
+	Data           json.RawMessage `json:"data" db:"data"`
+	ReadAt         *time.Time      `json:"read_at,omitempty" db:"read_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Payment struct {
+	PaymentID   int       `json:"payment_id" db:"payment_id"`
+	BookingID   int       `json:"booking_id" db:"booking_id"`
+	Provider    string    `json:"provider" db:"provider"`
+	ProviderRef string    `json:"provider_ref" db:"provider_ref"`
+	Method      string    `json:"method" db:"method"`
+	Amount      float64   `json:"amount" db:"amount"`
+	Status      string    `json:"status" db:"status"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// PricingRule กฎปรับราคาตามวัน เวลา ประเภทโรง และประเภทที่นั่ง
+// เงื่อนไขที่ไม่ได้กำหนด (nil หรือว่าง) หมายถึงไม่จำกัด
+type PricingRule struct {
+	RuleID          int       `json:"rule_id" db:"rule_id"`
+	Name            string    `json:"name" db:"name"`
+	Description     *string   `json:"description,omitempty" db:"description"`
+	Priority        int       `json:"priority" db:"priority"`
+	AdjustmentType  string    `json:"adjustment_type" db:"adjustment_type"` // 'percentage', 'fixed', 'override'
+	AdjustmentValue float64   `json:"adjustment_value" db:"adjustment_value"`
+	StopProcessing  bool      `json:"stop_processing" db:"stop_processing"`
+	DaysOfWeek      []int64   `json:"days_of_week,omitempty" db:"days_of_week"` // 0 = อาทิตย์ ... 6 = เสาร์
+	Holidays        string    `json:"holidays" db:"holidays"`                   // 'any', 'only', 'include', 'exclude'
+	StartTime       *string   `json:"start_time,omitempty" db:"start_time"`     // HH:MM
+	EndTime         *string   `json:"end_time,omitempty" db:"end_time"`         // HH:MM
+	ValidFrom       *string   `json:"valid_from,omitempty" db:"valid_from"`     // YYYY-MM-DD
+	ValidUntil      *string   `json:"valid_until,omitempty" db:"valid_until"`   // YYYY-MM-DD
+	CinemaID        *int      `json:"cinema_id,omitempty" db:"cinema_id"`
+	TheaterTypes    []string  `json:"theater_types,omitempty" db:"theater_types"`
+	SeatTypes       []string  `json:"seat_types,omitempty" db:"seat_types"`
+	IsActive        bool      `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PricingRuleRequest ใช้ทั้งสร้างและแก้ไขกฎ (แก้ไขจะแทนที่ทั้งกฎ)
+type PricingRuleRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Description     *string  `json:"description"`
+	Priority        *int     `json:"priority"`
+	AdjustmentType  string   `json:"adjustment_type" binding:"required,oneof=percentage fixed override"`
+	AdjustmentValue float64  `json:"adjustment_value"`
+	StopProcessing  bool     `json:"stop_processing"`
+	DaysOfWeek      []int64  `json:"days_of_week" binding:"omitempty,dive,min=0,max=6"`
+	Holidays        string   `json:"holidays" binding:"omitempty,oneof=any only include exclude"`
+	StartTime       *string  `json:"start_time" binding:"omitempty,datetime=15:04"`
+	EndTime         *string  `json:"end_time" binding:"omitempty,datetime=15:04"`
+	ValidFrom       *string  `json:"valid_from" binding:"omitempty,datetime=2006-01-02"`
+	ValidUntil      *string  `json:"valid_until" binding:"omitempty,datetime=2006-01-02"`
+	CinemaID        *int     `json:"cinema_id"`
+	TheaterTypes    []string `json:"theater_types"`
+	SeatTypes       []string `json:"seat_types" binding:"omitempty,dive,oneof=standard premium couple vip"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+type Holiday struct {
+	HolidayDate string    `json:"holiday_date" db:"holiday_date"` // YYYY-MM-DD
+	Name        string    `json:"name" db:"name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateHolidayRequest struct {
+	HolidayDate string `json:"holiday_date" binding:"required,datetime=2006-01-02"`
+	Name        string `json:"name" binding:"required"`
+}
+
+// AppliedPricingRule กฎที่ถูกใช้กับราคา พร้อมราคาก่อนและหลังใช้กฎ
+type AppliedPricingRule struct {
+	RuleID          int     `json:"rule_id"`
+	Name            string  `json:"name"`
+	AdjustmentType  string  `json:"adjustment_type"`
+	AdjustmentValue float64 `json:"adjustment_value"`
+	PriceBefore     float64 `json:"price_before"`
+	PriceAfter      float64 `json:"price_after"`
+}
+
+// SeatTypePricePreview ราคาของประเภทที่นั่งในรอบฉายก่อนและหลังใช้กฎราคา
+type SeatTypePricePreview struct {
+	SeatType     string               `json:"seat_type"`
+	BasePrice    float64              `json:"base_price"`
+	BaseSource   string               `json:"base_source"` // 'showtime', 'theater', 'default'
+	FinalPrice   float64              `json:"final_price"`
+	AppliedRules []AppliedPricingRule `json:"applied_rules"`
+	TicketPrices map[string]float64   `json:"ticket_prices"` // ticket_type -> ราคา (เฉพาะประเภทที่ผ่านเรตอายุ)
+}
+
+// PricePreview ราคาทุกประเภทที่นั่งของรอบฉายตามกฎราคาปัจจุบัน
+type PricePreview struct {
+	ShowtimeID  int                    `json:"showtime_id"`
+	AgeRating   *string                `json:"age_rating,omitempty"`
+	ShowDate    string                 `json:"show_date"`
+	ShowTime    string                 `json:"show_time"`
+	DayOfWeek   int                    `json:"day_of_week"`
+	IsHoliday   bool                   `json:"is_holiday"`
+	TheaterType *string                `json:"theater_type,omitempty"`
+	Prices      []SeatTypePricePreview `json:"prices"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// PromoCode โค้ดส่วนลด เงื่อนไขที่เป็น nil หมายถึงไม่จำกัด
+type PromoCode struct {
+	PromoID        int        `json:"promo_id" db:"promo_id"`
+	Code           string     `json:"code" db:"code"`
+	Description    *string    `json:"description,omitempty" db:"description"`
+	DiscountType   string     `json:"discount_type" db:"discount_type"` // 'percentage', 'fixed'
+	DiscountValue  float64    `json:"discount_value" db:"discount_value"`
+	MaxDiscount    *float64   `json:"max_discount,omitempty" db:"max_discount"`
+	MaxUses        *int       `json:"max_uses,omitempty" db:"max_uses"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user,omitempty" db:"max_uses_per_user"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty" db:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	MovieID        *int       `json:"movie_id,omitempty" db:"movie_id"`
+	CinemaID       *int       `json:"cinema_id,omitempty" db:"cinema_id"`
+	MinSeats       *int       `json:"min_seats,omitempty" db:"min_seats"`
+	IsActive       bool       `json:"is_active" db:"is_active"`
+	UsedCount      int        `json:"used_count"` // นับเฉพาะการจองที่ไม่ถูกยกเลิก
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" binding:"required,max=50"`
+	Description    *string    `json:"description"`
+	DiscountType   string     `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue  float64    `json:"discount_value" binding:"required,gt=0"`
+	MaxDiscount    *float64   `json:"max_discount" binding:"omitempty,gt=0"`
+	MaxUses        *int       `json:"max_uses" binding:"omitempty,min=1"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user" binding:"omitempty,min=1"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MovieID        *int       `json:"movie_id"`
+	CinemaID       *int       `json:"cinema_id"`
+	MinSeats       *int       `json:"min_seats" binding:"omitempty,min=1"`
+}
+
+type UpdatePromoCodeRequest struct {
+	Description    *string    `json:"description"`
+	DiscountValue  *float64   `json:"discount_value" binding:"omitempty,gt=0"`
+	MaxDiscount    *float64   `json:"max_discount" binding:"omitempty,gt=0"`
+	MaxUses        *int       `json:"max_uses" binding:"omitempty,min=1"`
+	MaxUsesPerUser *int       `json:"max_uses_per_user" binding:"omitempty,min=1"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	MinSeats       *int       `json:"min_seats" binding:"omitempty,min=1"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// ValidatePromoCodeRequest ตรวจโค้ดส่วนลดก่อนจอง (ให้หน้า checkout แสดงส่วนลด)
+type ValidatePromoCodeRequest struct {
+	Code       string               `json:"code" binding:"required"`
+	ShowtimeID int                  `json:"showtime_id" binding:"required"`
+	SeatIDs    []int                `json:"seat_ids"`
+	Seats      []BookingSeatRequest `json:"seats" binding:"dive"`
+}
+
+// SeatRequests รวม seat_ids และ seats เป็นรายการเดียว (เหมือน CreateBookingRequest)
+func (r ValidatePromoCodeRequest) SeatRequests() []BookingSeatRequest {
+	return mergeSeatRequests(r.SeatIDs, r.Seats)
+}
+
+// PromoRedemption การใช้โค้ดส่วนลดในการจองหนึ่งครั้ง
+type PromoRedemption struct {
+	RedemptionID   int       `json:"redemption_id"`
+	PromoID        int       `json:"promo_id"`
+	BookingID      int       `json:"booking_id"`
+	BookingCode    string    `json:"booking_code"`
+	BookingStatus  string    `json:"booking_status"`
+	UserID         int       `json:"user_id"`
+	DiscountAmount float64   `json:"discount_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PromoRedemptionReport สรุปการใช้โค้ดส่วนลด
+type PromoRedemptionReport struct {
+	PromoCode     PromoCode         `json:"promo_code"`
+	Redemptions   int               `json:"redemptions"`    // ไม่รวมการจองที่ถูกยกเลิก
+	UniqueUsers   int               `json:"unique_users"`   // ไม่รวมการจองที่ถูกยกเลิก
+	TotalDiscount float64           `json:"total_discount"` // ไม่รวมการจองที่ถูกยกเลิก
+	Items         []PromoRedemption `json:"items"`
+}
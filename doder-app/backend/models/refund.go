@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type RefundRequest struct {
+	RefundID          int        `json:"refund_id" db:"refund_id"`
+	BookingID         int        `json:"booking_id" db:"booking_id"`
+	BookingCode       string     `json:"booking_code" db:"booking_code"`
+	UserID            int        `json:"user_id" db:"user_id"`
+	Reason            *string    `json:"reason,omitempty" db:"reason"`
+	Amount            float64    `json:"amount" db:"amount"`
+	Status            string     `json:"status" db:"status"` // 'pending', 'refunding', 'refunded', 'rejected'
+	AdminNote         *string    `json:"admin_note,omitempty" db:"admin_note"`
+	ReviewedBy        *int       `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ProviderRefundRef *string    `json:"provider_refund_ref,omitempty" db:"provider_refund_ref"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateRefundRequest struct {
+	Reason *string `json:"reason"`
+}
+
+type ReviewRefundRequest struct {
+	AdminNote *string `json:"admin_note"`
+}
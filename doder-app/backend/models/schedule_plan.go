@@ -0,0 +1,71 @@
+package models
+
+// SchedulePlanMovie ภาพยนตร์ที่ต้องการให้ตัวจัดรอบใช้ Weight สูง = ได้รอบมากกว่าและได้ช่วง prime time ก่อน
+type SchedulePlanMovie struct {
+	MovieID     int     `json:"movie_id" binding:"required"`
+	Weight      float64 `json:"weight" binding:"omitempty,gt=0"` // ว่าง = 1
+	MinShowings int     `json:"min_showings" binding:"omitempty,min=0"`
+}
+
+// SchedulePlanRequest ขอแผนรอบฉายหนึ่งวันของสาขา (ยังไม่สร้างรอบฉาย)
+type SchedulePlanRequest struct {
+	ShowDate       string              `json:"show_date" binding:"required"` // YYYY-MM-DD
+	Movies         []SchedulePlanMovie `json:"movies" binding:"required,min=1,dive"`
+	TheaterIDs     []int               `json:"theater_ids"`                               // ว่าง = ทุกโรงที่เปิดอยู่ของสาขา
+	PrimeTimeStart string              `json:"prime_time_start"`                          // HH:MM ว่าง = 18:00
+	PrimeTimeEnd   string              `json:"prime_time_end"`                            // HH:MM ว่าง = 22:00
+	PrimeTimeBoost float64             `json:"prime_time_boost" binding:"omitempty,gt=0"` // ตัวคูณ weight ของรอบที่เริ่มใน prime time ว่าง = 1.5
+}
+
+// PlannedShowtime รอบฉายหนึ่งรอบในแผน
+type PlannedShowtime struct {
+	TheaterID   int    `json:"theater_id"`
+	TheaterName string `json:"theater_name"`
+	MovieID     int    `json:"movie_id"`
+	MovieTitle  string `json:"movie_title"`
+	ShowTime    string `json:"show_time"` // HH:MM
+	EndTime     string `json:"end_time"`  // HH:MM น้อยกว่า show_time = จบหลังเที่ยงคืน
+	PrimeTime   bool   `json:"prime_time"`
+}
+
+// SchedulePlanMovieSummary จำนวนรอบที่แต่ละเรื่องได้ในแผน
+type SchedulePlanMovieSummary struct {
+	MovieID     int    `json:"movie_id"`
+	MovieTitle  string `json:"movie_title"`
+	Showings    int    `json:"showings"`
+	MinShowings int    `json:"min_showings"`
+}
+
+// SchedulePlanTheater เวลาที่โรงว่างเหลือในแผน (ไม่นับรอบฉายที่มีอยู่แล้ว)
+type SchedulePlanTheater struct {
+	TheaterID   int    `json:"theater_id"`
+	TheaterName string `json:"theater_name"`
+	Showings    int    `json:"showings"`
+	IdleMinutes int    `json:"idle_minutes"`
+}
+
+// SchedulePlan แผนรอบฉายที่เสนอ ส่ง showtimes กลับมาที่ accept เพื่อสร้างจริง (แก้ก่อนส่งได้)
+type SchedulePlan struct {
+	CinemaID  int                        `json:"cinema_id"`
+	ShowDate  string                     `json:"show_date"`
+	OpensAt   string                     `json:"opens_at"`
+	ClosesAt  string                     `json:"closes_at"`
+	Showtimes []PlannedShowtime          `json:"showtimes"`
+	Movies    []SchedulePlanMovieSummary `json:"movies"`
+	Theaters  []SchedulePlanTheater      `json:"theaters"`
+	Warnings  []string                   `json:"warnings"`
+}
+
+// AcceptPlanShowtime รอบฉายจากแผนที่จะสร้าง (end_time คำนวณใหม่จากความยาวภาพยนตร์)
+type AcceptPlanShowtime struct {
+	TheaterID int    `json:"theater_id" binding:"required"`
+	MovieID   int    `json:"movie_id" binding:"required"`
+	ShowTime  string `json:"show_time" binding:"required"` // HH:MM
+}
+
+// AcceptSchedulePlanRequest สร้างรอบฉายทั้งหมดของแผนใน transaction เดียว
+type AcceptSchedulePlanRequest struct {
+	ShowDate  string               `json:"show_date" binding:"required"` // YYYY-MM-DD
+	Price     float64              `json:"price" binding:"required,min=0"`
+	Showtimes []AcceptPlanShowtime `json:"showtimes" binding:"required,min=1,dive"`
+}
@@ -16,17 +16,17 @@ type CreateSeatRequest struct {
 	TheaterID  int    `json:"theater_id" binding:"required"`
 	SeatRow    string `json:"seat_row" binding:"required"`
 	SeatNumber int    `json:"seat_number" binding:"required,min=1"`
-	SeatType   string `json:"seat_type"`
+	SeatType   string `json:"seat_type" binding:"omitempty,oneof=standard premium couple vip"`
 }
 
 type CreateSeatsRequest struct {
 	TheaterID   int      `json:"theater_id" binding:"required"`
 	Rows        []string `json:"rows" binding:"required"`
 	SeatsPerRow int      `json:"seats_per_row" binding:"required,min=1"`
-	SeatType    string   `json:"seat_type"`
+	SeatType    string   `json:"seat_type" binding:"omitempty,oneof=standard premium couple vip"`
 }
 
 type UpdateSeatRequest struct {
-	SeatType *string `json:"seat_type"`
+	SeatType *string `json:"seat_type" binding:"omitempty,oneof=standard premium couple vip"`
 	IsActive *bool   `json:"is_active"`
 }
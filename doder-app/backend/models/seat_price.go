@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// SeatPrice ราคาตามประเภทที่นั่ง กำหนดที่ระดับโรง (TheaterID) หรือรอบฉาย (ShowtimeID) อย่างใดอย่างหนึ่ง
+type SeatPrice struct {
+	SeatPriceID int       `json:"seat_price_id" db:"seat_price_id"`
+	TheaterID   *int      `json:"theater_id,omitempty" db:"theater_id"`
+	ShowtimeID  *int      `json:"showtime_id,omitempty" db:"showtime_id"`
+	SeatType    string    `json:"seat_type" db:"seat_type"`
+	Price       float64   `json:"price" db:"price"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SeatTypePrice ราคาของประเภทที่นั่งหนึ่ง
+type SeatTypePrice struct {
+	SeatType string  `json:"seat_type" binding:"required,oneof=standard premium couple vip"`
+	Price    float64 `json:"price" binding:"min=0"`
+}
+
+type SetSeatPricesRequest struct {
+	Prices []SeatTypePrice `json:"prices" binding:"required,min=1,dive"`
+}
+
+// EffectiveSeatPrice ราคาที่ใช้จริงของประเภทที่นั่งในรอบฉาย (หลังใช้กฎราคาแล้ว)
+type EffectiveSeatPrice struct {
+	SeatType     string             `json:"seat_type"`
+	BasePrice    float64            `json:"base_price"`
+	Price        float64            `json:"price"`
+	TicketPrices map[string]float64 `json:"ticket_prices"` // ticket_type -> ราคา
+}
@@ -6,7 +6,7 @@ type SeatStatus struct {
 	SeatStatusID  int        `json:"seat_status_id" db:"seat_status_id"`
 	ShowtimeID    int        `json:"showtime_id" db:"showtime_id"`
 	SeatID        int        `json:"seat_id" db:"seat_id"`
-	Status        string     `json:"status" db:"status"`
+	Status        string     `json:"status" db:"status"` // 'available', 'reserved', 'booked'
 	BookingID     *int       `json:"booking_id,omitempty" db:"booking_id"`
 	ReservedUntil *time.Time `json:"reserved_until,omitempty" db:"reserved_until"`
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
@@ -20,7 +20,7 @@ type SeatStatusWithInfo struct {
 	SeatRow       string     `json:"seat_row" db:"seat_row"`
 	SeatNumber    int        `json:"seat_number" db:"seat_number"`
 	SeatType      string     `json:"seat_type" db:"seat_type"`
-	Status        string     `json:"status" db:"status"`
+	Status        string     `json:"status" db:"status"` // 'available', 'reserved', 'booked' (API แสดง 'selecting' เพิ่มได้ ไม่บันทึกใน DB)
 	BookingID     *int       `json:"booking_id,omitempty" db:"booking_id"`
 	ReservedUntil *time.Time `json:"reserved_until,omitempty" db:"reserved_until"`
 }
@@ -12,6 +12,7 @@ type Showtime struct {
 	Price          float64   `json:"price" db:"price"`
 	AvailableSeats int       `json:"available_seats" db:"available_seats"`
 	IsActive       bool      `json:"is_active" db:"is_active"`
+	NeedsReview    bool      `json:"needs_review" db:"needs_review"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -30,6 +31,7 @@ type ShowtimeWithDetails struct {
 	Price          float64   `json:"price" db:"price"`
 	AvailableSeats int       `json:"available_seats" db:"available_seats"`
 	IsActive       bool      `json:"is_active" db:"is_active"`
+	NeedsReview    bool      `json:"needs_review" db:"needs_review"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -39,7 +41,7 @@ type CreateShowtimeRequest struct {
 	TheaterID int     `json:"theater_id" binding:"required"`
 	ShowDate  string  `json:"show_date" binding:"required"` // YYYY-MM-DD
 	ShowTime  string  `json:"show_time" binding:"required"` // HH:MM
-	EndTime   string  `json:"end_time" binding:"required"`  // HH:MM
+	EndTime   string  `json:"end_time"`                     // HH:MM ว่างได้ คำนวณจากความยาวภาพยนตร์ + SHOWTIME_TRAILER_MINUTES
 	Price     float64 `json:"price" binding:"required,min=0"`
 }
 
@@ -50,3 +52,103 @@ type UpdateShowtimeRequest struct {
 	Price    *float64 `json:"price"`
 	IsActive *bool    `json:"is_active"`
 }
+
+// SeatCountDrift รอบฉายที่ available_seats ไม่ตรงกับที่นั่งว่างจริงจาก seats/seat_status
+type SeatCountDrift struct {
+	ShowtimeID  int  `json:"showtime_id"`
+	StoredSeats int  `json:"stored_available_seats"`
+	ActualSeats int  `json:"actual_available_seats"`
+	Fixed       bool `json:"fixed"`
+}
+
+// ShowtimeReschedule ผลการปรับ end_time ของรอบฉายในอนาคตหลังแก้ความยาวภาพยนตร์
+type ShowtimeReschedule struct {
+	UpdatedShowtimeIDs []int            `json:"updated_showtime_ids"`
+	NeedsReview        []ShowtimeReview `json:"needs_review"`
+}
+
+// ShowtimeReview รอบฉายที่ปรับเวลาจบอัตโนมัติไม่ได้เพราะจะทับรอบอื่น admin ต้องย้ายรอบเอง
+type ShowtimeReview struct {
+	ShowtimeID            int    `json:"showtime_id"`
+	ConflictingShowtimeID int    `json:"conflicting_showtime_id"`
+	Reason                string `json:"reason"`
+}
+
+// GenerateShowtimesRequest สร้างรอบฉายซ้ำของภาพยนตร์หนึ่งเรื่องในหลายโรง ทุกวันที่ตรงกับ weekdays ในช่วงวันที่
+type GenerateShowtimesRequest struct {
+	MovieID    int      `json:"movie_id" binding:"required"`
+	TheaterIDs []int    `json:"theater_ids" binding:"required,min=1"`
+	StartDate  string   `json:"start_date" binding:"required"`                 // YYYY-MM-DD
+	EndDate    string   `json:"end_date" binding:"required"`                   // YYYY-MM-DD (รวมวันนี้ด้วย)
+	Weekdays   []int    `json:"weekdays" binding:"omitempty,dive,min=0,max=6"` // 0 = อาทิตย์ ... 6 = เสาร์ ว่าง = ทุกวัน
+	StartTimes []string `json:"start_times" binding:"required,min=1"`          // HH:MM
+	Price      float64  `json:"price" binding:"required,min=0"`
+	// SkipConflicts ข้ามรอบที่ทับรอบอื่นแล้วสร้างที่เหลือ ถ้า false จะไม่สร้างอะไรเลยเมื่อมีรอบที่ทับ
+	SkipConflicts bool `json:"skip_conflicts"`
+	DryRun        bool `json:"dry_run"`
+}
+
+// GeneratedSchedule ผลการสร้างรอบฉายซ้ำ (dry_run = รอบที่จะถูกสร้าง)
+type GeneratedSchedule struct {
+	DryRun  bool           `json:"dry_run"`
+	Created []Showtime     `json:"created"`
+	Skipped []ShowtimeSkip `json:"skipped"`
+}
+
+// ShowtimeSkip รอบฉายที่สร้างไม่ได้เพราะทับรอบอื่นในโรงเดียวกัน
+type ShowtimeSkip struct {
+	TheaterID             int    `json:"theater_id"`
+	ShowDate              string `json:"show_date"`
+	ShowTime              string `json:"show_time"`
+	EndTime               string `json:"end_time"`
+	ConflictingShowtimeID int    `json:"conflicting_showtime_id"`
+	Reason                string `json:"reason"`
+}
+
+// CancelShowtimeRequest เหตุผลการยกเลิกรอบฉาย (แจ้งลูกค้าในการแจ้งเตือน)
+type CancelShowtimeRequest struct {
+	Reason *string `json:"reason" binding:"omitempty,max=255"`
+}
+
+// ShowtimeCancellationImpact ผลกระทบถ้ายกเลิกรอบฉายนี้ ให้ admin ดูก่อนยืนยัน
+type ShowtimeCancellationImpact struct {
+	ShowtimeID        int     `json:"showtime_id"`
+	MovieTitle        string  `json:"movie_title"`
+	ShowDate          string  `json:"show_date"`
+	ShowTime          string  `json:"show_time"`
+	IsActive          bool    `json:"is_active"`
+	PendingBookings   int     `json:"pending_bookings"`
+	ConfirmedBookings int     `json:"confirmed_bookings"`
+	Seats             int     `json:"seats"`
+	RefundAmount      float64 `json:"refund_amount"`    // เงินที่เก็บแล้วและต้องคืนผ่าน payment gateway
+	WaitlistEntries   int     `json:"waitlist_entries"` // คิวที่ยังรออยู่ (คิวที่ได้ที่นั่งแล้วนับอยู่ใน pending_bookings)
+	AffectedUsers     int     `json:"affected_users"`
+}
+
+// ShowtimeCancellation ผลการยกเลิกรอบฉาย FailedBookings คือการจองที่ยกเลิกไม่สำเร็จ เรียกยกเลิกซ้ำเพื่อทำต่อได้
+type ShowtimeCancellation struct {
+	ShowtimeID        int                  `json:"showtime_id"`
+	Reason            *string              `json:"reason,omitempty"`
+	CancelledBookings []CancelledBooking   `json:"cancelled_bookings"`
+	FailedBookings    []FailedCancellation `json:"failed_bookings"`
+	SeatsReleased     int                  `json:"seats_released"`
+	RefundedAmount    float64              `json:"refunded_amount"`
+	WaitlistCancelled int                  `json:"waitlist_cancelled"`
+	NotifiedUsers     int                  `json:"notified_users"`
+}
+
+// CancelledBooking การจองที่ถูกยกเลิกพร้อมรอบฉาย
+type CancelledBooking struct {
+	BookingID      int     `json:"booking_id"`
+	BookingCode    string  `json:"booking_code"`
+	UserID         int     `json:"user_id"`
+	PreviousStatus string  `json:"previous_status"` // 'pending', 'confirmed'
+	Seats          int     `json:"seats"`
+	RefundedAmount float64 `json:"refunded_amount"`
+}
+
+// FailedCancellation การจองที่ยกเลิกไม่สำเร็จ (เช่น payment gateway คืนเงินไม่ได้)
+type FailedCancellation struct {
+	BookingID int    `json:"booking_id"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TicketType ประเภทตั๋ว (adult, child, senior, student) ช่วงอายุใช้ตรวจกับเรตของภาพยนตร์
+type TicketType struct {
+	TicketType string    `json:"ticket_type" db:"ticket_type"`
+	Name       string    `json:"name" db:"name"`
+	MinAge     *int      `json:"min_age,omitempty" db:"min_age"`
+	MaxAge     *int      `json:"max_age,omitempty" db:"max_age"`
+	IsActive   bool      `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateTicketTypeRequest struct {
+	TicketType string `json:"ticket_type" binding:"required,max=20"`
+	Name       string `json:"name" binding:"required"`
+	MinAge     *int   `json:"min_age" binding:"omitempty,min=0"`
+	MaxAge     *int   `json:"max_age" binding:"omitempty,min=0"`
+}
+
+type UpdateTicketTypeRequest struct {
+	Name     *string `json:"name"`
+	MinAge   *int    `json:"min_age" binding:"omitempty,min=0"`
+	MaxAge   *int    `json:"max_age" binding:"omitempty,min=0"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// TicketFare การปรับราคาตามประเภทตั๋ว CinemaID/SeatType เป็น nil = ทุกสาขา/ทุกประเภทที่นั่ง
+type TicketFare struct {
+	FareID          int       `json:"fare_id" db:"fare_id"`
+	TicketType      string    `json:"ticket_type" db:"ticket_type"`
+	CinemaID        *int      `json:"cinema_id,omitempty" db:"cinema_id"`
+	SeatType        *string   `json:"seat_type,omitempty" db:"seat_type"`
+	AdjustmentType  string    `json:"adjustment_type" db:"adjustment_type"` // 'percentage', 'fixed', 'override'
+	AdjustmentValue float64   `json:"adjustment_value" db:"adjustment_value"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TicketFareRequest ใช้ทั้งสร้างและแก้ไขค่าตั๋ว (แก้ไขจะแทนที่ทั้งแถว)
+type TicketFareRequest struct {
+	TicketType      string  `json:"ticket_type" binding:"required"`
+	CinemaID        *int    `json:"cinema_id"`
+	SeatType        *string `json:"seat_type" binding:"omitempty,oneof=standard premium couple vip"`
+	AdjustmentType  string  `json:"adjustment_type" binding:"required,oneof=percentage fixed override"`
+	AdjustmentValue float64 `json:"adjustment_value"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// WaitlistEntry คิวรอที่นั่งของรอบฉายที่เต็ม Position มีเฉพาะคิวที่ยังรออยู่ (1 = คิวแรก)
+type WaitlistEntry struct {
+	EntryID        int        `json:"entry_id" db:"entry_id"`
+	ShowtimeID     int        `json:"showtime_id" db:"showtime_id"`
+	UserID         int        `json:"user_id" db:"user_id"`
+	SeatCount      int        `json:"seat_count" db:"seat_count"`
+	Status         string     `json:"status" db:"status"` // 'waiting', 'offered', 'fulfilled', 'expired', 'cancelled'
+	Position       *int       `json:"position,omitempty"`
+	BookingID      *int       `json:"booking_id,omitempty" db:"booking_id"`
+	OfferedAt      *time.Time `json:"offered_at,omitempty" db:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty" db:"offer_expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type JoinWaitlistRequest struct {
+	SeatCount int `json:"seat_count" binding:"required,min=1,max=10"`
+}
+
+// WaitlistDepth ความยาวคิวรอที่นั่งของรอบฉาย (สำหรับ admin)
+type WaitlistDepth struct {
+	ShowtimeID     int    `json:"showtime_id"`
+	MovieTitle     string `json:"movie_title"`
+	CinemaName     string `json:"cinema_name"`
+	TheaterName    string `json:"theater_name"`
+	ShowDate       string `json:"show_date"`
+	ShowTime       string `json:"show_time"`
+	AvailableSeats int    `json:"available_seats"`
+	WaitingEntries int    `json:"waiting_entries"`
+	WaitingSeats   int    `json:"waiting_seats"`
+	OfferedEntries int    `json:"offered_entries"`
+}
@@ -0,0 +1,136 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// FakeGateway ผู้ให้บริการชำระเงินจำลองที่ทำงานใน process (ใช้ทดสอบ flow แบบ offline)
+type FakeGateway struct {
+	mu      sync.Mutex
+	secret  []byte
+	nextID  int
+	intents map[string]*Intent
+	refunds map[string]*Refund // ตาม idempotency key
+	// DeclineMethods วิธีชำระเงินที่จะถูกปฏิเสธตอน Capture (จำลองบัตรโดนปฏิเสธ)
+	DeclineMethods map[string]bool
+}
+
+func NewFakeGateway(webhookSecret string) *FakeGateway {
+	return &FakeGateway{
+		secret:         []byte(webhookSecret),
+		intents:        map[string]*Intent{},
+		refunds:        map[string]*Refund{},
+		DeclineMethods: map[string]bool{},
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	if !IsSupportedMethod(req.Method) {
+		return nil, ErrUnsupportedMethod
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.nextID++
+	intent := &Intent{
+		ProviderRef: fmt.Sprintf("fake_%s_%d", req.BookingCode, g.nextID),
+		Amount:      req.Amount,
+		Method:      req.Method,
+		Status:      StatusPending,
+	}
+	g.intents[intent.ProviderRef] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, providerRef string) (*Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[providerRef]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if g.DeclineMethods[intent.Method] {
+		intent.Status = StatusFailed
+		return nil, ErrPaymentDeclined
+	}
+	intent.Status = StatusCaptured
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refund, ok := g.refunds[idempotencyKey]; ok && idempotencyKey != "" {
+		if refund.ProviderRef != providerRef {
+			return nil, fmt.Errorf("idempotency key %q was used to refund %s", idempotencyKey, refund.ProviderRef)
+		}
+		copied := *refund
+		return &copied, nil
+	}
+
+	intent, ok := g.intents[providerRef]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status == StatusRefunded {
+		return nil, ErrAlreadyRefunded
+	}
+	if amount > intent.Amount {
+		return nil, fmt.Errorf("refund amount %.2f exceeds captured amount %.2f", amount, intent.Amount)
+	}
+	intent.Status = StatusRefunded
+
+	g.nextID++
+	refund := &Refund{
+		ProviderRef: providerRef,
+		RefundRef:   fmt.Sprintf("fake_refund_%d", g.nextID),
+		Amount:      amount,
+		Status:      StatusRefunded,
+	}
+	if idempotencyKey != "" {
+		g.refunds[idempotencyKey] = refund
+	}
+
+	copied := *refund
+	return &copied, nil
+}
+
+func (g *FakeGateway) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	// ถ้าไม่ได้ตั้ง secret ใครก็สร้าง signature ได้ จึงปฏิเสธทุก webhook
+	if len(g.secret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(g.Sign(payload)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decode webhook payload: %w", err)
+	}
+	return &event, nil
+}
+
+// Sign สร้าง HMAC-SHA256 (hex) ของ payload ด้วย secret เดียวกับที่ใช้ตรวจสอบ webhook
+func (g *FakeGateway) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
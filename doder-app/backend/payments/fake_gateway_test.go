@@ -0,0 +1,111 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newCapturedIntent(t *testing.T, g *FakeGateway, amount float64) *Intent {
+	t.Helper()
+	ctx := context.Background()
+	intent, err := g.CreateIntent(ctx, IntentRequest{BookingID: 1, BookingCode: "BK1", Amount: amount, Method: MethodCreditCard})
+	if err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	captured, err := g.Capture(ctx, intent.ProviderRef)
+	if err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	return captured
+}
+
+func TestFakeGatewayRefund(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway("secret")
+	intent := newCapturedIntent(t, g, 250)
+
+	refund, err := g.Refund(ctx, intent.ProviderRef, 250, "refund-request-7-payment-3")
+	if err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if refund.Status != StatusRefunded || refund.Amount != 250 {
+		t.Errorf("refund = %+v, want refunded 250", refund)
+	}
+
+	// ส่งซ้ำด้วย key เดิม (เช่น job retry) ได้ผลเดิมโดยไม่คืนเงินซ้ำ
+	replayed, err := g.Refund(ctx, intent.ProviderRef, 250, "refund-request-7-payment-3")
+	if err != nil {
+		t.Fatalf("replay refund: %v", err)
+	}
+	if replayed.RefundRef != refund.RefundRef {
+		t.Errorf("replayed refund ref = %q, want %q", replayed.RefundRef, refund.RefundRef)
+	}
+
+	if _, err := g.Refund(ctx, intent.ProviderRef, 250, "refund-request-8-payment-3"); !errors.Is(err, ErrAlreadyRefunded) {
+		t.Errorf("refund with new key error = %v, want %v", err, ErrAlreadyRefunded)
+	}
+	if _, err := g.Refund(ctx, intent.ProviderRef, 250, ""); !errors.Is(err, ErrAlreadyRefunded) {
+		t.Errorf("refund without key error = %v, want %v", err, ErrAlreadyRefunded)
+	}
+}
+
+func TestFakeGatewayRefundErrors(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway("secret")
+	intent := newCapturedIntent(t, g, 100)
+	other := newCapturedIntent(t, g, 100)
+
+	if _, err := g.Refund(ctx, "fake_missing", 100, "k1"); !errors.Is(err, ErrIntentNotFound) {
+		t.Errorf("unknown intent error = %v, want %v", err, ErrIntentNotFound)
+	}
+	if _, err := g.Refund(ctx, intent.ProviderRef, 100.01, "k2"); err == nil {
+		t.Error("refund above captured amount succeeded")
+	}
+	if _, err := g.Refund(ctx, intent.ProviderRef, 100, "k3"); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if _, err := g.Refund(ctx, other.ProviderRef, 100, "k3"); err == nil {
+		t.Error("reused idempotency key refunded a different intent")
+	}
+}
+
+func TestFakeGatewayVerifyWebhook(t *testing.T) {
+	payload := []byte(`{"type":"payment.succeeded","provider_ref":"fake_BK1_1","amount":250}`)
+	g := NewFakeGateway("secret")
+	// HMAC-SHA256 ของ payload ด้วย key "secret" (คำนวณแยกจาก Sign)
+	signature := "64a321cefc80ae41885be7a4dd65a9c311cf28f85b5215a92f2a6b1981c34953"
+	if got := g.Sign(payload); got != signature {
+		t.Fatalf("Sign() = %s, want %s", got, signature)
+	}
+
+	tests := []struct {
+		name      string
+		gateway   *FakeGateway
+		payload   []byte
+		signature string
+		wantErr   error
+	}{
+		{"valid signature", g, payload, signature, nil},
+		{"tampered payload", g, []byte(`{"type":"payment.succeeded","provider_ref":"fake_BK1_1","amount":1}`), signature, ErrInvalidSignature},
+		{"other secret", NewFakeGateway("other"), payload, signature, ErrInvalidSignature},
+		{"missing signature", g, payload, "", ErrInvalidSignature},
+		{"uppercase hex", g, payload, strings.ToUpper(signature), ErrInvalidSignature},
+		{"secret not set", NewFakeGateway(""), payload, NewFakeGateway("").Sign(payload), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := tt.gateway.VerifyWebhook(tt.payload, tt.signature)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if event.Type != EventPaymentSucceeded || event.ProviderRef != "fake_BK1_1" || event.Amount != 250 {
+				t.Errorf("event = %+v", event)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// วิธีชำระเงินที่รองรับ (ตรงกับ models.ConfirmPaymentRequest.PaymentMethod)
+const (
+	MethodCreditCard = "credit_card"
+	MethodPromptPay  = "promptpay"
+	MethodCash       = "cash"
+)
+
+// สถานะของ payment ในตาราง payments
+const (
+	StatusPending  = "pending"
+	StatusCaptured = "captured"
+	StatusFailed   = "failed"
+	// StatusRefunding บันทึกว่าต้องคืนเงินแล้ว แต่ยังไม่ได้รับผลจากผู้ให้บริการ (ส่งหลัง commit)
+	StatusRefunding = "refunding"
+	StatusRefunded  = "refunded"
+)
+
+// ประเภท event ที่ได้จาก webhook ของผู้ให้บริการ
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	// EventRefundSucceeded ผู้ให้บริการคืนเงินสำเร็จ (เช่น ตอบ Refund ไม่ทันแต่คืนเงินให้ภายหลัง)
+	EventRefundSucceeded = "refund.succeeded"
+)
+
+var (
+	ErrUnsupportedMethod = errors.New("unsupported payment method")
+	ErrIntentNotFound    = errors.New("payment intent not found")
+	ErrInvalidSignature  = errors.New("invalid webhook signature")
+	ErrPaymentDeclined   = errors.New("payment declined")
+	ErrAlreadyRefunded   = errors.New("payment has already been refunded")
+	ErrUnknownGateway    = errors.New("unknown payment gateway")
+	ErrFakeGatewayDenied = errors.New("fake payment gateway keeps payments in memory only and is for development (set PAYMENT_ALLOW_FAKE_GATEWAY=true to use it)")
+)
+
+// IntentRequest ข้อมูลสำหรับสร้างรายการชำระเงินกับผู้ให้บริการ
+type IntentRequest struct {
+	BookingID   int
+	BookingCode string
+	Amount      float64
+	Method      string
+}
+
+// Intent รายการชำระเงินฝั่งผู้ให้บริการ
+type Intent struct {
+	ProviderRef string
+	Amount      float64
+	Method      string
+	Status      string
+}
+
+// Refund ผลการคืนเงิน
+type Refund struct {
+	ProviderRef string
+	RefundRef   string
+	Amount      float64
+	Status      string
+}
+
+// WebhookEvent event ที่ผ่านการตรวจสอบ signature แล้ว
+type WebhookEvent struct {
+	Type        string  `json:"type"`
+	ProviderRef string  `json:"provider_ref"`
+	Amount      float64 `json:"amount"`
+	RefundRef   string  `json:"refund_ref,omitempty"` // มีเฉพาะ event refund.succeeded
+}
+
+// Gateway ผู้ให้บริการชำระเงิน (บัตรเครดิต, PromptPay ฯลฯ)
+type Gateway interface {
+	// Name ชื่อผู้ให้บริการ ใช้บันทึกในคอลัมน์ payments.provider
+	Name() string
+	// CreateIntent สร้างรายการรอชำระเงิน
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	// Capture เรียกเก็บเงินของรายการที่สร้างไว้
+	Capture(ctx context.Context, providerRef string) (*Intent, error)
+	// Refund คืนเงินบางส่วนหรือทั้งหมด เรียกซ้ำด้วย idempotencyKey เดิมจะได้ผลเดิมโดยไม่คืนเงินซ้ำ
+	Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) (*Refund, error)
+	// VerifyWebhook ตรวจสอบ signature ของ webhook และแปลง payload เป็น event
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// NewGateway สร้าง payment gateway ตามชื่อผู้ให้บริการ (PAYMENT_GATEWAY)
+// fake gateway ลืมรายการทั้งหมดเมื่อ restart (คืนเงินรายการเก่าไม่ได้) จึงใช้ได้เมื่อ allowFake เท่านั้น
+func NewGateway(name, webhookSecret string, allowFake bool) (Gateway, error) {
+	switch name {
+	case "fake":
+		if !allowFake {
+			return nil, ErrFakeGatewayDenied
+		}
+		return NewFakeGateway(webhookSecret), nil
+	case "":
+		return nil, fmt.Errorf("%w: PAYMENT_GATEWAY is not set", ErrUnknownGateway)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownGateway, name)
+}
+
+// Satang แปลงจำนวนเงินบาทเป็นสตางค์ (ปัดเศษ) ใช้เทียบจำนวนเงินแทนการเทียบ float64 ตรงๆ
+func Satang(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// IsSupportedMethod ตรวจสอบว่าวิธีชำระเงินนี้รองรับหรือไม่
+func IsSupportedMethod(method string) bool {
+	switch method {
+	case MethodCreditCard, MethodPromptPay, MethodCash:
+		return true
+	}
+	return false
+}
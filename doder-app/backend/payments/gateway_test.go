@@ -0,0 +1,50 @@
+package payments
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewGateway(t *testing.T) {
+	tests := []struct {
+		name      string
+		gateway   string
+		allowFake bool
+		wantErr   error
+	}{
+		{"fake allowed", "fake", true, nil},
+		{"fake without dev flag", "fake", false, ErrFakeGatewayDenied},
+		{"not configured", "", true, ErrUnknownGateway},
+		{"unknown provider", "omise", true, ErrUnknownGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway, err := NewGateway(tt.gateway, "secret", tt.allowFake)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewGateway() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && gateway.Name() != tt.gateway {
+				t.Errorf("gateway name = %q, want %q", gateway.Name(), tt.gateway)
+			}
+		})
+	}
+}
+
+func TestSatang(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   int64
+	}{
+		{0, 0},
+		{100.5, 10050},
+		{0.1 + 0.2, 30},
+		{19.99, 1999},
+		{250.004, 25000},
+		{250.006, 25001},
+	}
+	for _, tt := range tests {
+		if got := Satang(tt.amount); got != tt.want {
+			t.Errorf("Satang(%v) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// EMVCo tags ที่ใช้ใน PromptPay QR (Thai QR Payment)
+const (
+	promptPayAID          = "A000000677010111"
+	promptPayCurrencyTHB  = "764"
+	promptPayCountryCode  = "TH"
+	promptPayMaxReference = 25
+)
+
+var ErrInvalidPromptPayID = errors.New("promptpay id must be a 10-digit mobile number, a 13-digit tax id or a 15-digit e-wallet id")
+
+// PromptPayPayload สร้าง payload ตามมาตรฐาน EMVCo สำหรับ PromptPay แบบระบุจำนวนเงิน (dynamic QR)
+// promptPayID รับเบอร์มือถือ 10 หลัก, เลขประจำตัวผู้เสียภาษี 13 หลัก หรือ e-wallet 15 หลัก
+func PromptPayPayload(promptPayID string, amount float64, reference string) (string, error) {
+	accountTag, account, err := promptPayAccount(promptPayID)
+	if err != nil {
+		return "", err
+	}
+
+	merchant := emvField("00", promptPayAID) + emvField(accountTag, account)
+
+	var b strings.Builder
+	b.WriteString(emvField("00", "01")) // Payload format indicator
+	b.WriteString(emvField("01", "12")) // Point of initiation: 12 = dynamic (ใช้ครั้งเดียว)
+	b.WriteString(emvField("29", merchant))
+	b.WriteString(emvField("53", promptPayCurrencyTHB))
+	b.WriteString(emvField("54", fmt.Sprintf("%.2f", amount)))
+	b.WriteString(emvField("58", promptPayCountryCode))
+	if ref := PromptPayReference(reference); ref != "" {
+		b.WriteString(emvField("62", emvField("05", ref))) // Reference label
+	}
+
+	// CRC คำนวณรวม tag และความยาวของตัว CRC เอง ("6304")
+	b.WriteString("6304")
+	b.WriteString(fmt.Sprintf("%04X", crc16CCITT([]byte(b.String()))))
+
+	return b.String(), nil
+}
+
+// PromptPayReference แปลงข้อความเป็น reference ที่ใส่ใน QR ได้ (ตัวอักษรใหญ่/ตัวเลข ไม่เกิน 25 ตัว)
+func PromptPayReference(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	ref := b.String()
+	if len(ref) > promptPayMaxReference {
+		ref = ref[:promptPayMaxReference]
+	}
+	return ref
+}
+
+// promptPayAccount เลือก sub-tag ตามชนิดของ PromptPay ID
+func promptPayAccount(id string) (string, string, error) {
+	// รับเฉพาะเลขอารบิก 0-9 (unicode.IsDigit รับเลขไทย ๐-๙ ด้วย ซึ่งใส่ใน QR ไม่ได้) ตัดขีดและช่องว่างทิ้ง
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, id)
+
+	switch len(digits) {
+	case 10:
+		// เบอร์มือถือ: ตัด 0 ข้างหน้า เติมรหัสประเทศ 66 และเติม 0 ให้ครบ 13 หลัก
+		return "01", "0066" + digits[1:], nil
+	case 13:
+		return "02", digits, nil
+	case 15:
+		return "03", digits, nil
+	}
+	return "", "", ErrInvalidPromptPayID
+}
+
+func emvField(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// crc16CCITT CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) ตามที่ EMVCo กำหนด
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
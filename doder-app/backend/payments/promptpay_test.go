@@ -0,0 +1,97 @@
+package payments
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	// ค่า check มาตรฐานของ CRC-16/CCITT-FALSE
+	if got := crc16CCITT([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("crc16CCITT(123456789) = %04X, want 29B1", got)
+	}
+}
+
+func TestPromptPayPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		promptPayID string
+		amount      float64
+		reference   string
+		want        string
+	}{
+		{
+			name:        "mobile number with reference",
+			promptPayID: "081-234-5678",
+			amount:      100.5,
+			reference:   "bk-1234",
+			want:        "00020101021229370016A0000006770101110113006681234567853037645406100.505802TH62100506BK12346304AAE4",
+		},
+		{
+			name:        "tax id without reference",
+			promptPayID: "1234567890123",
+			amount:      42,
+			want:        "00020101021229370016A000000677010111021312345678901235303764540542.005802TH63042DCD",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PromptPayPayload(tt.promptPayID, tt.amount, tt.reference)
+			if err != nil {
+				t.Fatalf("PromptPayPayload() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PromptPayPayload() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptPayAccount(t *testing.T) {
+	tests := []struct {
+		id      string
+		tag     string
+		account string
+		wantErr bool
+	}{
+		{id: "0812345678", tag: "01", account: "0066812345678"},
+		{id: "081 234 5678", tag: "01", account: "0066812345678"},
+		{id: "1-2345-67890-12-3", tag: "02", account: "1234567890123"},
+		{id: "123456789012345", tag: "03", account: "123456789012345"},
+		{id: "๐๘๑๒๓๔๕๖๗๘", wantErr: true},
+		{id: "08123456", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			tag, account, err := promptPayAccount(tt.id)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPromptPayID) {
+					t.Fatalf("promptPayAccount() error = %v, want %v", err, ErrInvalidPromptPayID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("promptPayAccount() error = %v", err)
+			}
+			if tag != tt.tag || account != tt.account {
+				t.Errorf("promptPayAccount() = %s %s, want %s %s", tag, account, tt.tag, tt.account)
+			}
+		})
+	}
+}
+
+func TestPromptPayReference(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"bk-1234", "BK1234"},
+		{"จอง BK๑2", "BK2"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123", "ABCDEFGHIJKLMNOPQRSTUVWXY"},
+	}
+	for _, tt := range tests {
+		if got := PromptPayReference(tt.in); got != tt.want {
+			t.Errorf("PromptPayReference(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -3,23 +3,37 @@ package routes
 import (
 	"database/sql"
 
+	"movie-booking-system/config"
+	"movie-booking-system/events"
 	"movie-booking-system/handlers"
 	"movie-booking-system/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronService) {
+func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronService, bookingService *services.BookingService, refundService *services.RefundService, pricingService *services.PricingService, promoService *services.PromoService, showtimeService *services.ShowtimeService, waitlistService *services.WaitlistService, seatBroker events.Broker, seatSelections *services.SeatSelections) {
 
 	cinemaHandler := handlers.NewCinemaHandler(db)
-	movieHandler := handlers.NewMovieHandler(db)
+	movieHandler := handlers.NewMovieHandler(db, showtimeService)
 	theaterHandler := handlers.NewTheaterHandler(db)
-	showtimeHandler := handlers.NewShowtimeHandler(db)
-	seatHandler := handlers.NewSeatHandler(db)
+	showtimeHandler := handlers.NewShowtimeHandler(db, showtimeService, bookingService)
+	seatHandler := handlers.NewSeatHandler(db, seatSelections, pricingService)
+	seatSelectionHandler := handlers.NewSeatSelectionHandler(bookingService, seatSelections, seatBroker)
+	seatStreamHandler := handlers.NewSeatStreamHandler(db, seatBroker)
+	seatPriceHandler := handlers.NewSeatPriceHandler(db, pricingService)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	promoHandler := handlers.NewPromoHandler(promoService)
+	ticketHandler := handlers.NewTicketHandler(db)
+	concessionHandler := handlers.NewConcessionHandler(db)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService)
+	notificationHandler := handlers.NewNotificationHandler(db)
 	cronHandler := handlers.NewCronHandler(cronService)
 	uploadHandler := handlers.NewUploadHandler()
 	authHandler := handlers.NewAuthHandler(db)
-	bookingHandler := handlers.NewBookingHandler(db)
+	bookingHandler := handlers.NewBookingHandler(db, bookingService)
+	groupBookingHandler := handlers.NewGroupBookingHandler(bookingService)
+	paymentHandler := handlers.NewPaymentHandler(bookingService, config.PromptPayID())
+	refundHandler := handlers.NewRefundHandler(refundService)
 
 	// Middlewares
 	authMiddleware := handlers.AuthMiddleware()
@@ -43,6 +57,7 @@ func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronServi
 		//  Cinemas
 		api.GET("/cinemas", cinemaHandler.GetAllCinemas)
 		api.GET("/cinemas/:id", cinemaHandler.GetCinemaByID)
+		api.GET("/cinemas/:id/concessions", concessionHandler.GetCinemaConcessions)
 
 		// Theaters
 		api.GET("/theaters", theaterHandler.GetAllTheaters)
@@ -52,19 +67,47 @@ func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronServi
 		api.GET("/showtimes", showtimeHandler.GetAllShowtimes)
 		api.GET("/showtimes/:id", showtimeHandler.GetShowtimeByID)
 		api.GET("/showtimes/:id/seats", seatHandler.GetSeatStatusByShowtime)
+		api.GET("/showtimes/:id/seats/stream", seatStreamHandler.StreamSeatStatus)
+		api.GET("/showtimes/:id/seats/select", authMiddleware, seatSelectionHandler.SelectSeats)
+		api.GET("/showtimes/:id/seat-prices", seatPriceHandler.GetShowtimeSeatPrices)
+		api.POST("/showtimes/:id/waitlist", authMiddleware, waitlistHandler.JoinWaitlist)
+
+		// Waitlist (ต้อง login)
+		api.GET("/waitlist/my-entries", authMiddleware, waitlistHandler.GetMyWaitlist)
+		api.DELETE("/waitlist/:id", authMiddleware, waitlistHandler.LeaveWaitlist)
+
+		// Notifications (ต้อง login)
+		api.GET("/notifications", authMiddleware, notificationHandler.GetMyNotifications)
+		api.PUT("/notifications/:id/read", authMiddleware, notificationHandler.MarkNotificationRead)
 
 		// Seats
 		api.GET("/seats", seatHandler.GetAllSeats)
 		api.GET("/seats/:id", seatHandler.GetSeatByID)
 
+		// Ticket types
+		api.GET("/ticket-types", ticketHandler.GetAllTicketTypes)
+
+		// Payment webhooks (ผู้ให้บริการเรียก ตรวจสอบด้วย signature แทนการ login)
+		api.POST("/payments/webhook/:provider", paymentHandler.HandleWebhook)
+
+		// Promo codes (ตรวจโค้ดก่อนจอง ต้อง login เพื่อนับสิทธิ์ต่อผู้ใช้)
+		api.POST("/promo-codes/validate", authMiddleware, promoHandler.ValidatePromoCode)
+
 		// User Routes Bookings (ต้อง login)
 		bookings := api.Group("/bookings", authMiddleware)
 		{
 			bookings.POST("", bookingHandler.CreateBooking)
 			bookings.GET("/my-bookings", bookingHandler.GetUserBookings)
+			bookings.GET("/my-shares", groupBookingHandler.GetMyShares)
 			bookings.GET("/:id", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), bookingHandler.GetBooking)
-			bookings.PUT("/:id/confirm-payment", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), bookingHandler.ConfirmPayment)
+			bookings.POST("/:id/payment", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), paymentHandler.StartPayment)
 			bookings.DELETE("/:id", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), bookingHandler.CancelBooking)
+			bookings.GET("/:id/payment/promptpay", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), paymentHandler.GetPromptPayQR)
+			bookings.POST("/:id/extend", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), bookingHandler.ExtendHold)
+			bookings.POST("/:id/refund", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), refundHandler.RequestRefund)
+			bookings.POST("/:id/group", bookingMiddleware.BookingExistsMiddleware(), bookingMiddleware.BookingOwnerMiddleware(), groupBookingHandler.CreateGroupBooking)
+			bookings.GET("/:id/group", bookingMiddleware.BookingExistsMiddleware(), groupBookingHandler.GetGroupBooking)
+			bookings.POST("/:id/shares/payment", bookingMiddleware.BookingExistsMiddleware(), groupBookingHandler.PayShare)
 		}
 
 		// Admin Routes
@@ -79,16 +122,40 @@ func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronServi
 			admin.POST("/cinemas", cinemaHandler.CreateCinema)
 			admin.PUT("/cinemas/:id", cinemaHandler.UpdateCinema)
 			admin.DELETE("/cinemas/:id", cinemaHandler.DeleteCinema)
+			admin.POST("/cinemas/:id/schedule-plan", showtimeHandler.PlanCinemaSchedule)
+			admin.POST("/cinemas/:id/schedule-plan/accept", showtimeHandler.AcceptCinemaSchedule)
 
 			// Theaters
 			admin.POST("/theaters", theaterHandler.CreateTheater)
 			admin.PUT("/theaters/:id", theaterHandler.UpdateTheater)
 			admin.DELETE("/theaters/:id", theaterHandler.DeleteTheater)
+			admin.GET("/theaters/:id/seat-prices", seatPriceHandler.GetTheaterSeatPrices)
+			admin.PUT("/theaters/:id/seat-prices", seatPriceHandler.SetTheaterSeatPrices)
+			admin.DELETE("/theaters/:id/seat-prices/:seat_type", seatPriceHandler.DeleteTheaterSeatPrice)
 
 			// Showtimes
 			admin.POST("/showtimes", showtimeHandler.CreateShowtime)
+			admin.POST("/showtimes/reconcile-seats", bookingHandler.ReconcileAvailableSeats)
+			admin.POST("/showtimes/generate", showtimeHandler.GenerateShowtimes)
 			admin.PUT("/showtimes/:id", showtimeHandler.UpdateShowtime)
 			admin.DELETE("/showtimes/:id", showtimeHandler.DeleteShowtime)
+			admin.GET("/showtimes/:id/cancellation-impact", showtimeHandler.GetCancellationImpact)
+			admin.GET("/showtimes/:id/seat-prices", seatPriceHandler.GetShowtimeSeatPriceOverrides)
+			admin.PUT("/showtimes/:id/seat-prices", seatPriceHandler.SetShowtimeSeatPrices)
+			admin.DELETE("/showtimes/:id/seat-prices/:seat_type", seatPriceHandler.DeleteShowtimeSeatPrice)
+			admin.GET("/showtimes/:id/price-preview", pricingHandler.PreviewShowtimePrice)
+			admin.GET("/showtimes/:id/waitlist", waitlistHandler.GetShowtimeWaitlist)
+			admin.GET("/waitlist", waitlistHandler.GetWaitlistDepth)
+
+			// Pricing rules
+			admin.GET("/pricing-rules", pricingHandler.GetAllPricingRules)
+			admin.GET("/pricing-rules/:id", pricingHandler.GetPricingRule)
+			admin.POST("/pricing-rules", pricingHandler.CreatePricingRule)
+			admin.PUT("/pricing-rules/:id", pricingHandler.UpdatePricingRule)
+			admin.DELETE("/pricing-rules/:id", pricingHandler.DeletePricingRule)
+			admin.GET("/holidays", pricingHandler.GetAllHolidays)
+			admin.POST("/holidays", pricingHandler.CreateHoliday)
+			admin.DELETE("/holidays/:date", pricingHandler.DeleteHoliday)
 
 			// Seats
 			admin.POST("/seats", seatHandler.CreateSeat)
@@ -99,10 +166,40 @@ func SetupRoutes(router *gin.Engine, db *sql.DB, cronService *services.CronServi
 			// Bookings (Admin only)
 			admin.GET("/bookings", bookingHandler.GetAllBookings)
 			admin.GET("/bookings/:id", bookingMiddleware.BookingExistsMiddleware(), bookingHandler.GetBooking)
+			admin.PUT("/bookings/:id/confirm-payment", bookingMiddleware.BookingExistsMiddleware(), bookingHandler.ConfirmPayment)
+
+			// Refunds
+			admin.GET("/refunds", refundHandler.GetAllRefunds)
+			admin.PUT("/refunds/:id/approve", refundHandler.ApproveRefund)
+			admin.PUT("/refunds/:id/reject", refundHandler.RejectRefund)
+
+			// Promo codes
+			admin.GET("/promo-codes", promoHandler.GetAllPromoCodes)
+			admin.GET("/promo-codes/:id", promoHandler.GetPromoCode)
+			admin.POST("/promo-codes", promoHandler.CreatePromoCode)
+			admin.PUT("/promo-codes/:id", promoHandler.UpdatePromoCode)
+			admin.DELETE("/promo-codes/:id", promoHandler.DeletePromoCode)
+			admin.GET("/promo-codes/:id/redemptions", promoHandler.GetPromoRedemptions)
+
+			// Ticket types & fares
+			admin.POST("/ticket-types", ticketHandler.CreateTicketType)
+			admin.PUT("/ticket-types/:ticket_type", ticketHandler.UpdateTicketType)
+			admin.DELETE("/ticket-types/:ticket_type", ticketHandler.DeleteTicketType)
+			admin.GET("/ticket-fares", ticketHandler.GetAllTicketFares)
+			admin.POST("/ticket-fares", ticketHandler.CreateTicketFare)
+			admin.PUT("/ticket-fares/:id", ticketHandler.UpdateTicketFare)
+			admin.DELETE("/ticket-fares/:id", ticketHandler.DeleteTicketFare)
+
+			// Concessions
+			admin.GET("/concessions", concessionHandler.GetAllConcessions)
+			admin.POST("/concessions", concessionHandler.CreateConcession)
+			admin.PUT("/concessions/:id", concessionHandler.UpdateConcession)
+			admin.DELETE("/concessions/:id", concessionHandler.DeleteConcession)
 
 			// Cron
 			admin.GET("/cron/status", cronHandler.GetCronStatus)
 			admin.POST("/cron/cancel-expired", cronHandler.TriggerCancelExpiredReservations)
+			admin.POST("/cron/retention", cronHandler.RunRetention)
 
 			// Upload
 			admin.POST("/upload/poster", uploadHandler.UploadPoster)
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func cronTime(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"1,,2 * * * *",
+	}
+	for _, spec := range specs {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"every minute", "* * * * *", "2026-03-14 10:00:30", "2026-03-14 10:01:00"},
+		{"exact minute is not repeated", "* * * * *", "2026-03-14 10:01:00", "2026-03-14 10:02:00"},
+		{"step", "*/5 * * * *", "2026-03-14 10:02:00", "2026-03-14 10:05:00"},
+		{"step wraps hour", "*/5 * * * *", "2026-03-14 10:55:00", "2026-03-14 11:00:00"},
+		{"step within range", "0-30/10 * * * *", "2026-03-14 10:25:00", "2026-03-14 10:30:00"},
+		{"step range ends", "0-30/10 * * * *", "2026-03-14 10:31:00", "2026-03-14 11:00:00"},
+		{"start with step runs to max", "50/5 * * * *", "2026-03-14 10:51:00", "2026-03-14 10:55:00"},
+		{"list", "0 8,20 * * *", "2026-03-14 09:00:00", "2026-03-14 20:00:00"},
+		{"daily passed today", "30 3 * * *", "2026-03-14 04:00:00", "2026-03-15 03:30:00"},
+		{"weekdays skip weekend", "0 9 * * 1-5", "2026-03-13 10:00:00", "2026-03-16 09:00:00"},
+		{"first of month", "0 0 1 * *", "2026-01-31 12:00:00", "2026-02-01 00:00:00"},
+		{"new year", "0 0 1 1 *", "2026-06-01 00:00:00", "2027-01-01 00:00:00"},
+		{"leap day", "0 12 29 2 *", "2026-03-01 00:00:00", "2028-02-29 12:00:00"},
+		{"day of month or weekday: weekday first", "0 0 15 * 5", "2026-03-07 00:00:00", "2026-03-13 00:00:00"},
+		{"day of month or weekday: day first", "0 0 15 * 5", "2026-03-13 00:00:00", "2026-03-15 00:00:00"},
+		{"stepped day of month is not a wildcard", "0 0 */10 * 1", "2026-03-12 00:00:00", "2026-03-16 00:00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+			}
+			got := schedule.Next(cronTime(tt.from))
+			if want := cronTime(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.Format(time.DateTime), want.Format(time.DateTime))
+			}
+		})
+	}
+}
+
+func TestScheduleNextNeverMatches(t *testing.T) {
+	schedule, err := ParseSchedule("0 12 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule: %v", err)
+	}
+	if got := schedule.Next(cronTime("2026-03-14 10:00:00")); !got.IsZero() {
+		t.Errorf("Next() = %s, want zero time for 31 February", got)
+	}
+}
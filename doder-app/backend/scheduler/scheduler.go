@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"movie-booking-system/models"
+)
+
+// ผลลัพธ์ของแต่ละรอบที่บันทึกใน job_runs
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrJobExists      = errors.New("job already registered")
+	ErrJobLocked      = errors.New("job is running on another instance")
+	ErrAlreadyStarted = errors.New("scheduler already started")
+)
+
+// JobFunc งานที่ scheduler เรียก ctx จะไม่ถูกยกเลิกตอน shutdown เพื่อให้งานที่รันอยู่ทำจนเสร็จ
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	spec     string
+	schedule *Schedule
+	run      JobFunc
+}
+
+// Scheduler รัน job ตาม cron expression
+// ใช้ Postgres advisory lock ต่อ job เพื่อให้มีเพียง replica เดียวที่รันแต่ละรอบ
+// และบันทึกผลลงตาราง scheduled_jobs / job_runs
+type Scheduler struct {
+	db       *sql.DB
+	instance string
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	order   []string
+	started bool
+	wg      sync.WaitGroup
+}
+
+func New(db *sql.DB) *Scheduler {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	return &Scheduler{db: db, instance: instance, jobs: map[string]*job{}}
+}
+
+// Register เพิ่ม job ต้องเรียกก่อน Start
+func (s *Scheduler) Register(name, spec string, run JobFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrAlreadyStarted
+	}
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+	s.jobs[name] = &job{name: name, spec: spec, schedule: schedule, run: run}
+	s.order = append(s.order, name)
+	return nil
+}
+
+// Start เริ่มรันทุก job จนกว่า ctx จะถูกยกเลิก (เรียก Wait เพื่อรอให้งานที่ค้างอยู่เสร็จ)
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrAlreadyStarted
+	}
+	s.started = true
+
+	for _, name := range s.order {
+		j := s.jobs[name]
+		if err := s.saveNextRun(ctx, j, j.schedule.Next(time.Now())); err != nil {
+			log.Printf("Failed to register job %s: %v", j.name, err)
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}()
+	}
+
+	log.Printf("Scheduler started with %d job(s)", len(s.order))
+	return nil
+}
+
+// Wait รอให้ทุก job loop หยุด (หลังจาก ctx ที่ส่งให้ Start ถูกยกเลิก)
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// RunNow รัน job ทันทีนอกรอบ (ยังต้องได้ advisory lock เหมือนรอบปกติ)
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	ran, err := s.execute(ctx, j, time.Time{})
+	if err != nil {
+		return err
+	}
+	if !ran {
+		return ErrJobLocked
+	}
+	return nil
+}
+
+// Status ดึงสถานะล่าสุดของทุก job จาก database
+func (s *Scheduler) Status(ctx context.Context) ([]models.JobStatus, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT job_name, schedule, last_run_at, last_duration_ms, last_status, last_error, last_instance, next_run_at
+		FROM scheduled_jobs
+		ORDER BY job_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("fetch job status: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := []models.JobStatus{}
+	for rows.Next() {
+		var st models.JobStatus
+		err := rows.Scan(
+			&st.JobName, &st.Schedule, &st.LastRunAt, &st.LastDurationMs,
+			&st.LastStatus, &st.LastError, &st.LastInstance, &st.NextRunAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan job status: %w", err)
+		}
+		s.mu.Lock()
+		_, st.Registered = s.jobs[st.JobName]
+		s.mu.Unlock()
+		statuses = append(statuses, st)
+	}
+	return statuses, rows.Err()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Job %s has no upcoming run, stopping", j.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// งานที่เริ่มแล้วให้ทำจนเสร็จแม้ ctx จะถูกยกเลิกระหว่างทาง
+		if _, err := s.execute(context.WithoutCancel(ctx), j, next); err != nil {
+			log.Printf("Job %s failed: %v", j.name, err)
+		}
+	}
+}
+
+// execute รัน job หนึ่งรอบถ้าได้ advisory lock คืน false ถ้า replica อื่นกำลังรันอยู่หรือรันรอบ slot ไปแล้ว
+// slot คือเวลาตามตารางของรอบนี้ (zero = รันทันทีนอกรอบ)
+func (s *Scheduler) execute(ctx context.Context, j *job, slot time.Time) (bool, error) {
+	// advisory lock ผูกกับ session จึงต้อง lock/unlock บน connection เดียวกัน
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", j.name).Scan(&locked); err != nil {
+		return false, fmt.Errorf("acquire advisory lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", j.name); err != nil {
+			log.Printf("Failed to release lock for job %s: %v", j.name, err)
+			// ทิ้ง connection นี้ไป (ไม่คืนเข้า pool) session ปิดแล้ว lock จะถูกปล่อยเอง
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	// replica อื่นอาจรันรอบนี้เสร็จและปล่อย lock ไปก่อนที่เราจะได้ lock
+	if !slot.IsZero() {
+		var lastRunAt sql.NullTime
+		err := conn.QueryRowContext(ctx, "SELECT last_run_at FROM scheduled_jobs WHERE job_name = $1", j.name).Scan(&lastRunAt)
+		if err != nil && err != sql.ErrNoRows {
+			return true, fmt.Errorf("check last run: %w", err)
+		}
+		if lastRunAt.Valid && !lastRunAt.Time.Before(slot) {
+			return false, nil
+		}
+	}
+
+	startedAt := time.Now()
+	runErr := j.run(ctx)
+	finishedAt := time.Now()
+
+	if err := s.recordRun(ctx, j, startedAt, finishedAt, runErr); err != nil {
+		log.Printf("Failed to record run of job %s: %v", j.name, err)
+	}
+	return true, runErr
+}
+
+func (s *Scheduler) recordRun(ctx context.Context, j *job, startedAt, finishedAt time.Time, runErr error) error {
+	status := RunSucceeded
+	var errMsg *string
+	if runErr != nil {
+		status = RunFailed
+		msg := runErr.Error()
+		errMsg = &msg
+	}
+	durationMs := finishedAt.Sub(startedAt).Milliseconds()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO job_runs (job_name, instance, started_at, finished_at, duration_ms, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, j.name, s.instance, startedAt, finishedAt, durationMs, status, errMsg)
+	if err != nil {
+		return fmt.Errorf("insert job run: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (job_name, schedule, last_run_at, last_duration_ms, last_status, last_error, last_instance, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (job_name) DO UPDATE
+		SET schedule = EXCLUDED.schedule,
+		    last_run_at = EXCLUDED.last_run_at,
+		    last_duration_ms = EXCLUDED.last_duration_ms,
+		    last_status = EXCLUDED.last_status,
+		    last_error = EXCLUDED.last_error,
+		    last_instance = EXCLUDED.last_instance,
+		    next_run_at = EXCLUDED.next_run_at,
+		    updated_at = CURRENT_TIMESTAMP
+	`, j.name, j.spec, startedAt, durationMs, status, errMsg, s.instance, j.schedule.Next(finishedAt))
+	if err != nil {
+		return fmt.Errorf("update job status: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *Scheduler) saveNextRun(ctx context.Context, j *job, next time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (job_name, schedule, next_run_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (job_name) DO UPDATE
+		SET schedule = EXCLUDED.schedule, next_run_at = EXCLUDED.next_run_at, updated_at = CURRENT_TIMESTAMP
+	`, j.name, j.spec, next)
+	return err
+}
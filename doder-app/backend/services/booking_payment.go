@@ -0,0 +1,573 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/payments"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrPaymentNotFound  = errors.New("payment not found")
+	ErrAmountMismatch   = errors.New("paid amount does not match booking total")
+	ErrPaymentCancelled = errors.New("payment arrived for a booking that can no longer be confirmed")
+)
+
+// PaymentStart ผลลัพธ์ของการเริ่มชำระเงิน (รอ webhook จากผู้ให้บริการมายืนยัน)
+type PaymentStart struct {
+	PaymentID   int
+	BookingID   int
+	Provider    string
+	ProviderRef string
+	Method      string
+	Amount      float64
+	Status      string
+}
+
+// WebhookResult ผลการประมวลผล webhook
+type WebhookResult struct {
+	BookingID int
+	PaymentID int
+	Status    string // สถานะ payment หลังประมวลผล
+	Duplicate bool   // true ถ้า event นี้เคยประมวลผลแล้ว
+}
+
+// pendingBooking ข้อมูลการจองที่ล็อกไว้ระหว่างยืนยันการชำระเงิน
+type pendingBooking struct {
+	BookingStatus string
+	PaymentStatus string
+	ShowtimeID    int
+	BookingCode   string
+	TotalAmount   float64
+}
+
+// Gateway คืน payment gateway ตามชื่อผู้ให้บริการ (ใช้ตรวจสอบ webhook)
+func (s *BookingService) Gateway(provider string) (payments.Gateway, error) {
+	if s.gateway == nil || s.gateway.Name() != provider {
+		return nil, ErrUnknownProvider
+	}
+	return s.gateway, nil
+}
+
+// StartPayment สร้างรายการชำระเงินกับผู้ให้บริการและบันทึก payment สถานะ pending
+// การจองจะถูกยืนยันเมื่อผู้ให้บริการส่ง webhook payment.succeeded กลับมา
+func (s *BookingService) StartPayment(ctx context.Context, bookingID int, paymentMethod string) (*PaymentStart, error) {
+	if !payments.IsSupportedMethod(paymentMethod) {
+		return nil, payments.ErrUnsupportedMethod
+	}
+
+	pending, err := s.PendingPayment(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startPayment(ctx, bookingID, pending.BookingCode, nil, pending.TotalAmount, paymentMethod)
+}
+
+// startPayment สร้าง payment สถานะ pending ของทั้งการจอง (shareID = nil) หรือของส่วนหนึ่งในการจองแบบกลุ่ม
+func (s *BookingService) startPayment(ctx context.Context, bookingID int, bookingCode string, shareID *int, amount float64, paymentMethod string) (*PaymentStart, error) {
+	// ใช้ payment ที่ยังรออยู่ซ้ำถ้าวิธีชำระเงินเดิม (กด "ชำระเงิน" ซ้ำไม่สร้างรายการใหม่)
+	start := &PaymentStart{BookingID: bookingID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT payment_id, provider, provider_ref, method, amount, status
+		FROM payments
+		WHERE booking_id = $1 AND share_id IS NOT DISTINCT FROM $2 AND method = $3 AND status = $4 AND amount = $5
+		ORDER BY payment_id DESC
+		LIMIT 1
+	`, bookingID, shareID, paymentMethod, payments.StatusPending, amount).Scan(
+		&start.PaymentID, &start.Provider, &start.ProviderRef, &start.Method, &start.Amount, &start.Status,
+	)
+	if err == nil {
+		return start, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("find pending payment: %w", err)
+	}
+
+	intent, err := s.gateway.CreateIntent(ctx, payments.IntentRequest{
+		BookingID:   bookingID,
+		BookingCode: bookingCode,
+		Amount:      amount,
+		Method:      paymentMethod,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO payments (booking_id, provider, provider_ref, method, amount, status, share_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING payment_id
+	`, bookingID, s.gateway.Name(), intent.ProviderRef, intent.Method, intent.Amount, payments.StatusPending, shareID).Scan(&start.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("record payment: %w", err)
+	}
+
+	start.Provider = s.gateway.Name()
+	start.ProviderRef = intent.ProviderRef
+	start.Method = intent.Method
+	start.Amount = intent.Amount
+	start.Status = payments.StatusPending
+	return start, nil
+}
+
+// HandleWebhook ประมวลผล event จากผู้ให้บริการที่ตรวจสอบ signature แล้ว
+// event ซ้ำ (ผู้ให้บริการส่งซ้ำ) จะไม่ยืนยันหรือเปลี่ยนสถานะซ้ำ
+func (s *BookingService) HandleWebhook(ctx context.Context, provider string, event *payments.WebhookEvent) (*WebhookResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	seatChanges := &seatEvents{}
+	refunds := &pendingRefunds{}
+
+	// ล็อก payment ตาม provider reference (webhook ที่มาพร้อมกันจะรอกัน)
+	result := &WebhookResult{}
+	var paymentStatus string
+	var paymentAmount float64
+	var shareID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		SELECT payment_id, booking_id, status, amount, share_id
+		FROM payments
+		WHERE provider = $1 AND provider_ref = $2
+		FOR UPDATE
+	`, provider, event.ProviderRef).Scan(&result.PaymentID, &result.BookingID, &paymentStatus, &paymentAmount, &shareID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch payment: %w", err)
+	}
+
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		if paymentStatus != payments.StatusPending {
+			result.Status = paymentStatus
+			result.Duplicate = true
+			return result, nil
+		}
+		// เทียบเป็นสตางค์ เพราะจำนวนเงินจาก JSON และ DECIMAL เป็น float64 ที่อาจคลาดกันเล็กน้อย
+		if payments.Satang(event.Amount) != payments.Satang(paymentAmount) {
+			return nil, ErrAmountMismatch
+		}
+		err = s.confirmCapturedPayment(ctx, tx, result.BookingID, result.PaymentID, shareID, event.ProviderRef, paymentAmount, seatChanges, refunds)
+		if errors.Is(err, ErrPaymentCancelled) {
+			// การจองยืนยันไม่ได้แล้ว commit สถานะ refunding ก่อน แล้วจึงคืนเงินผ่านผู้ให้บริการ
+			if err := tx.Commit(); err != nil {
+				return nil, fmt.Errorf("commit transaction: %w", err)
+			}
+			seatChanges.publish(s.broker)
+			result.Status = payments.StatusRefunding
+			if _, err := refunds.send(ctx, s.db, s.gateway); err != nil {
+				log.Printf("Refunds are waiting for retry: %v", err)
+			} else {
+				result.Status = payments.StatusRefunded
+			}
+			return result, ErrPaymentCancelled
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Status = payments.StatusCaptured
+
+	case payments.EventPaymentFailed:
+		if paymentStatus != payments.StatusPending {
+			result.Status = paymentStatus
+			result.Duplicate = true
+			return result, nil
+		}
+		if err := setPaymentStatus(ctx, tx, result.PaymentID, payments.StatusFailed); err != nil {
+			return nil, err
+		}
+		result.Status = payments.StatusFailed
+
+	case payments.EventRefundSucceeded:
+		// คืนเงินสำเร็จแล้วแต่ยังไม่ได้บันทึก (เช่น gateway ตอบ Refund ไม่ทัน) ปิด payment แทน job retry_refunds
+		if paymentStatus != payments.StatusRefunding {
+			result.Status = paymentStatus
+			result.Duplicate = true
+			return result, nil
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, refund_ref = COALESCE(NULLIF($2, ''), refund_ref), updated_at = CURRENT_TIMESTAMP
+			WHERE payment_id = $3
+		`, payments.StatusRefunded, event.RefundRef, result.PaymentID)
+		if err != nil {
+			return nil, fmt.Errorf("mark payment refunded: %w", err)
+		}
+		if _, err := completeRefundRequests(ctx, tx, nil); err != nil {
+			return nil, err
+		}
+		result.Status = payments.StatusRefunded
+
+	default:
+		// event ที่ไม่รู้จักตอบรับไว้เฉยๆ เพื่อไม่ให้ผู้ให้บริการส่งซ้ำ
+		result.Status = paymentStatus
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	seatChanges.publish(s.broker)
+	s.sendRefunds(ctx, refunds)
+	return result, nil
+}
+
+// confirmCapturedPayment ยืนยันการจองจาก payment ที่ผู้ให้บริการเก็บเงินแล้ว
+// การจองแบบกลุ่มจะบันทึกว่าส่วนนั้นจ่ายแล้ว และยืนยันการจองเมื่อทุกส่วนจ่ายครบ
+// ถ้ายืนยันไม่ได้ (การจองถูกยกเลิก/ที่นั่งถูกคนอื่น confirm/ส่วนนี้จ่ายไปแล้ว) จะบันทึกการคืนเงินใน refunds
+// (ผู้เรียกส่งหลัง commit) และคืน ErrPaymentCancelled
+func (s *BookingService) confirmCapturedPayment(ctx context.Context, tx *sql.Tx, bookingID, paymentID int, shareID sql.NullInt64, providerRef string, amount float64, seatChanges *seatEvents, refunds *pendingRefunds) error {
+	booking, err := lockBookingForPayment(ctx, tx, bookingID)
+	if err != nil {
+		return err
+	}
+	group, err := isGroupBooking(ctx, tx, bookingID)
+	if err != nil {
+		return err
+	}
+
+	cancelled := booking.BookingStatus == "cancelled" || booking.PaymentStatus == "paid"
+	if !cancelled && group {
+		// payment ที่เริ่มก่อนแบ่งจ่ายเป็นกลุ่ม (ไม่มี share) หรือจ่ายส่วนที่จ่ายไปแล้วซ้ำ ต้องคืนเงิน
+		if !shareID.Valid {
+			cancelled = true
+		} else {
+			shareStatus, err := lockShareForPayment(ctx, tx, shareID.Int64)
+			if err != nil {
+				return err
+			}
+			cancelled = shareStatus != "pending"
+		}
+	}
+	if !cancelled {
+		conflictSeatID, err := findConfirmConflict(ctx, tx, bookingID, booking.ShowtimeID)
+		if err != nil {
+			return err
+		}
+		if conflictSeatID > 0 {
+			if _, err := s.cancelInTx(ctx, tx, bookingID, booking.ShowtimeID, seatChanges, refunds); err != nil {
+				return fmt.Errorf("auto-cancel conflicting booking: %w", err)
+			}
+			cancelled = true
+		}
+	}
+
+	if cancelled {
+		refund := paymentRefund{
+			PaymentID:      paymentID,
+			ProviderRef:    providerRef,
+			Amount:         amount,
+			IdempotencyKey: fmt.Sprintf("payment-cancelled-%d", paymentID),
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, refund_idempotency_key = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE payment_id = $3
+		`, payments.StatusRefunding, refund.IdempotencyKey, paymentID)
+		if err != nil {
+			return fmt.Errorf("mark payment refunding: %w", err)
+		}
+		refunds.add(refund)
+		return ErrPaymentCancelled
+	}
+
+	if err := setPaymentStatus(ctx, tx, paymentID, payments.StatusCaptured); err != nil {
+		return err
+	}
+	if group {
+		allPaid, err := markSharePaid(ctx, tx, bookingID, shareID.Int64)
+		if err != nil || !allPaid {
+			return err
+		}
+	}
+	return markBookingConfirmed(ctx, tx, bookingID, booking.ShowtimeID, seatChanges)
+}
+
+// Confirm เรียกเก็บเงินผ่าน payment gateway แล้วยืนยันการจองและเปลี่ยนที่นั่งเป็น booked ทั้งหมดใน transaction เดียว
+// ใช้สำหรับ admin ยืนยันการชำระเงินที่เคาน์เตอร์ ลูกค้าทั่วไปยืนยันผ่าน webhook ของผู้ให้บริการ
+// ถ้าส่ง idempotencyKey มา การเรียกซ้ำด้วย key เดิมจะได้ผลลัพธ์เดิมโดยไม่ยืนยันหรือเก็บเงินซ้ำ
+func (s *BookingService) Confirm(ctx context.Context, bookingID int, paymentMethod, idempotencyKey string) (*ConfirmResult, error) {
+	if !payments.IsSupportedMethod(paymentMethod) {
+		return nil, payments.ErrUnsupportedMethod
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	seatChanges := &seatEvents{}
+	refunds := &pendingRefunds{}
+
+	// จอง idempotency key ก่อน ถ้ามีคนใช้ key นี้อยู่จะรอจน transaction นั้นจบ
+	if idempotencyKey != "" {
+		replay, err := claimIdempotencyKey(ctx, tx, confirmPaymentScope, idempotencyKey, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if replay != nil {
+			return replayConfirm(replay)
+		}
+	}
+
+	// ตรวจสอบว่าการจองมีอยู่และยังไม่ได้ชำระเงิน (ล็อกแถวไว้จนจบ transaction)
+	booking, err := lockBookingForPayment(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.BookingStatus == "cancelled" {
+		return nil, ErrBookingCancelled
+	}
+	if booking.PaymentStatus == "paid" {
+		return nil, ErrAlreadyPaid
+	}
+	if group, err := isGroupBooking(ctx, tx, bookingID); err != nil {
+		return nil, err
+	} else if group {
+		return nil, ErrGroupBooking
+	}
+
+	// ตรวจสอบว่าที่นั่งในการจองนี้ถูก confirm โดยคนอื่นไปแล้วหรือไม่
+	conflictSeatID, err := findConfirmConflict(ctx, tx, bookingID, booking.ShowtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if conflictSeatID > 0 {
+		// มีที่นั่งถูก confirm ไปแล้วโดยคนอื่น → ยกเลิกการจองนี้โดยอัตโนมัติ
+		if _, err := s.cancelInTx(ctx, tx, bookingID, booking.ShowtimeID, seatChanges, refunds); err != nil {
+			return nil, fmt.Errorf("auto-cancel conflicting booking: %w", err)
+		}
+		if idempotencyKey != "" {
+			err := completeIdempotencyKey(ctx, tx, confirmPaymentScope, idempotencyKey, idempotencyResultConflict, conflictSeatID, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", err)
+		}
+		seatChanges.publish(s.broker)
+		s.sendRefunds(ctx, refunds)
+		return nil, &ConfirmConflictError{SeatID: conflictSeatID}
+	}
+
+	// เรียกเก็บเงิน ถ้า transaction ไม่ commit หลังจากนี้จะคืนเงินให้อัตโนมัติ
+	intent, err := s.capture(ctx, bookingID, booking.BookingCode, booking.TotalAmount, paymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.refundUncommitted(intent)
+		}
+	}()
+
+	// บันทึก payment
+	var paymentID int
+	paymentQuery := `
+		INSERT INTO payments (booking_id, provider, provider_ref, method, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING payment_id
+	`
+	err = tx.QueryRowContext(ctx, paymentQuery,
+		bookingID, s.gateway.Name(), intent.ProviderRef, intent.Method, intent.Amount, payments.StatusCaptured,
+	).Scan(&paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("record payment: %w", err)
+	}
+
+	if err := markBookingConfirmed(ctx, tx, bookingID, booking.ShowtimeID, seatChanges); err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != "" {
+		err := completeIdempotencyKey(ctx, tx, confirmPaymentScope, idempotencyKey, idempotencyResultConfirmed, 0, paymentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	committed = true
+	seatChanges.publish(s.broker)
+
+	return &ConfirmResult{
+		BookingID:     bookingID,
+		PaymentID:     paymentID,
+		PaymentMethod: intent.Method,
+		Amount:        intent.Amount,
+	}, nil
+}
+
+// PendingPayment ดึงการจองที่ยังรอชำระเงินและถือที่นั่งอยู่ (ใช้สร้าง QR ชำระเงิน)
+// การจองแบบกลุ่มจ่ายทีละส่วนผ่าน StartSharePayment จึงคืน ErrGroupBooking
+func (s *BookingService) PendingPayment(ctx context.Context, bookingID int) (*PendingPayment, error) {
+	pending, err := s.pendingPayment(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := isGroupBooking(ctx, s.db, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if group {
+		return nil, ErrGroupBooking
+	}
+	return pending, nil
+}
+
+// pendingPayment ตรวจว่าการจองยังรอชำระเงินและการถือที่นั่งยังไม่หมดอายุ
+func (s *BookingService) pendingPayment(ctx context.Context, bookingID int) (*PendingPayment, error) {
+	var pending PendingPayment
+	var bookingStatus, paymentStatus string
+	var reservedUntil sql.NullTime
+	query := `
+		SELECT b.booking_id, b.booking_code, b.total_amount, b.booking_status, b.payment_status,
+		       (SELECT MIN(ss.reserved_until) FROM seat_status ss
+		        WHERE ss.booking_id = b.booking_id AND ss.status = 'reserved')
+		FROM bookings b
+		WHERE b.booking_id = $1
+	`
+	err := s.db.QueryRowContext(ctx, query, bookingID).Scan(
+		&pending.BookingID, &pending.BookingCode, &pending.TotalAmount,
+		&bookingStatus, &paymentStatus, &reservedUntil,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking: %w", err)
+	}
+
+	if bookingStatus != "pending" || paymentStatus != "pending" {
+		return nil, ErrBookingNotPending
+	}
+	if !reservedUntil.Valid || !reservedUntil.Time.After(time.Now()) {
+		return nil, ErrHoldExpired
+	}
+
+	pending.ReservedUntil = reservedUntil.Time
+	return &pending, nil
+}
+
+// capture สร้าง intent และเรียกเก็บเงินกับ payment gateway
+func (s *BookingService) capture(ctx context.Context, bookingID int, bookingCode string, amount float64, method string) (*payments.Intent, error) {
+	intent, err := s.gateway.CreateIntent(ctx, payments.IntentRequest{
+		BookingID:   bookingID,
+		BookingCode: bookingCode,
+		Amount:      amount,
+		Method:      method,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	captured, err := s.gateway.Capture(ctx, intent.ProviderRef)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	return captured, nil
+}
+
+// refundUncommitted คืนเงินที่เก็บไปแล้วแต่บันทึกการยืนยันไม่สำเร็จ (ไม่มีแถว payments จึงใช้ provider reference เป็น key)
+func (s *BookingService) refundUncommitted(intent *payments.Intent) {
+	if _, err := s.gateway.Refund(context.Background(), intent.ProviderRef, intent.Amount, "uncommitted-"+intent.ProviderRef); err != nil {
+		log.Printf("Failed to refund uncommitted payment %s: %v", intent.ProviderRef, err)
+	}
+}
+
+// sendRefunds ส่งการคืนเงินที่บันทึกไว้ใน transaction หลัง commit แล้ว
+// รายการที่ล้มเหลวยังเป็น refunding และจะถูกส่งซ้ำโดย job retry_refunds
+func (s *BookingService) sendRefunds(ctx context.Context, refunds *pendingRefunds) {
+	if _, err := refunds.send(ctx, s.db, s.gateway); err != nil {
+		log.Printf("Refunds are waiting for retry: %v", err)
+	}
+}
+
+// lockBookingForPayment ล็อกแถว booking ด้วย FOR UPDATE และคืนสถานะปัจจุบัน
+func lockBookingForPayment(ctx context.Context, tx *sql.Tx, bookingID int) (*pendingBooking, error) {
+	var booking pendingBooking
+	query := `
+		SELECT booking_status, payment_status, showtime_id, booking_code, total_amount
+		FROM bookings WHERE booking_id = $1 FOR UPDATE
+	`
+	err := tx.QueryRowContext(ctx, query, bookingID).Scan(
+		&booking.BookingStatus, &booking.PaymentStatus, &booking.ShowtimeID, &booking.BookingCode, &booking.TotalAmount,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking: %w", err)
+	}
+	return &booking, nil
+}
+
+// findConfirmConflict หาที่นั่งในการจองนี้ที่ถูกการจองอื่น confirm ไปแล้ว (คืน 0 ถ้าไม่มี)
+func findConfirmConflict(ctx context.Context, tx *sql.Tx, bookingID, showtimeID int) (int, error) {
+	conflictQuery := `
+		SELECT bs.seat_id FROM booking_seats bs
+		JOIN bookings b ON bs.booking_id = b.booking_id
+		WHERE bs.seat_id IN (SELECT seat_id FROM booking_seats WHERE booking_id = $1)
+		AND b.showtime_id = $2
+		AND b.booking_status = 'confirmed'
+		AND b.booking_id != $1
+		LIMIT 1
+	`
+	var conflictSeatID int
+	err := tx.QueryRowContext(ctx, conflictQuery, bookingID, showtimeID).Scan(&conflictSeatID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("check seat conflicts: %w", err)
+	}
+	return conflictSeatID, nil
+}
+
+// markBookingConfirmed เปลี่ยนการจองเป็น confirmed/paid และที่นั่งเป็น booked
+func markBookingConfirmed(ctx context.Context, tx *sql.Tx, bookingID, showtimeID int, seatChanges *seatEvents) error {
+	updateQuery := `
+		UPDATE bookings
+		SET payment_status = 'paid', booking_status = 'confirmed', updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateQuery, bookingID); err != nil {
+		return fmt.Errorf("confirm booking: %w", err)
+	}
+
+	updateSeatStatusQuery := `
+		UPDATE seat_status
+		SET status = 'booked', reserved_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1
+		RETURNING seat_id
+	`
+	bookedSeatIDs, err := querySeatIDs(ctx, tx, updateSeatStatusQuery, bookingID)
+	if err != nil {
+		return fmt.Errorf("update seat status: %w", err)
+	}
+	seatChanges.add(showtimeID, events.SeatBooked, bookedSeatIDs)
+	return closeWaitlistOffer(ctx, tx, bookingID, "fulfilled")
+}
+
+// setPaymentStatus อัปเดตสถานะของ payment
+func setPaymentStatus(ctx context.Context, tx *sql.Tx, paymentID int, status string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE payment_id = $2
+	`, status, paymentID)
+	if err != nil {
+		return fmt.Errorf("update payment status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+)
+
+func paymentRefundState(t *testing.T, db *sql.DB, bookingID int) (string, sql.NullString, sql.NullString) {
+	t.Helper()
+	var status string
+	var key, refundRef sql.NullString
+	err := db.QueryRow(`
+		SELECT status, refund_idempotency_key, refund_ref FROM payments WHERE booking_id = $1
+	`, bookingID).Scan(&status, &key, &refundRef)
+	if err != nil {
+		t.Fatalf("fetch payment: %v", err)
+	}
+	return status, key, refundRef
+}
+
+// webhook ที่มาถึงหลังการจองถูกยกเลิก: commit สถานะ refunding ก่อน แล้วคืนเงินเมื่อ commit แล้ว
+func TestHandleWebhookRefundsCancelledBooking(t *testing.T) {
+	db := openTestDB(t)
+	showtimeID, seatIDs := createShowtimeFixture(t, db, 1)
+	userID := createTestUsers(t, db, 1)[0]
+	service := newTestBookingService(db)
+	ctx := context.Background()
+
+	booking, err := service.Reserve(ctx, userID, showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[0]}}, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	start, err := service.StartPayment(ctx, booking.BookingID, payments.MethodCreditCard)
+	if err != nil {
+		t.Fatalf("start payment: %v", err)
+	}
+
+	// จำนวนเงินต่างกันไม่ถึงสตางค์ถือว่าตรงกัน
+	mismatch := &payments.WebhookEvent{Type: payments.EventPaymentSucceeded, ProviderRef: start.ProviderRef, Amount: start.Amount - 0.01}
+	if _, err := service.HandleWebhook(ctx, start.Provider, mismatch); !errors.Is(err, ErrAmountMismatch) {
+		t.Fatalf("webhook short by one satang error = %v, want %v", err, ErrAmountMismatch)
+	}
+
+	if err := service.Cancel(ctx, booking.BookingID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	event := &payments.WebhookEvent{Type: payments.EventPaymentSucceeded, ProviderRef: start.ProviderRef, Amount: start.Amount + 0.001}
+	result, err := service.HandleWebhook(ctx, start.Provider, event)
+	if !errors.Is(err, ErrPaymentCancelled) {
+		t.Fatalf("webhook error = %v, want %v", err, ErrPaymentCancelled)
+	}
+	if result.Status != payments.StatusRefunded {
+		t.Errorf("result status = %s, want %s", result.Status, payments.StatusRefunded)
+	}
+
+	status, key, refundRef := paymentRefundState(t, db, booking.BookingID)
+	if status != payments.StatusRefunded || !key.Valid || !refundRef.Valid {
+		t.Errorf("payment = %s (key %v, ref %v), want refunded with key and ref", status, key, refundRef)
+	}
+
+	// ผู้ให้บริการส่ง webhook ซ้ำ ต้องไม่คืนเงินซ้ำ
+	duplicate, err := service.HandleWebhook(ctx, start.Provider, event)
+	if err != nil {
+		t.Fatalf("duplicate webhook: %v", err)
+	}
+	if !duplicate.Duplicate {
+		t.Error("duplicate webhook was processed again")
+	}
+}
+
+// webhook refund.succeeded ปิด payment ที่ค้าง refunding โดยไม่ต้องรอ job retry_refunds
+func TestHandleWebhookRefundSucceeded(t *testing.T) {
+	db := openTestDB(t)
+	showtimeID, seatIDs := createShowtimeFixture(t, db, 1)
+	userID := createTestUsers(t, db, 1)[0]
+	service := newTestBookingService(db)
+	ctx := context.Background()
+
+	booking, err := service.Reserve(ctx, userID, showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[0]}}, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	start, err := service.StartPayment(ctx, booking.BookingID, payments.MethodCreditCard)
+	if err != nil {
+		t.Fatalf("start payment: %v", err)
+	}
+
+	event := &payments.WebhookEvent{Type: payments.EventRefundSucceeded, ProviderRef: start.ProviderRef, Amount: start.Amount, RefundRef: "refund-1"}
+
+	// payment ที่ยังไม่ได้สั่งคืนเงิน ต้องไม่ถูกเปลี่ยนเป็น refunded
+	result, err := service.HandleWebhook(ctx, start.Provider, event)
+	if err != nil {
+		t.Fatalf("webhook for pending payment: %v", err)
+	}
+	if !result.Duplicate || result.Status != payments.StatusPending {
+		t.Errorf("pending payment result = %+v, want duplicate with status %s", result, payments.StatusPending)
+	}
+
+	if _, err := db.Exec(`UPDATE payments SET status = $1, refund_idempotency_key = 'test-refund' WHERE payment_id = $2`, payments.StatusRefunding, start.PaymentID); err != nil {
+		t.Fatalf("mark payment refunding: %v", err)
+	}
+	result, err = service.HandleWebhook(ctx, start.Provider, event)
+	if err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if result.Duplicate || result.Status != payments.StatusRefunded {
+		t.Errorf("result = %+v, want status %s", result, payments.StatusRefunded)
+	}
+
+	status, _, refundRef := paymentRefundState(t, db, booking.BookingID)
+	if status != payments.StatusRefunded || refundRef.String != "refund-1" {
+		t.Errorf("payment = %s (ref %v), want refunded with ref refund-1", status, refundRef)
+	}
+
+	duplicate, err := service.HandleWebhook(ctx, start.Provider, event)
+	if err != nil {
+		t.Fatalf("duplicate webhook: %v", err)
+	}
+	if !duplicate.Duplicate {
+		t.Error("duplicate webhook was processed again")
+	}
+}
@@ -0,0 +1,659 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+
+	"github.com/lib/pq"
+)
+
+// Errors ที่ BookingService คืนให้ผู้เรียก (handler แปลงเป็น HTTP status เอง)
+var (
+	ErrShowtimeNotFound      = errors.New("showtime not found")
+	ErrBookingNotFound       = errors.New("booking not found")
+	ErrNotEnoughSeats        = errors.New("not enough available seats")
+	ErrInvalidSeats          = errors.New("some seats do not belong to this showtime's theater")
+	ErrBookingNotCancellable = errors.New("cannot cancel this booking")
+	ErrBookingCancelled      = errors.New("cannot confirm payment for cancelled booking")
+	ErrAlreadyPaid           = errors.New("this booking has already been paid")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for another booking")
+	ErrPaymentFailed         = errors.New("payment was not captured")
+	ErrBookingNotPending     = errors.New("booking is not awaiting payment")
+	ErrHoldExpired           = errors.New("seat hold has expired")
+	ErrHoldAlreadyExtended   = errors.New("seat hold has already been extended")
+	// ErrSeatConflict ชนกับการจองอื่นที่กำลังล็อกที่นั่งชุดเดียวกัน (deadlock) โดยไม่รู้ว่าเสียที่นั่งไหนไป
+	ErrSeatConflict = errors.New("seats are being booked by another customer")
+)
+
+// SeatConflictError ที่นั่งถูกคนอื่นถือหรือจองไปแล้ว
+type SeatConflictError struct {
+	SeatIDs []int
+}
+
+func (e *SeatConflictError) Error() string {
+	return fmt.Sprintf("seats %v are no longer available", e.SeatIDs)
+}
+
+// SeatAlreadyConfirmedError ผู้ใช้เคย confirm ที่นั่งนี้ในรอบฉายเดียวกันไปแล้ว
+type SeatAlreadyConfirmedError struct {
+	SeatID int
+}
+
+func (e *SeatAlreadyConfirmedError) Error() string {
+	return fmt.Sprintf("you have already confirmed booking for seat %d in this showtime", e.SeatID)
+}
+
+// ConfirmConflictError ที่นั่งในการจองถูก confirm โดยการจองอื่นแล้ว การจองนี้จึงถูกยกเลิกอัตโนมัติ
+type ConfirmConflictError struct {
+	SeatID int
+}
+
+func (e *ConfirmConflictError) Error() string {
+	return fmt.Sprintf("seat %d has already been confirmed by another booking", e.SeatID)
+}
+
+// ConfirmResult ผลลัพธ์ของการยืนยันการชำระเงิน
+type ConfirmResult struct {
+	BookingID     int
+	PaymentID     int
+	PaymentMethod string
+	Amount        float64
+	Replayed      bool // true ถ้าเป็นผลลัพธ์เดิมจาก Idempotency-Key ที่เคยใช้แล้ว
+}
+
+// PendingPayment ข้อมูลการจองที่รอชำระเงินพร้อมเวลาหมดอายุของการถือที่นั่ง
+type PendingPayment struct {
+	BookingID     int
+	BookingCode   string
+	TotalAmount   float64
+	ReservedUntil time.Time
+}
+
+// ReserveResult ผลลัพธ์ของการจองที่นั่ง
+type ReserveResult struct {
+	BookingID      int
+	BookingCode    string
+	TotalAmount    float64
+	DiscountAmount float64
+	PromoCode      string
+	ReservedUntil  time.Time
+}
+
+// bookingStore ส่วนของ database ที่ BookingService ใช้ (*sql.DB)
+type bookingStore interface {
+	querier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// seatSelectionLocks ล็อกชั่วคราวขณะลูกค้ากำลังเลือกที่นั่ง (*SeatSelections)
+type seatSelectionLocks interface {
+	Select(showtimeID int, sessionID string, userID int, seatIDs []int) ([]int, error)
+	HeldByOthers(showtimeID, userID int, seatIDs []int) []int
+	consume(showtimeID, userID int, seatIDs []int)
+}
+
+// releasedSeatOfferer ส่งที่นั่งที่ถูกคืนให้คิวรอที่นั่ง (*WaitlistService)
+type releasedSeatOfferer interface {
+	offerReleasedSeats(ctx context.Context, tx *sql.Tx, showtimeID int, seatChanges *seatEvents) error
+}
+
+// BookingService รวม logic การจอง (จอง, ยืนยันชำระเงิน, ยกเลิก, หมดอายุ) ไว้ที่เดียว
+// ตัดสินสถานะการถือที่นั่งด้วย bookingHold ส่วนที่ต้องคุยกับภายนอกอยู่หลัง interface เล็กๆ
+type BookingService struct {
+	db            bookingStore
+	gateway       payments.Gateway
+	broker        events.Broker       // กระจายการเปลี่ยนสถานะที่นั่งให้ผังที่นั่งแบบ real-time
+	selections    seatSelectionLocks  // ล็อกชั่วคราวขณะลูกค้ากำลังเลือกที่นั่ง
+	waitlist      releasedSeatOfferer // ส่งที่นั่งที่ถูกคืนให้คิวรอที่นั่ง
+	holdDuration  time.Duration       // เวลาถือที่นั่งเริ่มต้น (สาขาตั้งค่าแทนได้ด้วย cinemas.hold_minutes)
+	holdExtension time.Duration       // เวลาที่ต่อได้หนึ่งครั้งผ่าน Extend
+}
+
+func NewBookingService(db *sql.DB, gateway payments.Gateway, broker events.Broker, selections *SeatSelections, waitlist *WaitlistService, holdDuration, holdExtension time.Duration) *BookingService {
+	return &BookingService{
+		db:            db,
+		gateway:       gateway,
+		broker:        broker,
+		selections:    selections,
+		waitlist:      waitlist,
+		holdDuration:  holdDuration,
+		holdExtension: holdExtension,
+	}
+}
+
+// Reserve จองที่นั่งและถือที่นั่งไว้ใน seat_status จนถึง reserved_until
+// แต่ละที่นั่งระบุประเภทตั๋วได้ (ว่าง = adult) ราคาคิดตามประเภทที่นั่ง กฎราคา และค่าตั๋ว
+// items (ขนม/เครื่องดื่ม) ตัดสต็อกของสาขาใน transaction เดียวกันและรวมเข้า total_amount (ส่วนลดโค้ดคิดจากค่าตั๋วเท่านั้น)
+// promoCode ว่างได้ ถ้าส่งมาต้องใช้ได้ ไม่อย่างนั้นการจองจะไม่ถูกสร้าง (PromoCodeError)
+func (s *BookingService) Reserve(ctx context.Context, userID, showtimeID int, seats []models.BookingSeatRequest, items []models.BookingItemRequest, promoCode string) (*ReserveResult, error) {
+	// ตัดที่นั่งซ้ำออก (ป้องกัน UNIQUE(booking_id, seat_id) ล้ม)
+	seats = uniqueSeatRequests(seats)
+	seatIDs := make([]int, len(seats))
+	for i, seat := range seats {
+		seatIDs[i] = seat.SeatID
+	}
+
+	// ตรวจสอบ showtime
+	var availableSeats, cinemaID int
+	var cinemaHoldMinutes sql.NullInt64
+	query := `
+		SELECT s.available_seats, c.cinema_id, c.hold_minutes
+		FROM showtimes s
+		JOIN theaters t ON s.theater_id = t.theater_id
+		JOIN cinemas c ON t.cinema_id = c.cinema_id
+		WHERE s.showtime_id = $1 AND s.is_active = TRUE
+	`
+	err := s.db.QueryRowContext(ctx, query, showtimeID).Scan(&availableSeats, &cinemaID, &cinemaHoldMinutes)
+	if err == sql.ErrNoRows {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime: %w", err)
+	}
+
+	// ตรวจสอบจำนวนที่นั่งว่างพอหรือไม่
+	if len(seatIDs) > availableSeats {
+		return nil, ErrNotEnoughSeats
+	}
+
+	// ตรวจสอบว่าผู้ใช้จองที่นั่งเดียวกันซ้ำหลังจากคอนเฟิร์มแล้วหรือไม่
+	var confirmedSeatID int
+	confirmedQuery := `
+		SELECT bs.seat_id FROM booking_seats bs
+		JOIN bookings b ON bs.booking_id = b.booking_id
+		WHERE b.user_id = $1 AND b.showtime_id = $2 AND bs.seat_id = ANY($3) AND b.booking_status = 'confirmed'
+		LIMIT 1
+	`
+	err = s.db.QueryRowContext(ctx, confirmedQuery, userID, showtimeID, pq.Array(seatIDs)).Scan(&confirmedSeatID)
+	if err == nil {
+		return nil, &SeatAlreadyConfirmedError{SeatID: confirmedSeatID}
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("check confirmed seats: %w", err)
+	}
+
+	// ที่นั่งที่ผู้ใช้อื่นกำลังเลือกอยู่ (ล็อกชั่วคราวผ่าน WebSocket) จองไม่ได้จนกว่าจะปล่อย
+	if heldSeatIDs := s.selections.HeldByOthers(showtimeID, userID, seatIDs); len(heldSeatIDs) > 0 {
+		return nil, &SeatConflictError{SeatIDs: heldSeatIDs}
+	}
+
+	// สร้าง booking code
+	bookingCode := fmt.Sprintf("BK%d%d", userID, time.Now().Unix())
+
+	// เริ่มต้น transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// ตรวจ is_active ซ้ำโดยล็อกแถวรอบฉายไว้จน commit: CancelShowtime (FOR UPDATE) จะรอให้การจองนี้เสร็จ
+	// แล้วเห็นการจองนี้ หรือการจองนี้รอจนรอบฉายถูกปิดแล้วได้ ErrShowtimeNotFound
+	// ใช้ FOR KEY SHARE ไม่ใช่ FOR SHARE เพราะ trigger ของ seat_status แก้ showtimes.available_seats (FOR NO KEY UPDATE)
+	// ซึ่งชนกับ FOR SHARE ของการจองอื่นที่ทำพร้อมกัน
+	var showtimeActive bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT is_active FROM showtimes WHERE showtime_id = $1 FOR KEY SHARE
+	`, showtimeID).Scan(&showtimeActive)
+	if err == sql.ErrNoRows || (err == nil && !showtimeActive) {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock showtime: %w", err)
+	}
+
+	// ตรวจสอบว่าที่นั่งทั้งหมดอยู่ในโรงของรอบฉายนี้และเปิดใช้งาน พร้อมราคาตามประเภทที่นั่งและประเภทตั๋ว
+	prices, err := quoteSeats(ctx, tx, showtimeID, seats)
+	if err != nil {
+		return nil, err
+	}
+
+	// ล็อกที่นั่งใน seat_status (SELECT ... FOR UPDATE) ก่อนจอง
+	lostSeatIDs, err := lockSeatsForHold(ctx, tx, showtimeID, seatIDs)
+	if isDeadlock(err) {
+		// ชนกับการจองอื่นที่ล็อกที่นั่งชุดเดียวกันอยู่ transaction ถูก abort จึงไม่รู้ว่าเสียที่นั่งไหน ไม่ระบุรายการที่นั่ง
+		return nil, ErrSeatConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock seats: %w", err)
+	}
+	if len(lostSeatIDs) > 0 {
+		return nil, &SeatConflictError{SeatIDs: lostSeatIDs}
+	}
+
+	// สร้าง booking
+	totalAmount := 0.0
+	for _, p := range prices {
+		totalAmount += p.Price
+	}
+
+	// ตรวจโค้ดส่วนลดใน transaction เดียวกัน (ล็อกแถวโค้ดไว้จนกว่าจะบันทึกการใช้)
+	var promo *appliedPromo
+	discountAmount := 0.0
+	if strings.TrimSpace(promoCode) != "" {
+		promo, err = applyPromoCode(ctx, tx, promoCode, userID, showtimeID, len(seatIDs), totalAmount)
+		if err != nil {
+			return nil, err
+		}
+		discountAmount = promo.discount
+		totalAmount -= discountAmount
+	}
+
+	// ตัดสต็อกสินค้าหน้าโรง (คืนสต็อกเมื่อการจองถูกยกเลิกหรือหมดเวลาถือที่นั่ง)
+	concessionLines, err := takeConcessionStock(ctx, tx, cinemaID, items)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range concessionLines {
+		totalAmount += line.unitPrice * float64(line.quantity)
+	}
+
+	var bookingID int
+	bookingQuery := `
+		INSERT INTO bookings (user_id, showtime_id, total_amount, discount_amount, booking_code, booking_status, payment_status)
+		VALUES ($1, $2, $3, $4, $5, 'pending', 'pending')
+		RETURNING booking_id
+	`
+	err = tx.QueryRowContext(ctx, bookingQuery, userID, showtimeID, totalAmount, discountAmount, bookingCode).Scan(&bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("create booking: %w", err)
+	}
+	if promo != nil {
+		if err := recordPromoRedemption(ctx, tx, promo, bookingID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := addBookingItems(ctx, tx, bookingID, concessionLines); err != nil {
+		return nil, err
+	}
+
+	// เพิ่ม booking seats
+	reservedUntil := time.Now().Add(holdDurationFor(s.holdDuration, cinemaHoldMinutes))
+	for _, p := range prices {
+		bookingSeatQuery := `
+			INSERT INTO booking_seats (booking_id, seat_id, ticket_type, price)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.ExecContext(ctx, bookingSeatQuery, bookingID, p.SeatID, p.TicketType, p.Price); err != nil {
+			return nil, fmt.Errorf("add seat %d to booking: %w", p.SeatID, err)
+		}
+	}
+
+	// อัปเดต seat status เป็น reserved (แถวถูกล็อกไว้แล้ว)
+	updateSeatStatusQuery := `
+		UPDATE seat_status
+		SET status = 'reserved', booking_id = $1, reserved_until = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE showtime_id = $3 AND seat_id = ANY($4)
+	`
+	_, err = tx.ExecContext(ctx, updateSeatStatusQuery, bookingID, reservedUntil, showtimeID, pq.Array(seatIDs))
+	if err != nil {
+		return nil, fmt.Errorf("update seat status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	s.selections.consume(showtimeID, userID, seatIDs)
+	seatChanges := &seatEvents{}
+	seatChanges.add(showtimeID, events.SeatReserved, seatIDs)
+	seatChanges.publish(s.broker)
+
+	result := &ReserveResult{
+		BookingID:      bookingID,
+		BookingCode:    bookingCode,
+		TotalAmount:    totalAmount,
+		DiscountAmount: discountAmount,
+		ReservedUntil:  reservedUntil,
+	}
+	if promo != nil {
+		result.PromoCode = promo.code
+	}
+	return result, nil
+}
+
+// Cancel ยกเลิกการจองที่ยังไม่ได้ชำระเงินและคืนที่นั่ง
+func (s *BookingService) Cancel(ctx context.Context, bookingID int) error {
+	// ตรวจสอบ status ว่าสามารถยกเลิกได้หรือไม่
+	var hold bookingHold
+	var showtimeID int
+	query := "SELECT booking_status, showtime_id FROM bookings WHERE booking_id = $1"
+	err := s.db.QueryRowContext(ctx, query, bookingID).Scan(&hold.BookingStatus, &showtimeID)
+	if err == sql.ErrNoRows {
+		return ErrBookingNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("fetch booking: %w", err)
+	}
+
+	if err := hold.checkCancel(); err != nil {
+		return err
+	}
+
+	return s.cancel(ctx, bookingID, showtimeID)
+}
+
+// Extend ต่อเวลาถือที่นั่งของการจองที่รอชำระเงิน ทำได้ครั้งเดียวต่อการจอง
+func (s *BookingService) Extend(ctx context.Context, bookingID int) (time.Time, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockBookingHold(ctx, tx, bookingID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// ต่อเวลาได้เฉพาะ hold ที่ยังไม่หมดอายุ (ถ้าหมดแล้ว cron จะยกเลิกการจองเอง)
+	extendedUntil, err := hold.extend(time.Now(), s.holdExtension)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE seat_status
+		SET reserved_until = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $2 AND status = 'reserved'
+	`, extendedUntil, bookingID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("extend seat hold: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bookings SET hold_extended = TRUE, updated_at = CURRENT_TIMESTAMP WHERE booking_id = $1
+	`, bookingID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("mark hold extended: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return extendedUntil, nil
+}
+
+// Expire ยกเลิกการจองที่ถือที่นั่งเกิน reserved_until และคืนจำนวนการจองที่ยกเลิก
+func (s *BookingService) Expire(ctx context.Context, now time.Time) (int, error) {
+	// หาการจองที่หมดอายุ (ยังไม่ชำระเงิน และ reserved_until ผ่านไปแล้ว)
+	query := `
+		SELECT DISTINCT b.booking_id, b.showtime_id
+		FROM bookings b
+		JOIN seat_status ss ON b.booking_id = ss.booking_id
+		WHERE b.booking_status = 'pending'
+		  AND b.payment_status = 'pending'
+		  AND ss.status = 'reserved'
+		  AND ss.reserved_until < $1
+	`
+	rows, err := s.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("query expired reservations: %w", err)
+	}
+
+	type expiredBooking struct {
+		bookingID  int
+		showtimeID int
+	}
+	expiredBookings := []expiredBooking{}
+	for rows.Next() {
+		var expired expiredBooking
+		if err := rows.Scan(&expired.bookingID, &expired.showtimeID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan expired reservation: %w", err)
+		}
+		expiredBookings = append(expiredBookings, expired)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("query expired reservations: %w", err)
+	}
+
+	// ยกเลิกทีละรายการ รายการที่ล้มเหลวไม่กระทบรายการอื่น
+	cancelledCount := 0
+	for _, expired := range expiredBookings {
+		cancelled, err := s.expire(ctx, expired.bookingID, expired.showtimeID, now)
+		if err != nil {
+			log.Printf("Failed to cancel expired booking %d: %v", expired.bookingID, err)
+			continue
+		}
+		if !cancelled {
+			continue
+		}
+		cancelledCount++
+		log.Printf("Cancelled expired booking %d (showtime: %d)", expired.bookingID, expired.showtimeID)
+	}
+
+	return cancelledCount, nil
+}
+
+// expire ยกเลิกการจองที่หมดเวลาถือที่นั่ง หลังล็อกแถว booking แล้วตรวจซ้ำ
+// (การจองอาจถูกต่อเวลาหรือชำระเงินระหว่างที่ Expire ค้นหา) คืนค่า false ถ้ายังไม่หมดอายุแล้ว
+func (s *BookingService) expire(ctx context.Context, bookingID, showtimeID int, now time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockBookingHold(ctx, tx, bookingID)
+	if err != nil {
+		return false, err
+	}
+	if !hold.expired(now) {
+		return false, nil
+	}
+
+	seatChanges := &seatEvents{}
+	refunds := &pendingRefunds{}
+	cancelled, err := s.cancelInTx(ctx, tx, bookingID, showtimeID, seatChanges, refunds)
+	if err != nil || !cancelled {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	seatChanges.publish(s.broker)
+	s.sendRefunds(ctx, refunds)
+	return true, nil
+}
+
+// cancel เปลี่ยนสถานะการจองเป็น cancelled คืนที่นั่งใน seat_status และ available_seats ใน transaction เดียว
+func (s *BookingService) cancel(ctx context.Context, bookingID, showtimeID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seatChanges := &seatEvents{}
+	refunds := &pendingRefunds{}
+	if _, err := s.cancelInTx(ctx, tx, bookingID, showtimeID, seatChanges, refunds); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	seatChanges.publish(s.broker)
+	s.sendRefunds(ctx, refunds)
+	return nil
+}
+
+// cancelInTx ยกเลิกการจองที่ยัง pending อยู่ภายใน transaction ที่ส่งมา
+// การจองแบบกลุ่มที่มีบางคนจ่ายส่วนของตัวเองแล้วจะถูกบันทึกคืนเงินส่วนนั้นใน refunds (ผู้เรียกส่งหลัง commit)
+// และที่นั่งที่คืนจะส่งต่อให้คิวรอที่นั่ง คืนค่า false ถ้าการจองไม่ได้อยู่ในสถานะ pending แล้ว (เช่นถูกยกเลิกหรือ confirm ไปก่อน)
+func (s *BookingService) cancelInTx(ctx context.Context, tx *sql.Tx, bookingID, showtimeID int, seatChanges *seatEvents, refunds *pendingRefunds) (bool, error) {
+	// ล็อกแถว booking และข้ามถ้าไม่ใช่ pending แล้ว (กันคืนที่นั่งซ้ำ)
+	result, err := tx.ExecContext(ctx, `
+		UPDATE bookings
+		SET booking_status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1 AND booking_status = 'pending'
+	`, bookingID)
+	if err != nil {
+		return false, fmt.Errorf("cancel booking: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := releaseSeats(ctx, tx, bookingID, showtimeID, seatChanges); err != nil {
+		return false, err
+	}
+	if err := restockConcessions(ctx, tx, bookingID); err != nil {
+		return false, err
+	}
+	captured, err := markCapturedPaymentsRefunding(ctx, tx, bookingID, fmt.Sprintf("booking-cancel-%d", bookingID))
+	if err != nil {
+		return false, err
+	}
+	refunds.add(captured...)
+	if err := closeWaitlistOffer(ctx, tx, bookingID, "expired"); err != nil {
+		return false, err
+	}
+	if err := s.waitlist.offerReleasedSeats(ctx, tx, showtimeID, seatChanges); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// releaseSeats คืนที่นั่งของการจองกลับเป็น available (available_seats ปรับตามโดย trigger ของ seat_status)
+func releaseSeats(ctx context.Context, tx *sql.Tx, bookingID, showtimeID int, seatChanges *seatEvents) error {
+	releasedSeatIDs, err := querySeatIDs(ctx, tx, `
+		UPDATE seat_status
+		SET status = 'available', booking_id = NULL, reserved_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1 AND showtime_id = $2
+		RETURNING seat_id
+	`, bookingID, showtimeID)
+	if err != nil {
+		return fmt.Errorf("release seats: %w", err)
+	}
+	seatChanges.add(showtimeID, events.SeatReleased, releasedSeatIDs)
+	return nil
+}
+
+// querySeatIDs รัน query ที่คืน seat_id คอลัมน์เดียว
+func querySeatIDs(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seatIDs := []int{}
+	for rows.Next() {
+		var seatID int
+		if err := rows.Scan(&seatID); err != nil {
+			return nil, err
+		}
+		seatIDs = append(seatIDs, seatID)
+	}
+	return seatIDs, rows.Err()
+}
+
+// uniqueSeatIDs ตัด seat_id ที่ซ้ำกันออก โดยคงลำดับเดิมไว้
+func uniqueSeatIDs(seatIDs []int) []int {
+	seen := make(map[int]bool, len(seatIDs))
+	unique := make([]int, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		if seen[seatID] {
+			continue
+		}
+		seen[seatID] = true
+		unique = append(unique, seatID)
+	}
+	return unique
+}
+
+// lockSeatsForHold ล็อกแถว seat_status ของที่นั่งที่ต้องการด้วย SELECT ... FOR UPDATE
+// แล้วคืน seat_id ที่ถูกคนอื่นถือไว้แล้ว (ต้องเรียกภายใน transaction)
+func lockSeatsForHold(ctx context.Context, tx *sql.Tx, showtimeID int, seatIDs []int) ([]int, error) {
+	// seat_status ไม่มีแถวของที่นั่งที่ยังไม่เคยถูกจอง จึงต้องสร้างแถว 'available' ก่อนถึงจะล็อกได้
+	// insert ตามลำดับ seat_id เช่นกัน (ON CONFLICT รอ transaction ที่ insert ที่นั่งเดียวกันอยู่ตามลำดับที่ insert)
+	sortedSeatIDs := append([]int(nil), seatIDs...)
+	sort.Ints(sortedSeatIDs)
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO seat_status (showtime_id, seat_id, status)
+		SELECT $1, seat_id, 'available' FROM UNNEST($2::int[]) AS seat_id
+		ORDER BY seat_id
+		ON CONFLICT (showtime_id, seat_id) DO NOTHING
+	`, showtimeID, pq.Array(sortedSeatIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	// เรียงตาม seat_id เพื่อให้ทุก transaction ล็อกตามลำดับเดียวกัน (กัน deadlock)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT seat_id, status FROM seat_status
+		WHERE showtime_id = $1 AND seat_id = ANY($2)
+		ORDER BY seat_id
+		FOR UPDATE
+	`, showtimeID, pq.Array(seatIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lost := map[int]bool{}
+	for rows.Next() {
+		var seatID int
+		var status string
+		if err := rows.Scan(&seatID, &status); err != nil {
+			return nil, err
+		}
+		if status != "available" {
+			lost[seatID] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// การจองที่ confirm ไปแล้วก่อนมีแถว seat_status ให้ถือว่าที่นั่งไม่ว่างเช่นกัน
+	confirmedRows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT bs.seat_id FROM booking_seats bs
+		JOIN bookings b ON bs.booking_id = b.booking_id
+		WHERE b.showtime_id = $1 AND bs.seat_id = ANY($2) AND b.booking_status = 'confirmed'
+	`, showtimeID, pq.Array(seatIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer confirmedRows.Close()
+
+	for confirmedRows.Next() {
+		var seatID int
+		if err := confirmedRows.Scan(&seatID); err != nil {
+			return nil, err
+		}
+		lost[seatID] = true
+	}
+	if err := confirmedRows.Err(); err != nil {
+		return nil, err
+	}
+
+	lostSeatIDs := []int{}
+	for _, seatID := range seatIDs {
+		if lost[seatID] {
+			lostSeatIDs = append(lostSeatIDs, seatID)
+		}
+	}
+	return lostSeatIDs, nil
+}
+
+// isDeadlock ตรวจว่า error มาจาก Postgres ตรวจพบ deadlock (40P01)
+func isDeadlock(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40P01"
+}
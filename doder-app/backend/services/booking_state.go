@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// bookingHold สถานะการถือที่นั่งของการจอง ใช้ตัดสินว่าการจองเปลี่ยนสถานะต่อได้หรือไม่
+// (จอง → ต่อเวลา → หมดอายุ/ยกเลิก) แยกจาก database เพื่อให้ทดสอบได้โดยไม่ต้องมี Postgres
+type bookingHold struct {
+	BookingStatus string
+	PaymentStatus string
+	HoldExtended  bool
+	ReservedUntil sql.NullTime // reserved_until ที่เร็วที่สุดของที่นั่งที่ยัง reserved (ไม่มี = ไม่ได้ถือที่นั่งแล้ว)
+}
+
+// holdDurationFor เวลาถือที่นั่งของการจองใหม่ สาขาที่ตั้ง hold_minutes ไว้ใช้ค่านั้นแทนค่าเริ่มต้น
+func holdDurationFor(defaultDuration time.Duration, cinemaHoldMinutes sql.NullInt64) time.Duration {
+	if cinemaHoldMinutes.Valid && cinemaHoldMinutes.Int64 > 0 {
+		return time.Duration(cinemaHoldMinutes.Int64) * time.Minute
+	}
+	return defaultDuration
+}
+
+// awaitingPayment การจองยังรอชำระเงินอยู่
+func (h bookingHold) awaitingPayment() bool {
+	return h.BookingStatus == "pending" && h.PaymentStatus == "pending"
+}
+
+// checkCancel ลูกค้ายกเลิกได้เฉพาะการจองที่ยังไม่ confirm และยังไม่ถูกยกเลิก
+func (h bookingHold) checkCancel() error {
+	if h.BookingStatus == "cancelled" || h.BookingStatus == "confirmed" {
+		return ErrBookingNotCancellable
+	}
+	return nil
+}
+
+// extend คืนเวลาถือที่นั่งใหม่หลังต่อเวลา ต่อได้ครั้งเดียวและเฉพาะ hold ที่ยังไม่หมดอายุ
+func (h bookingHold) extend(now time.Time, extension time.Duration) (time.Time, error) {
+	if !h.awaitingPayment() {
+		return time.Time{}, ErrBookingNotPending
+	}
+	if h.HoldExtended {
+		return time.Time{}, ErrHoldAlreadyExtended
+	}
+	if !h.ReservedUntil.Valid || !h.ReservedUntil.Time.After(now) {
+		return time.Time{}, ErrHoldExpired
+	}
+	return h.ReservedUntil.Time.Add(extension), nil
+}
+
+// expired การจองยังรอชำระเงินแต่เวลาถือที่นั่งผ่านไปแล้ว (cron ยกเลิกได้)
+func (h bookingHold) expired(now time.Time) bool {
+	return h.awaitingPayment() && h.ReservedUntil.Valid && h.ReservedUntil.Time.Before(now)
+}
+
+// lockBookingHold ล็อกแถว booking ด้วย FOR UPDATE และคืนสถานะการถือที่นั่งปัจจุบัน
+func lockBookingHold(ctx context.Context, tx *sql.Tx, bookingID int) (*bookingHold, error) {
+	var hold bookingHold
+	err := tx.QueryRowContext(ctx, `
+		SELECT b.booking_status, b.payment_status, b.hold_extended,
+		       (SELECT MIN(ss.reserved_until) FROM seat_status ss
+		        WHERE ss.booking_id = b.booking_id AND ss.status = 'reserved')
+		FROM bookings b
+		WHERE b.booking_id = $1
+		FOR UPDATE OF b
+	`, bookingID).Scan(&hold.BookingStatus, &hold.PaymentStatus, &hold.HoldExtended, &hold.ReservedUntil)
+	if err == sql.ErrNoRows {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking: %w", err)
+	}
+	return &hold, nil
+}
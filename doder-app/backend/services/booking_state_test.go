@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+var holdNow = time.Date(2026, 3, 14, 18, 0, 0, 0, time.UTC)
+
+func holdUntil(d time.Duration) sql.NullTime {
+	return sql.NullTime{Time: holdNow.Add(d), Valid: true}
+}
+
+func TestHoldDurationFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		cinemaMinutes sql.NullInt64
+		want          time.Duration
+	}{
+		{"cinema not set", sql.NullInt64{}, 15 * time.Minute},
+		{"cinema override", sql.NullInt64{Int64: 7, Valid: true}, 7 * time.Minute},
+		{"non-positive override ignored", sql.NullInt64{Int64: 0, Valid: true}, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := holdDurationFor(15*time.Minute, tt.cinemaMinutes); got != tt.want {
+				t.Errorf("holdDurationFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingHoldCheckCancel(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr error
+	}{
+		{"pending", nil},
+		{"confirmed", ErrBookingNotCancellable},
+		{"cancelled", ErrBookingNotCancellable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			hold := bookingHold{BookingStatus: tt.status, PaymentStatus: "pending"}
+			if err := hold.checkCancel(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkCancel() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBookingHoldExtend(t *testing.T) {
+	tests := []struct {
+		name    string
+		hold    bookingHold
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name: "active hold",
+			hold: bookingHold{BookingStatus: "pending", PaymentStatus: "pending", ReservedUntil: holdUntil(3 * time.Minute)},
+			want: holdNow.Add(8 * time.Minute),
+		},
+		{
+			name:    "already extended",
+			hold:    bookingHold{BookingStatus: "pending", PaymentStatus: "pending", HoldExtended: true, ReservedUntil: holdUntil(3 * time.Minute)},
+			wantErr: ErrHoldAlreadyExtended,
+		},
+		{
+			name:    "hold expired",
+			hold:    bookingHold{BookingStatus: "pending", PaymentStatus: "pending", ReservedUntil: holdUntil(-time.Second)},
+			wantErr: ErrHoldExpired,
+		},
+		{
+			name:    "hold ends now",
+			hold:    bookingHold{BookingStatus: "pending", PaymentStatus: "pending", ReservedUntil: holdUntil(0)},
+			wantErr: ErrHoldExpired,
+		},
+		{
+			name:    "seats no longer held",
+			hold:    bookingHold{BookingStatus: "pending", PaymentStatus: "pending"},
+			wantErr: ErrHoldExpired,
+		},
+		{
+			name:    "confirmed",
+			hold:    bookingHold{BookingStatus: "confirmed", PaymentStatus: "paid"},
+			wantErr: ErrBookingNotPending,
+		},
+		{
+			name:    "cancelled",
+			hold:    bookingHold{BookingStatus: "cancelled", PaymentStatus: "pending", ReservedUntil: holdUntil(3 * time.Minute)},
+			wantErr: ErrBookingNotPending,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.hold.extend(holdNow, 5*time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("extend() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("extend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingHoldExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		hold bookingHold
+		want bool
+	}{
+		{"hold still active", bookingHold{BookingStatus: "pending", PaymentStatus: "pending", ReservedUntil: holdUntil(time.Minute)}, false},
+		{"hold passed", bookingHold{BookingStatus: "pending", PaymentStatus: "pending", ReservedUntil: holdUntil(-time.Minute)}, true},
+		{"extended after lookup", bookingHold{BookingStatus: "pending", PaymentStatus: "pending", HoldExtended: true, ReservedUntil: holdUntil(4 * time.Minute)}, false},
+		{"paid after lookup", bookingHold{BookingStatus: "confirmed", PaymentStatus: "paid", ReservedUntil: holdUntil(-time.Minute)}, false},
+		{"already cancelled", bookingHold{BookingStatus: "cancelled", PaymentStatus: "pending", ReservedUntil: holdUntil(-time.Minute)}, false},
+		{"no held seats", bookingHold{BookingStatus: "pending", PaymentStatus: "pending"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hold.expired(holdNow); got != tt.want {
+				t.Errorf("expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// จอง → ต่อเวลา → หมดอายุ → ยกเลิก ตามลำดับที่เกิดจริง
+func TestBookingHoldLifecycle(t *testing.T) {
+	reservedAt := holdNow
+	hold := bookingHold{
+		BookingStatus: "pending",
+		PaymentStatus: "pending",
+		ReservedUntil: sql.NullTime{Time: reservedAt.Add(holdDurationFor(15*time.Minute, sql.NullInt64{})), Valid: true},
+	}
+
+	if hold.expired(reservedAt.Add(10 * time.Minute)) {
+		t.Fatal("hold expired before reserved_until")
+	}
+	extendedUntil, err := hold.extend(reservedAt.Add(10*time.Minute), 5*time.Minute)
+	if err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if want := reservedAt.Add(20 * time.Minute); !extendedUntil.Equal(want) {
+		t.Fatalf("extended until %v, want %v", extendedUntil, want)
+	}
+	hold.HoldExtended = true
+	hold.ReservedUntil = sql.NullTime{Time: extendedUntil, Valid: true}
+
+	if _, err := hold.extend(reservedAt.Add(12*time.Minute), 5*time.Minute); !errors.Is(err, ErrHoldAlreadyExtended) {
+		t.Fatalf("second extend error = %v, want %v", err, ErrHoldAlreadyExtended)
+	}
+	if hold.expired(reservedAt.Add(16 * time.Minute)) {
+		t.Fatal("extended hold expired at the original reserved_until")
+	}
+	if !hold.expired(reservedAt.Add(21 * time.Minute)) {
+		t.Fatal("hold not expired after the extended reserved_until")
+	}
+	if err := hold.checkCancel(); err != nil {
+		t.Fatalf("cancel expired hold: %v", err)
+	}
+
+	hold.BookingStatus = "cancelled"
+	if hold.expired(reservedAt.Add(21 * time.Minute)) {
+		t.Error("cancelled booking still reported as expired")
+	}
+	if err := hold.checkCancel(); !errors.Is(err, ErrBookingNotCancellable) {
+		t.Errorf("cancel twice error = %v, want %v", err, ErrBookingNotCancellable)
+	}
+}
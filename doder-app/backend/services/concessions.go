@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"movie-booking-system/models"
+)
+
+// ConcessionError สั่งสินค้าหน้าโรงรายการนี้ไม่ได้ (ไม่มีขายที่สาขานี้ หรือของไม่พอ)
+type ConcessionError struct {
+	ConcessionID int
+	Reason       string
+}
+
+func (e *ConcessionError) Error() string {
+	return fmt.Sprintf("concession %d cannot be ordered: %s", e.ConcessionID, e.Reason)
+}
+
+// concessionLine สินค้าที่ตัดสต็อกแล้วพร้อมราคาต่อหน่วย ณ เวลาจอง
+type concessionLine struct {
+	concessionID int
+	quantity     int
+	unitPrice    float64
+}
+
+// takeConcessionStock ตัดสต็อกสินค้าของสาขาภายใน transaction และคืนรายการพร้อมราคา
+// รวมรายการที่ซ้ำกันและล็อกตามลำดับ concession_id เพื่อกัน deadlock ระหว่างการจองพร้อมกัน
+func takeConcessionStock(ctx context.Context, tx *sql.Tx, cinemaID int, items []models.BookingItemRequest) ([]concessionLine, error) {
+	quantities := map[int]int{}
+	for _, item := range items {
+		quantities[item.ConcessionID] += item.Quantity
+	}
+	concessionIDs := make([]int, 0, len(quantities))
+	for concessionID := range quantities {
+		concessionIDs = append(concessionIDs, concessionID)
+	}
+	sort.Ints(concessionIDs)
+
+	lines := make([]concessionLine, 0, len(concessionIDs))
+	for _, concessionID := range concessionIDs {
+		quantity := quantities[concessionID]
+
+		var stock int
+		var price float64
+		err := tx.QueryRowContext(ctx, `
+			SELECT stock_quantity, price FROM concessions
+			WHERE concession_id = $1 AND cinema_id = $2 AND is_active = TRUE
+			FOR UPDATE
+		`, concessionID, cinemaID).Scan(&stock, &price)
+		if err == sql.ErrNoRows {
+			return nil, &ConcessionError{ConcessionID: concessionID, Reason: "not sold at this cinema"}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lock concession %d: %w", concessionID, err)
+		}
+		if stock < quantity {
+			return nil, &ConcessionError{ConcessionID: concessionID, Reason: fmt.Sprintf("only %d left in stock", stock)}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE concessions
+			SET stock_quantity = stock_quantity - $1, updated_at = CURRENT_TIMESTAMP
+			WHERE concession_id = $2
+		`, quantity, concessionID)
+		if err != nil {
+			return nil, fmt.Errorf("take concession %d stock: %w", concessionID, err)
+		}
+		lines = append(lines, concessionLine{concessionID: concessionID, quantity: quantity, unitPrice: price})
+	}
+	return lines, nil
+}
+
+// addBookingItems บันทึกสินค้าที่ตัดสต็อกแล้วลง booking_items
+func addBookingItems(ctx context.Context, tx *sql.Tx, bookingID int, lines []concessionLine) error {
+	for _, line := range lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO booking_items (booking_id, concession_id, quantity, unit_price)
+			VALUES ($1, $2, $3, $4)
+		`, bookingID, line.concessionID, line.quantity, line.unitPrice)
+		if err != nil {
+			return fmt.Errorf("add concession %d to booking: %w", line.concessionID, err)
+		}
+	}
+	return nil
+}
+
+// restockConcessions คืนสต็อกสินค้าของการจองที่ถูกยกเลิก
+func restockConcessions(ctx context.Context, tx *sql.Tx, bookingID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE concessions c
+		SET stock_quantity = c.stock_quantity + bi.quantity, updated_at = CURRENT_TIMESTAMP
+		FROM booking_items bi
+		WHERE bi.booking_id = $1 AND bi.concession_id = c.concession_id
+	`, bookingID)
+	if err != nil {
+		return fmt.Errorf("restock concessions: %w", err)
+	}
+	return nil
+}
@@ -1,183 +1,287 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
+
+	"movie-booking-system/config"
+	"movie-booking-system/scheduler"
+
+	"github.com/lib/pq"
+)
+
+// ชื่อ job ที่ลงทะเบียนกับ scheduler
+const (
+	JobCancelExpiredReservations = "cancel_expired_reservations"
+	JobCancelledBookingRetention = "cancelled_booking_retention"
+	JobRetryRefunds              = "retry_refunds"
 )
 
 type CronService struct {
-	db *sql.DB
+	db             *sql.DB
+	bookingService *BookingService
+	refundService  *RefundService
+	scheduler      *scheduler.Scheduler
+	retention      time.Duration // การจองที่ยกเลิกนานกว่านี้จะถูก archive แล้วลบ
 }
 
-func NewCronService(db *sql.DB) *CronService {
-	return &CronService{db: db}
+// NewCronService สร้าง scheduler และลงทะเบียน job ทั้งหมด
+// cron expression ของแต่ละ job ตั้งผ่าน environment ได้ (เช่น CRON_CANCEL_EXPIRED)
+func NewCronService(db *sql.DB, bookingService *BookingService, refundService *RefundService) (*CronService, error) {
+	s := &CronService{
+		db:             db,
+		bookingService: bookingService,
+		refundService:  refundService,
+		scheduler:      scheduler.New(db),
+		retention:      config.CancelledBookingRetention(),
+	}
+
+	// ยกเลิกการจองที่หมดเวลาถือที่นั่ง (ค่าเริ่มต้นทุก 1 นาที)
+	spec := config.CronSchedule("CRON_CANCEL_EXPIRED", "* * * * *")
+	if err := s.scheduler.Register(JobCancelExpiredReservations, spec, s.CancelExpiredReservations); err != nil {
+		return nil, fmt.Errorf("register %s: %w", JobCancelExpiredReservations, err)
+	}
+
+	// archive และลบการจองที่ยกเลิกเก่าๆ (ค่าเริ่มต้นวันละครั้งตอนตี 3 ครึ่ง)
+	spec = config.CronSchedule("CRON_RETENTION", "30 3 * * *")
+	if err := s.scheduler.Register(JobCancelledBookingRetention, spec, s.runRetention); err != nil {
+		return nil, fmt.Errorf("register %s: %w", JobCancelledBookingRetention, err)
+	}
+
+	// ส่งคำขอคืนเงินที่ผู้ให้บริการยังไม่ตอบรับซ้ำ (ค่าเริ่มต้นทุก 5 นาที)
+	spec = config.CronSchedule("CRON_RETRY_REFUNDS", "*/5 * * * *")
+	if err := s.scheduler.Register(JobRetryRefunds, spec, s.RetryRefunds); err != nil {
+		return nil, fmt.Errorf("register %s: %w", JobRetryRefunds, err)
+	}
+
+	return s, nil
 }
 
-// StartCronJobs
-func (s *CronService) StartCronJobs() {
+// StartCronJobs เริ่ม scheduler จนกว่า ctx จะถูกยกเลิก
+func (s *CronService) StartCronJobs(ctx context.Context) error {
 	log.Println("🕐 Starting cron jobs...")
 
-	// รัน auto-cancel expired reservations ทุก 1 นาที
-	go s.runAutoCancelExpiredReservations()
+	if err := s.scheduler.Start(ctx); err != nil {
+		return err
+	}
 
 	log.Println("✅ Cron jobs started successfully")
+	return nil
 }
 
-// runAutoCancelExpiredReservations ยกเลิกการจองที่หมดอายุอัตโนมัติ
-func (s *CronService) runAutoCancelExpiredReservations() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	// รันทันทีครั้งแรก
-	s.CancelExpiredReservations()
+// Wait รอให้ job ที่กำลังรันอยู่ทำจนเสร็จ (เรียกหลังยกเลิก ctx ของ StartCronJobs)
+func (s *CronService) Wait() {
+	s.scheduler.Wait()
+	log.Println("Cron jobs stopped")
+}
 
-	// รันทุก 1 นาที
-	for range ticker.C {
-		s.CancelExpiredReservations()
-	}
+// TriggerJob รัน job ทันทีนอกรอบ (Public - เรียกได้จาก handler)
+func (s *CronService) TriggerJob(ctx context.Context, name string) error {
+	return s.scheduler.RunNow(ctx, name)
 }
 
-// CancelExpiredReservations ยกเลิกการจองที่หมดเวลา (Public - เรียกได้จาก handler)
-func (s *CronService) CancelExpiredReservations() {
+// CancelExpiredReservations ยกเลิกการจองที่หมดเวลา
+func (s *CronService) CancelExpiredReservations(ctx context.Context) error {
 	now := time.Now()
 	log.Printf("Running auto-cancel expired reservations at %s", now.Format("2006-01-02 15:04:05"))
 
-	// เริ่ม transaction
-	tx, err := s.db.Begin()
+	cancelledCount, err := s.bookingService.Expire(ctx, now)
 	if err != nil {
-		log.Printf("Failed to start transaction: %v", err)
-		return
+		return fmt.Errorf("cancel expired reservations: %w", err)
 	}
-	defer tx.Rollback()
 
-	// 1. หาการจองที่หมดอายุ (reserved เกิน 10 นาที และยังไม่ชำระเงิน หรือยืนยันการจอง)
-	query := `
-		SELECT DISTINCT b.booking_id, b.showtime_id,
-		       (SELECT COUNT(*) FROM booking_seats WHERE booking_id = b.booking_id) as seat_count
-		FROM bookings b
-		JOIN seat_status ss ON b.booking_id = ss.booking_id
-		WHERE b.booking_status = 'pending'
-		  AND b.payment_status = 'pending'
-		  AND ss.status = 'reserved'
-		  AND ss.reserved_until < $1
-	`
+	if cancelledCount > 0 {
+		log.Printf("Auto-cancelled %d expired reservation(s)", cancelledCount)
+	} else {
+		log.Printf("No expired reservations found")
+	}
+	return nil
+}
 
-	rows, err := tx.Query(query, now)
+// RetryRefunds ส่งคำขอคืนเงินที่ค้างสถานะ refunding ซ้ำ
+func (s *CronService) RetryRefunds(ctx context.Context) error {
+	refundedCount, err := s.refundService.RetryRefunds(ctx)
+	if refundedCount > 0 {
+		log.Printf("Refunded %d pending payment(s)", refundedCount)
+	}
 	if err != nil {
-		log.Printf("Failed to query expired reservations: %v", err)
-		return
+		return fmt.Errorf("retry refunds: %w", err)
 	}
-	defer rows.Close()
-
-	cancelledCount := 0
-	expiredBookings := []struct {
-		bookingID  int
-		showtimeID int
-		seatCount  int
-	}{}
+	return nil
+}
 
-	for rows.Next() {
-		var bookingID, showtimeID, seatCount int
-		if err := rows.Scan(&bookingID, &showtimeID, &seatCount); err != nil {
-			log.Printf("Failed to scan row: %v", err)
-			continue
-		}
-		expiredBookings = append(expiredBookings, struct {
-			bookingID  int
-			showtimeID int
-			seatCount  int
-		}{bookingID, showtimeID, seatCount})
-	}
-
-	// 2. ยกเลิกการจองที่หมดอายุแต่ละรายการ
-	for _, expired := range expiredBookings {
-		// 2.1 อัพเดทสถานะการจอง
-		_, err := tx.Exec(`
-			UPDATE bookings
-			SET booking_status = 'cancelled', 
-			    updated_at = CURRENT_TIMESTAMP
-			WHERE booking_id = $1
-		`, expired.bookingID)
+// retentionBatchSize จำนวนการจองที่ย้ายต่อหนึ่ง transaction (กัน lock ตารางนานเกินไป)
+const retentionBatchSize = 500
 
-		if err != nil {
-			log.Printf("Failed to cancel booking %d: %v", expired.bookingID, err)
-			continue
-		}
+// RetentionResult ผลลัพธ์ของ retention job
+type RetentionResult struct {
+	Cutoff   time.Time `json:"cutoff"`
+	DryRun   bool      `json:"dry_run"`
+	Bookings int       `json:"bookings"`
+	Seats    int       `json:"seats"`
+}
 
-		// 2.2 ลบสถานะที่นั่ง (คืนที่นั่ง)
-		_, err = tx.Exec(`
-			DELETE FROM seat_status
-			WHERE booking_id = $1 AND showtime_id = $2
-		`, expired.bookingID, expired.showtimeID)
+// runRetention job ที่ scheduler เรียก (archive แล้วลบจริง)
+func (s *CronService) runRetention(ctx context.Context) error {
+	_, err := s.CleanOldCancelledBookings(ctx, false)
+	return err
+}
 
+// CleanOldCancelledBookings ย้ายการจองที่ยกเลิกเกิน retention ไป archived_bookings แล้วลบออกจาก bookings
+// dryRun = true จะนับเฉพาะจำนวนที่จะถูกย้ายโดยไม่แก้ไขข้อมูล
+func (s *CronService) CleanOldCancelledBookings(ctx context.Context, dryRun bool) (*RetentionResult, error) {
+	result := &RetentionResult{Cutoff: time.Now().Add(-s.retention), DryRun: dryRun}
+
+	if dryRun {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT COUNT(*), COALESCE(SUM((SELECT COUNT(*) FROM booking_seats bs WHERE bs.booking_id = b.booking_id)), 0)
+			FROM bookings b
+			WHERE `+retentionCandidateCondition+`
+		`, result.Cutoff).Scan(&result.Bookings, &result.Seats)
 		if err != nil {
-			log.Printf("Failed to release seats for booking %d: %v", expired.bookingID, err)
-			continue
+			return nil, fmt.Errorf("count old cancelled bookings: %w", err)
 		}
+		return result, nil
+	}
 
-		// 2.3 คืนจำนวนที่นั่งว่าง
-		_, err = tx.Exec(`
-			UPDATE showtimes
-			SET available_seats = available_seats + $1
-			WHERE showtime_id = $2
-		`, expired.seatCount, expired.showtimeID)
-
+	for {
+		bookings, seats, err := s.archiveCancelledBatch(ctx, result.Cutoff)
 		if err != nil {
-			log.Printf("Failed to update available seats for showtime %d: %v", expired.showtimeID, err)
-			continue
+			return result, err
 		}
+		result.Bookings += bookings
+		result.Seats += seats
+		if bookings < retentionBatchSize {
+			break
+		}
+	}
 
-		cancelledCount++
-		log.Printf("Cancelled expired booking %d (showtime: %d, seats: %d)",
-			expired.bookingID, expired.showtimeID, expired.seatCount)
+	if result.Bookings > 0 {
+		log.Printf("Archived %d old cancelled booking(s) with %d seat(s)", result.Bookings, result.Seats)
 	}
+	return result, nil
+}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
-		return
+// retentionCandidateCondition การจองที่ยกเลิกก่อน cutoff ไม่มีที่นั่งใน seat_status ที่ยังถูกถือ/ขายอยู่
+// และไม่มี payment ที่ยังรอคืนเงิน (job retry_refunds ต้องใช้แถว payments นั้น)
+const retentionCandidateCondition = `
+	b.booking_status = 'cancelled'
+	AND b.updated_at < $1
+	AND NOT EXISTS (
+		SELECT 1 FROM seat_status ss
+		WHERE ss.booking_id = b.booking_id AND ss.status <> 'available'
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM payments p
+		WHERE p.booking_id = b.booking_id AND p.status = 'refunding'
+	)
+`
+
+// archiveCancelledBatch ย้ายการจองหนึ่ง batch ภายใน transaction เดียว (archive และ purge สำเร็จหรือล้มพร้อมกัน)
+func (s *CronService) archiveCancelledBatch(ctx context.Context, cutoff time.Time) (int, int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if cancelledCount > 0 {
-		log.Printf("Auto-cancelled %d expired reservation(s)", cancelledCount)
-	} else {
-		log.Printf("No expired reservations found")
+	rows, err := tx.QueryContext(ctx, `
+		SELECT b.booking_id FROM bookings b
+		WHERE `+retentionCandidateCondition+`
+		ORDER BY b.booking_id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, cutoff, retentionBatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("select old cancelled bookings: %w", err)
+	}
+	bookingIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("scan booking id: %w", err)
+		}
+		bookingIDs = append(bookingIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("select old cancelled bookings: %w", err)
+	}
+	if len(bookingIDs) == 0 {
+		return 0, 0, nil
 	}
-}
 
-// CleanOldCancelledBookings ลบข้อมูลการจองที่ยกเลิกเก่าๆ (optional - รัน 1 วันครั้ง)
-func (s *CronService) CleanOldCancelledBookings() {
-	// ลบการจองที่ยกเลิกเกิน 30 วัน
-	cutoffDate := time.Now().AddDate(0, 0, -30)
+	var seatCount int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM booking_seats WHERE booking_id = ANY($1)
+	`, pq.Array(bookingIDs)).Scan(&seatCount)
+	if err != nil {
+		return 0, 0, fmt.Errorf("count archived seats: %w", err)
+	}
 
-	result, err := s.db.Exec(`
-		DELETE FROM bookings
-		WHERE booking_status = 'cancelled'
-		  AND created_at < $1
-	`, cutoffDate)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO archived_bookings (
+			booking_id, user_id, showtime_id, booking_date, total_amount, booking_status, payment_status,
+			booking_code, created_at, updated_at, seats, payments, refund_requests, discount_amount, promo_redemptions,
+			booking_items, booking_shares
+		)
+		SELECT
+			b.booking_id, b.user_id, b.showtime_id, b.booking_date, b.total_amount, b.booking_status, b.payment_status,
+			b.booking_code, b.created_at, b.updated_at,
+			COALESCE((SELECT jsonb_agg(to_jsonb(bs) ORDER BY bs.seat_id) FROM booking_seats bs WHERE bs.booking_id = b.booking_id), '[]'),
+			COALESCE((SELECT jsonb_agg(to_jsonb(p) ORDER BY p.payment_id) FROM payments p WHERE p.booking_id = b.booking_id), '[]'),
+			COALESCE((SELECT jsonb_agg(to_jsonb(r) ORDER BY r.refund_id) FROM refund_requests r WHERE r.booking_id = b.booking_id), '[]'),
+			b.discount_amount,
+			COALESCE((SELECT jsonb_agg(to_jsonb(pr) ORDER BY pr.redemption_id) FROM promo_redemptions pr WHERE pr.booking_id = b.booking_id), '[]'),
+			COALESCE((SELECT jsonb_agg(to_jsonb(bi) ORDER BY bi.booking_item_id) FROM booking_items bi WHERE bi.booking_id = b.booking_id), '[]'),
+			COALESCE((SELECT jsonb_agg(to_jsonb(sh) ORDER BY sh.share_id) FROM booking_shares sh WHERE sh.booking_id = b.booking_id), '[]')
+		FROM bookings b
+		WHERE b.booking_id = ANY($1)
+		ON CONFLICT (booking_id) DO NOTHING
+	`, pq.Array(bookingIDs))
+	if err != nil {
+		return 0, 0, fmt.Errorf("archive bookings: %w", err)
+	}
+
+	// seat_status.booking_id ไม่มี ON DELETE CASCADE ต้องตัดการอ้างอิงก่อนลบ
+	_, err = tx.ExecContext(ctx, `
+		UPDATE seat_status SET booking_id = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = ANY($1)
+	`, pq.Array(bookingIDs))
+	if err != nil {
+		return 0, 0, fmt.Errorf("detach seat status: %w", err)
+	}
 
+	// booking_seats, booking_items, booking_shares, payments, refund_requests และ idempotency_keys ถูกลบตาม ON DELETE CASCADE
+	_, err = tx.ExecContext(ctx, "DELETE FROM bookings WHERE booking_id = ANY($1)", pq.Array(bookingIDs))
 	if err != nil {
-		log.Printf("Failed to clean old cancelled bookings: %v", err)
-		return
+		return 0, 0, fmt.Errorf("purge bookings: %w", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected > 0 {
-		log.Printf("Cleaned %d old cancelled booking(s)", rowsAffected)
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit transaction: %w", err)
 	}
+	return len(bookingIDs), seatCount, nil
 }
 
-// GetCronStatus ดูสถานะ cronjob (optional - สำหรับ monitoring)
-func (s *CronService) GetCronStatus() map[string]interface{} {
+// GetCronStatus ดูสถานะ cronjob (สำหรับ monitoring) เวลารันล่าสุด/ถัดไปอ่านจาก scheduled_jobs
+func (s *CronService) GetCronStatus(ctx context.Context) (map[string]interface{}, error) {
+	jobs, err := s.scheduler.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	status := map[string]interface{}{
-		"running":  true,
-		"last_run": time.Now().Format("2006-01-02 15:04:05"),
+		"jobs": jobs,
 	}
 
 	// นับจำนวนการจองที่รอหมดอายุ
 	var pendingCount int
-	s.db.QueryRow(`
+	s.db.QueryRowContext(ctx, `
 		SELECT COUNT(DISTINCT b.booking_id)
 		FROM bookings b
 		JOIN seat_status ss ON b.booking_id = ss.booking_id
@@ -190,7 +294,7 @@ func (s *CronService) GetCronStatus() map[string]interface{} {
 	status["pending_reservations"] = pendingCount
 
 	var expiredCount int
-	s.db.QueryRow(`
+	s.db.QueryRowContext(ctx, `
 		SELECT COUNT(DISTINCT b.booking_id)
 		FROM bookings b
 		JOIN seat_status ss ON b.booking_id = ss.booking_id
@@ -202,5 +306,5 @@ func (s *CronService) GetCronStatus() map[string]interface{} {
 
 	status["expired_reservations"] = expiredCount
 
-	return status
+	return status, nil
 }
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrGroupExists      = errors.New("booking is already a group booking")
+	ErrNotGroupBooking  = errors.New("booking is not a group booking")
+	ErrGroupBooking     = errors.New("group bookings are paid per share")
+	ErrShareNotFound    = errors.New("you have no share in this booking")
+	ErrShareAlreadyPaid = errors.New("this share has already been paid")
+)
+
+// GroupBookingError แบ่งที่นั่งให้ผู้ถูกเชิญไม่ได้ (เบอร์ไม่พบ ที่นั่งไม่อยู่ในการจอง ฯลฯ)
+type GroupBookingError struct {
+	Reason string
+}
+
+func (e *GroupBookingError) Error() string {
+	return "cannot create group booking: " + e.Reason
+}
+
+// CreateGroup เปลี่ยนการจองที่รอชำระเงินเป็นการจองแบบกลุ่ม ผู้ถูกเชิญจ่ายค่าที่นั่งของตัวเอง
+// ส่วนลดโค้ดเฉลี่ยตามค่าตั๋ว ผู้จัดจ่ายที่นั่งที่เหลือและสินค้าหน้าโรงทั้งหมด
+// การจองจะ confirmed เมื่อทุกส่วนจ่ายครบภายในเวลาถือที่นั่งเดิม
+func (s *BookingService) CreateGroup(ctx context.Context, bookingID, organizerID int, invites []models.GroupInviteRequest) (*models.GroupBooking, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	booking, err := lockBookingForPayment(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.BookingStatus != "pending" || booking.PaymentStatus != "pending" {
+		return nil, ErrBookingNotPending
+	}
+
+	var reservedUntil sql.NullTime
+	var discountAmount float64
+	var group bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT MIN(reserved_until) FROM seat_status WHERE booking_id = b.booking_id AND status = 'reserved'),
+			b.discount_amount,
+			EXISTS(SELECT 1 FROM booking_shares WHERE booking_id = b.booking_id)
+		FROM bookings b WHERE b.booking_id = $1
+	`, bookingID).Scan(&reservedUntil, &discountAmount, &group)
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking hold: %w", err)
+	}
+	if !reservedUntil.Valid || !reservedUntil.Time.After(time.Now()) {
+		return nil, ErrHoldExpired
+	}
+	if group {
+		return nil, ErrGroupExists
+	}
+
+	seatPrices := map[int]float64{}
+	ticketSubtotal := 0.0
+	rows, err := tx.QueryContext(ctx, "SELECT seat_id, price FROM booking_seats WHERE booking_id = $1", bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking seats: %w", err)
+	}
+	for rows.Next() {
+		var seatID int
+		var price float64
+		if err := rows.Scan(&seatID, &price); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan booking seat: %w", err)
+		}
+		seatPrices[seatID] = price
+		ticketSubtotal += price
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch booking seats: %w", err)
+	}
+
+	// ส่วนลดโค้ดเฉลี่ยให้ทุกที่นั่งตามสัดส่วนราคา
+	priceRatio := 1.0
+	if ticketSubtotal > 0 {
+		priceRatio = (ticketSubtotal - discountAmount) / ticketSubtotal
+	}
+
+	type inviteShare struct {
+		userID  int
+		seatIDs []int
+		amount  float64
+	}
+	shares := make([]inviteShare, 0, len(invites))
+	invited := map[int]bool{}
+	assigned := map[int]bool{}
+	inviteesAmount := 0.0
+	for _, invite := range invites {
+		userID, err := findUserByPhone(ctx, tx, strings.TrimSpace(invite.Phone))
+		if err != nil {
+			return nil, err
+		}
+		if userID == organizerID {
+			return nil, &GroupBookingError{Reason: "you cannot invite yourself"}
+		}
+		if invited[userID] {
+			return nil, &GroupBookingError{Reason: fmt.Sprintf("phone %s is invited more than once", invite.Phone)}
+		}
+		invited[userID] = true
+
+		seatSum := 0.0
+		for _, seatID := range invite.SeatIDs {
+			price, ok := seatPrices[seatID]
+			if !ok {
+				return nil, &GroupBookingError{Reason: fmt.Sprintf("seat %d is not part of this booking", seatID)}
+			}
+			if assigned[seatID] {
+				return nil, &GroupBookingError{Reason: fmt.Sprintf("seat %d is assigned more than once", seatID)}
+			}
+			assigned[seatID] = true
+			seatSum += price
+		}
+
+		amount := math.Round(seatSum*priceRatio*100) / 100
+		inviteesAmount += amount
+		shares = append(shares, inviteShare{userID: userID, seatIDs: invite.SeatIDs, amount: amount})
+	}
+	if len(assigned) == len(seatPrices) {
+		return nil, &GroupBookingError{Reason: "the organizer must keep at least one seat"}
+	}
+
+	// ผู้จัดจ่ายส่วนที่เหลือ (ที่นั่งของตัวเอง + สินค้าหน้าโรง) ไม่ให้ติดลบจากการปัดเศษ
+	organizerAmount := math.Max(0, math.Round((booking.TotalAmount-inviteesAmount)*100)/100)
+	var organizerShareID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO booking_shares (booking_id, user_id, is_organizer, amount)
+		VALUES ($1, $2, TRUE, $3)
+		RETURNING share_id
+	`, bookingID, organizerID, organizerAmount).Scan(&organizerShareID)
+	if err != nil {
+		return nil, fmt.Errorf("create organizer share: %w", err)
+	}
+
+	for _, share := range shares {
+		var shareID int
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO booking_shares (booking_id, user_id, amount)
+			VALUES ($1, $2, $3)
+			RETURNING share_id
+		`, bookingID, share.userID, share.amount).Scan(&shareID)
+		if err != nil {
+			return nil, fmt.Errorf("create share: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE booking_seats SET share_id = $1 WHERE booking_id = $2 AND seat_id = ANY($3)
+		`, shareID, bookingID, pq.Array(share.seatIDs))
+		if err != nil {
+			return nil, fmt.Errorf("assign share seats: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE booking_seats SET share_id = $1 WHERE booking_id = $2 AND share_id IS NULL
+	`, organizerShareID, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("assign organizer seats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return s.Group(ctx, bookingID)
+}
+
+// Group ดึงการจองแบบกลุ่มพร้อมส่วนที่แต่ละคนต้องจ่ายและที่นั่งของแต่ละคน
+func (s *BookingService) Group(ctx context.Context, bookingID int) (*models.GroupBooking, error) {
+	group := &models.GroupBooking{BookingID: bookingID}
+	var reservedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT b.booking_code, b.booking_status, b.total_amount,
+		       (SELECT MIN(ss.reserved_until) FROM seat_status ss
+		        WHERE ss.booking_id = b.booking_id AND ss.status = 'reserved')
+		FROM bookings b
+		WHERE b.booking_id = $1
+	`, bookingID).Scan(&group.BookingCode, &group.BookingStatus, &group.TotalAmount, &reservedUntil)
+	if err == sql.ErrNoRows {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking: %w", err)
+	}
+	if reservedUntil.Valid {
+		group.ReservedUntil = &reservedUntil.Time
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sh.share_id, sh.booking_id, sh.user_id, u.first_name, u.last_name,
+		       sh.is_organizer, sh.amount, sh.status, sh.paid_at
+		FROM booking_shares sh
+		JOIN users u ON sh.user_id = u.user_id
+		WHERE sh.booking_id = $1
+		ORDER BY sh.is_organizer DESC, sh.share_id
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch shares: %w", err)
+	}
+	defer rows.Close()
+
+	shareIDs := []int{}
+	for rows.Next() {
+		share, err := scanBookingShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		if share.Status == "paid" {
+			group.PaidAmount += share.Amount
+		}
+		group.Shares = append(group.Shares, *share)
+		shareIDs = append(shareIDs, share.ShareID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch shares: %w", err)
+	}
+	if len(group.Shares) == 0 {
+		return nil, ErrNotGroupBooking
+	}
+
+	seats, err := s.shareSeats(ctx, shareIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range group.Shares {
+		group.Shares[i].Seats = seats[group.Shares[i].ShareID]
+	}
+	return group, nil
+}
+
+// UserShares ดึงส่วนที่ผู้ใช้ต้องจ่ายในการจองแบบกลุ่มทั้งหมด (รวมคำเชิญที่ยังไม่ได้จ่าย)
+func (s *BookingService) UserShares(ctx context.Context, userID int) ([]models.UserBookingShare, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sh.share_id, sh.booking_id, sh.user_id, u.first_name, u.last_name,
+		       sh.is_organizer, sh.amount, sh.status, sh.paid_at,
+		       b.booking_code, b.booking_status, m.title, c.cinema_name, st.show_date, st.show_time,
+		       (SELECT MIN(ss.reserved_until) FROM seat_status ss
+		        WHERE ss.booking_id = b.booking_id AND ss.status = 'reserved')
+		FROM booking_shares sh
+		JOIN users u ON sh.user_id = u.user_id
+		JOIN bookings b ON sh.booking_id = b.booking_id
+		JOIN showtimes st ON b.showtime_id = st.showtime_id
+		JOIN movies m ON st.movie_id = m.movie_id
+		JOIN theaters t ON st.theater_id = t.theater_id
+		JOIN cinemas c ON t.cinema_id = c.cinema_id
+		WHERE sh.user_id = $1
+		ORDER BY sh.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch user shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []models.UserBookingShare{}
+	shareIDs := []int{}
+	for rows.Next() {
+		var share models.UserBookingShare
+		var paidAt, showDate, reservedUntil sql.NullTime
+		var showTime sql.NullString
+		err := rows.Scan(
+			&share.ShareID, &share.BookingID, &share.UserID, &share.FirstName, &share.LastName,
+			&share.IsOrganizer, &share.Amount, &share.Status, &paidAt,
+			&share.BookingCode, &share.BookingStatus, &share.MovieTitle, &share.CinemaName, &showDate, &showTime,
+			&reservedUntil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan user share: %w", err)
+		}
+		if paidAt.Valid {
+			share.PaidAt = &paidAt.Time
+		}
+		if reservedUntil.Valid {
+			share.ReservedUntil = &reservedUntil.Time
+		}
+		share.ShowDate = showDate.Time.Format("2006-01-02")
+		share.ShowTime = showTime.String
+		shares = append(shares, share)
+		shareIDs = append(shareIDs, share.ShareID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch user shares: %w", err)
+	}
+
+	seats, err := s.shareSeats(ctx, shareIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		shares[i].Seats = seats[shares[i].ShareID]
+	}
+	return shares, nil
+}
+
+// StartSharePayment เริ่มชำระเงินส่วนของผู้ใช้ในการจองแบบกลุ่ม (ยืนยันผ่าน webhook เหมือน StartPayment)
+func (s *BookingService) StartSharePayment(ctx context.Context, bookingID, userID int, paymentMethod string) (*PaymentStart, error) {
+	if !payments.IsSupportedMethod(paymentMethod) {
+		return nil, payments.ErrUnsupportedMethod
+	}
+
+	pending, err := s.pendingPayment(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shareID int
+	var amount float64
+	var status string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT share_id, amount, status FROM booking_shares
+		WHERE booking_id = $1 AND user_id = $2
+	`, bookingID, userID).Scan(&shareID, &amount, &status)
+	if err == sql.ErrNoRows {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch share: %w", err)
+	}
+	if status != "pending" {
+		return nil, ErrShareAlreadyPaid
+	}
+
+	return s.startPayment(ctx, bookingID, pending.BookingCode, &shareID, amount, paymentMethod)
+}
+
+// shareSeats ดึงที่นั่งของแต่ละส่วน (share_id -> ที่นั่ง)
+func (s *BookingService) shareSeats(ctx context.Context, shareIDs []int) (map[int][]models.SeatInfo, error) {
+	seats := make(map[int][]models.SeatInfo, len(shareIDs))
+	for _, shareID := range shareIDs {
+		seats[shareID] = []models.SeatInfo{}
+	}
+	if len(shareIDs) == 0 {
+		return seats, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT bs.share_id, s.seat_id, s.seat_row, s.seat_number, bs.ticket_type, bs.price
+		FROM booking_seats bs
+		JOIN seats s ON bs.seat_id = s.seat_id
+		WHERE bs.share_id = ANY($1)
+		ORDER BY s.seat_row, s.seat_number
+	`, pq.Array(shareIDs))
+	if err != nil {
+		return nil, fmt.Errorf("fetch share seats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shareID int
+		var seat models.SeatInfo
+		if err := rows.Scan(&shareID, &seat.SeatID, &seat.SeatRow, &seat.SeatNumber, &seat.TicketType, &seat.Price); err != nil {
+			return nil, fmt.Errorf("scan share seat: %w", err)
+		}
+		seats[shareID] = append(seats[shareID], seat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch share seats: %w", err)
+	}
+	return seats, nil
+}
+
+func scanBookingShare(row rowScanner) (*models.BookingShare, error) {
+	var share models.BookingShare
+	var paidAt sql.NullTime
+	err := row.Scan(
+		&share.ShareID, &share.BookingID, &share.UserID, &share.FirstName, &share.LastName,
+		&share.IsOrganizer, &share.Amount, &share.Status, &paidAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scan share: %w", err)
+	}
+	if paidAt.Valid {
+		share.PaidAt = &paidAt.Time
+	}
+	return &share, nil
+}
+
+// findUserByPhone หาผู้ใช้ที่ลงทะเบียนด้วยเบอร์โทรนี้ (ต้องตรงกับบัญชีเดียว)
+func findUserByPhone(ctx context.Context, tx *sql.Tx, phone string) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM users WHERE phone = $1 LIMIT 2", phone)
+	if err != nil {
+		return 0, fmt.Errorf("find user by phone: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return 0, fmt.Errorf("scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("find user by phone: %w", err)
+	}
+
+	switch len(userIDs) {
+	case 0:
+		return 0, &GroupBookingError{Reason: fmt.Sprintf("no registered user with phone %s", phone)}
+	case 1:
+		return userIDs[0], nil
+	default:
+		return 0, &GroupBookingError{Reason: fmt.Sprintf("phone %s belongs to more than one account", phone)}
+	}
+}
+
+// isGroupBooking ตรวจว่าการจองถูกแบ่งจ่ายเป็นกลุ่มแล้วหรือไม่
+func isGroupBooking(ctx context.Context, q querier, bookingID int) (bool, error) {
+	var group bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM booking_shares WHERE booking_id = $1)", bookingID).Scan(&group)
+	if err != nil {
+		return false, fmt.Errorf("check group booking: %w", err)
+	}
+	return group, nil
+}
+
+// lockShareForPayment ล็อกส่วนของผู้จ่ายและคืนสถานะปัจจุบัน
+func lockShareForPayment(ctx context.Context, tx *sql.Tx, shareID int64) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, "SELECT status FROM booking_shares WHERE share_id = $1 FOR UPDATE", shareID).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("fetch share: %w", err)
+	}
+	return status, nil
+}
+
+// markSharePaid บันทึกว่าส่วนนี้จ่ายแล้ว และคืนค่า true ถ้าทุกส่วนของการจองจ่ายครบ
+func markSharePaid(ctx context.Context, tx *sql.Tx, bookingID int, shareID int64) (bool, error) {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE booking_shares SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE share_id = $1
+	`, shareID)
+	if err != nil {
+		return false, fmt.Errorf("mark share paid: %w", err)
+	}
+
+	var allPaid bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT NOT EXISTS(SELECT 1 FROM booking_shares WHERE booking_id = $1 AND status <> 'paid')
+	`, bookingID).Scan(&allPaid)
+	if err != nil {
+		return false, fmt.Errorf("check shares paid: %w", err)
+	}
+	return allPaid, nil
+}
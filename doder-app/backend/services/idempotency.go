@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	confirmPaymentScope = "confirm_payment"
+
+	idempotencyResultProcessing = "processing"
+	idempotencyResultConfirmed  = "confirmed"
+	idempotencyResultConflict   = "conflict"
+)
+
+// idempotencyRecord ผลลัพธ์ที่บันทึกไว้กับ Idempotency-Key (payment ของผลลัพธ์ confirmed อ่านจากตาราง payments)
+type idempotencyRecord struct {
+	BookingID      int
+	Result         string
+	ConflictSeatID sql.NullInt64
+	PaymentID      sql.NullInt64
+	PaymentMethod  sql.NullString
+	Amount         sql.NullFloat64
+}
+
+// claimIdempotencyKey จอง key ใน transaction ปัจจุบัน
+// ถ้า key นี้เคยทำสำเร็จแล้วจะคืน record เดิมกลับไป (ผู้เรียกต้องตอบผลลัพธ์เดิมแทนการทำซ้ำ)
+// แถวที่ insert จะเห็นได้เมื่อ commit เท่านั้น ถ้า transaction ล้ม key จะถูกปล่อยให้ลองใหม่ได้
+func claimIdempotencyKey(ctx context.Context, tx *sql.Tx, scope, key string, bookingID int) (*idempotencyRecord, error) {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (scope, idempotency_key, booking_id, result)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, idempotency_key) DO NOTHING
+	`, scope, key, bookingID, idempotencyResultProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 1 {
+		return nil, nil
+	}
+
+	var record idempotencyRecord
+	err = tx.QueryRowContext(ctx, `
+		SELECT k.booking_id, k.result, k.conflict_seat_id, k.payment_id, p.method, p.amount
+		FROM idempotency_keys k
+		LEFT JOIN payments p ON k.payment_id = p.payment_id
+		WHERE k.scope = $1 AND k.idempotency_key = $2
+	`, scope, key).Scan(&record.BookingID, &record.Result, &record.ConflictSeatID, &record.PaymentID, &record.PaymentMethod, &record.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("load idempotency key: %w", err)
+	}
+	if record.BookingID != bookingID {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return &record, nil
+}
+
+// completeIdempotencyKey บันทึกผลลัพธ์สุดท้ายของ key (commit พร้อมกับงานหลัก)
+// paymentID คือ payment ที่สร้างเมื่อผลลัพธ์เป็น confirmed (0 = ไม่มี) ใช้ตอบผลลัพธ์เดิมเมื่อ client retry
+func completeIdempotencyKey(ctx context.Context, tx *sql.Tx, scope, key, result string, conflictSeatID, paymentID int) error {
+	var seatID, payment sql.NullInt64
+	if conflictSeatID > 0 {
+		seatID = sql.NullInt64{Int64: int64(conflictSeatID), Valid: true}
+	}
+	if paymentID > 0 {
+		payment = sql.NullInt64{Int64: int64(paymentID), Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET result = $1, conflict_seat_id = $2, payment_id = $3
+		WHERE scope = $4 AND idempotency_key = $5
+	`, result, seatID, payment, scope, key)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// replayConfirm แปลง record เดิมกลับเป็นผลลัพธ์ของ Confirm
+func replayConfirm(record *idempotencyRecord) (*ConfirmResult, error) {
+	if record.Result == idempotencyResultConflict {
+		return nil, &ConfirmConflictError{SeatID: int(record.ConflictSeatID.Int64)}
+	}
+	return &ConfirmResult{
+		BookingID:     record.BookingID,
+		PaymentID:     int(record.PaymentID.Int64),
+		PaymentMethod: record.PaymentMethod.String,
+		Amount:        record.Amount.Float64,
+		Replayed:      true,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+)
+
+func TestReplayConfirm(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       idempotencyRecord
+		want         ConfirmResult
+		wantConflict int
+	}{
+		{
+			name: "confirmed",
+			record: idempotencyRecord{
+				BookingID:     12,
+				Result:        idempotencyResultConfirmed,
+				PaymentID:     sql.NullInt64{Int64: 31, Valid: true},
+				PaymentMethod: sql.NullString{String: "credit_card", Valid: true},
+				Amount:        sql.NullFloat64{Float64: 450, Valid: true},
+			},
+			want: ConfirmResult{BookingID: 12, PaymentID: 31, PaymentMethod: "credit_card", Amount: 450, Replayed: true},
+		},
+		{
+			name:         "conflict",
+			record:       idempotencyRecord{BookingID: 12, Result: idempotencyResultConflict, ConflictSeatID: sql.NullInt64{Int64: 40, Valid: true}},
+			wantConflict: 40,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := replayConfirm(&tt.record)
+			if tt.wantConflict > 0 {
+				var conflict *ConfirmConflictError
+				if !errors.As(err, &conflict) || conflict.SeatID != tt.wantConflict {
+					t.Fatalf("replayConfirm() error = %v, want conflict on seat %d", err, tt.wantConflict)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("replayConfirm() error = %v", err)
+			}
+			if *result != tt.want {
+				t.Errorf("replayConfirm() = %+v, want %+v", *result, tt.want)
+			}
+		})
+	}
+}
+
+// Confirm ซ้ำด้วย Idempotency-Key เดิมต้องได้ผลเดิมโดยไม่เก็บเงินซ้ำ และ key เดิมใช้กับการจองอื่นไม่ได้
+func TestConfirmIdempotencyKeyReplay(t *testing.T) {
+	db := openTestDB(t)
+	showtimeID, seatIDs := createShowtimeFixture(t, db, 2)
+	userIDs := createTestUsers(t, db, 2)
+	service := newTestBookingService(db)
+	ctx := context.Background()
+
+	first, err := service.Reserve(ctx, userIDs[0], showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[0]}}, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	second, err := service.Reserve(ctx, userIDs[1], showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[1]}}, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	confirmed, err := service.Confirm(ctx, first.BookingID, "cash", key)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if confirmed.Replayed {
+		t.Fatal("first confirm reported as replayed")
+	}
+
+	replayed, err := service.Confirm(ctx, first.BookingID, "cash", key)
+	if err != nil {
+		t.Fatalf("replay confirm: %v", err)
+	}
+	want := *confirmed
+	want.Replayed = true
+	if *replayed != want {
+		t.Errorf("replay = %+v, want %+v", *replayed, want)
+	}
+
+	var paymentCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM payments WHERE booking_id = $1", first.BookingID).Scan(&paymentCount)
+	if err != nil {
+		t.Fatalf("count payments: %v", err)
+	}
+	if paymentCount != 1 {
+		t.Errorf("payments = %d, want 1", paymentCount)
+	}
+
+	if _, err := service.Confirm(ctx, second.BookingID, "cash", key); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("confirm other booking with same key error = %v, want %v", err, ErrIdempotencyKeyReused)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"movie-booking-system/payments"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// paymentRefund payment ที่บันทึกสถานะ refunding แล้ว รอส่งคำขอคืนเงินให้ผู้ให้บริการ
+type paymentRefund struct {
+	PaymentID      int
+	ProviderRef    string
+	Amount         float64
+	IdempotencyKey string
+}
+
+// pendingRefunds เก็บการคืนเงินที่บันทึกใน transaction
+// ต้องเรียก send หลัง commit เท่านั้น เพื่อไม่ให้เงินถูกคืนจริงแต่สถานะใน database ถูก rollback
+type pendingRefunds struct {
+	refunds []paymentRefund
+}
+
+func (r *pendingRefunds) add(refunds ...paymentRefund) {
+	r.refunds = append(r.refunds, refunds...)
+}
+
+// send ส่งคำขอคืนเงินทั้งหมดให้ gateway แล้วเปลี่ยน payment เป็น refunded คืน refund reference ของรายการที่สำเร็จ
+// รายการที่ล้มเหลวยังเป็น refunding ให้ job retry_refunds ส่งใหม่ด้วย idempotency key เดิม
+func (r *pendingRefunds) send(ctx context.Context, db execer, gateway payments.Gateway) ([]string, error) {
+	refundRefs := []string{}
+	var errs []error
+	for _, p := range r.refunds {
+		refundRef, err := sendRefund(ctx, db, gateway, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("refund payment %d: %w", p.PaymentID, err))
+			continue
+		}
+		refundRefs = append(refundRefs, refundRef)
+	}
+	r.refunds = nil
+	return refundRefs, errors.Join(errs...)
+}
+
+// sendRefund คืนเงิน payment หนึ่งรายการผ่าน gateway แล้วบันทึกผล
+func sendRefund(ctx context.Context, db execer, gateway payments.Gateway, p paymentRefund) (string, error) {
+	refund, err := gateway.Refund(ctx, p.ProviderRef, p.Amount, p.IdempotencyKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		UPDATE payments SET status = $1, refund_ref = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE payment_id = $3 AND status = $4
+	`, payments.StatusRefunded, refund.RefundRef, p.PaymentID, payments.StatusRefunding)
+	if err != nil {
+		return "", fmt.Errorf("mark payment refunded: %w", err)
+	}
+	return refund.RefundRef, nil
+}
+
+// markCapturedPaymentsRefunding เปลี่ยน payment ที่ captured ทั้งหมดของการจองเป็น refunding ภายใน transaction
+// idempotency key ของแต่ละ payment คือ keyPrefix ต่อด้วย payment_id (เก็บไว้ใช้ส่งซ้ำ)
+// ใช้ทั้งตอนอนุมัติคืนเงิน และตอนยกเลิกการจองแบบกลุ่มที่มีบางคนจ่ายส่วนของตัวเองไปแล้ว
+func markCapturedPaymentsRefunding(ctx context.Context, tx *sql.Tx, bookingID int, keyPrefix string) ([]paymentRefund, error) {
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE payments
+		SET status = $3, refund_idempotency_key = $4 || '-payment-' || payment_id, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1 AND status = $2
+		RETURNING payment_id, provider_ref, amount, refund_idempotency_key
+	`, bookingID, payments.StatusCaptured, payments.StatusRefunding, keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("mark captured payments refunding: %w", err)
+	}
+
+	refunds := []paymentRefund{}
+	for rows.Next() {
+		var p paymentRefund
+		if err := rows.Scan(&p.PaymentID, &p.ProviderRef, &p.Amount, &p.IdempotencyKey); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan refunding payment: %w", err)
+		}
+		refunds = append(refunds, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("mark captured payments refunding: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE booking_shares SET status = 'refunded', updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1 AND status = 'paid'
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("mark shares refunded: %w", err)
+	}
+	return refunds, nil
+}
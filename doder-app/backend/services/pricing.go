@@ -0,0 +1,510 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"movie-booking-system/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+	ErrInvalidPricingRule  = errors.New("invalid pricing rule")
+	ErrHolidayNotFound     = errors.New("holiday not found")
+	ErrHolidayExists       = errors.New("holiday already exists")
+)
+
+// querier ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SeatPrice ราคาของที่นั่งหนึ่งที่ในรอบฉาย
+type SeatPrice struct {
+	SeatID     int
+	SeatType   string
+	TicketType string  // ว่างถ้ายังไม่ได้คิดค่าตั๋ว (quoteSeats ใส่ให้)
+	BasePrice  float64 // ราคาตามประเภทที่นั่งก่อนใช้กฎราคา
+	Price      float64 // ราคาหลังใช้กฎราคา (และค่าตั๋วถ้าผ่าน quoteSeats)
+}
+
+// pricingContext ข้อมูลของรอบฉายที่ใช้จับคู่กับเงื่อนไขของกฎราคา
+type pricingContext struct {
+	showDate    string // YYYY-MM-DD
+	showTime    string // HH:MM
+	dayOfWeek   int
+	isHoliday   bool
+	cinemaID    int
+	theaterType *string
+}
+
+// PricingService คำนวณราคาที่นั่งจากราคาตามประเภทที่นั่งและกฎราคา และจัดการกฎราคา/วันหยุด
+type PricingService struct {
+	db *sql.DB
+}
+
+func NewPricingService(db *sql.DB) *PricingService {
+	return &PricingService{db: db}
+}
+
+// SeatPrices ราคาสุดท้ายของที่นั่งในรอบฉาย ถ้า seatIDs เป็น nil จะคืนทุกที่นั่งที่เปิดใช้งาน
+func (s *PricingService) SeatPrices(ctx context.Context, showtimeID int, seatIDs []int) ([]SeatPrice, error) {
+	return seatPrices(ctx, s.db, showtimeID, seatIDs)
+}
+
+// Preview ราคาของทุกประเภทที่นั่งในรอบฉาย พร้อมกฎที่ถูกใช้และราคาแยกตามประเภทตั๋ว (รวมรอบฉายที่ปิดแล้ว)
+func (s *PricingService) Preview(ctx context.Context, showtimeID int) (*models.PricePreview, error) {
+	pc, err := loadPricingContext(ctx, s.db, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := loadPricingRules(ctx, s.db, pc)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := loadFareContext(ctx, s.db, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT se.seat_type,
+		       COALESCE(sps.price, spt.price, st.price),
+		       CASE WHEN sps.price IS NOT NULL THEN 'showtime'
+		            WHEN spt.price IS NOT NULL THEN 'theater'
+		            ELSE 'default' END
+		FROM showtimes st
+		JOIN seats se ON se.theater_id = st.theater_id AND se.is_active = TRUE
+		LEFT JOIN seat_prices sps ON sps.showtime_id = st.showtime_id AND sps.seat_type = se.seat_type
+		LEFT JOIN seat_prices spt ON spt.theater_id = st.theater_id AND spt.seat_type = se.seat_type
+		WHERE st.showtime_id = $1
+		ORDER BY se.seat_type
+	`, showtimeID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch seat type prices: %w", err)
+	}
+	defer rows.Close()
+
+	preview := &models.PricePreview{
+		ShowtimeID:  showtimeID,
+		ShowDate:    pc.showDate,
+		ShowTime:    pc.showTime,
+		DayOfWeek:   pc.dayOfWeek,
+		IsHoliday:   pc.isHoliday,
+		TheaterType: pc.theaterType,
+		AgeRating:   fc.ageRating,
+		Prices:      []models.SeatTypePricePreview{},
+	}
+	for rows.Next() {
+		var p models.SeatTypePricePreview
+		if err := rows.Scan(&p.SeatType, &p.BasePrice, &p.BaseSource); err != nil {
+			return nil, fmt.Errorf("scan seat type price: %w", err)
+		}
+		p.FinalPrice, p.AppliedRules = applyPricingRules(p.BasePrice, rules, pc, p.SeatType)
+		p.TicketPrices = fc.ticketPrices(p.SeatType, p.FinalPrice)
+		preview.Prices = append(preview.Prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch seat type prices: %w", err)
+	}
+	return preview, nil
+}
+
+// seatPrices ดึงราคาตามประเภทที่นั่งจาก view showtime_seat_prices (ราคารอบฉาย > ราคาโรง > showtimes.price)
+// แล้วใช้กฎราคา คืนเฉพาะที่นั่งที่เปิดใช้งานและอยู่ในโรงของรอบฉาย เรียงตาม seatIDs ที่ส่งมา
+func seatPrices(ctx context.Context, q querier, showtimeID int, seatIDs []int) ([]SeatPrice, error) {
+	pc, err := loadPricingContext(ctx, q, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := loadPricingRules(ctx, q, pc)
+	if err != nil {
+		return nil, err
+	}
+
+	var seatFilter any
+	if seatIDs != nil {
+		seatFilter = pq.Array(seatIDs)
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT seat_id, seat_type, price
+		FROM showtime_seat_prices
+		WHERE showtime_id = $1 AND is_active = TRUE AND ($2::int[] IS NULL OR seat_id = ANY($2))
+		ORDER BY seat_id
+	`, showtimeID, seatFilter)
+	if err != nil {
+		return nil, fmt.Errorf("fetch seat prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []SeatPrice{}
+	for rows.Next() {
+		var p SeatPrice
+		if err := rows.Scan(&p.SeatID, &p.SeatType, &p.BasePrice); err != nil {
+			return nil, fmt.Errorf("scan seat price: %w", err)
+		}
+		p.Price, _ = applyPricingRules(p.BasePrice, rules, pc, p.SeatType)
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch seat prices: %w", err)
+	}
+	if seatIDs == nil {
+		return prices, nil
+	}
+
+	byID := make(map[int]SeatPrice, len(prices))
+	for _, p := range prices {
+		byID[p.SeatID] = p
+	}
+	ordered := make([]SeatPrice, 0, len(prices))
+	for _, seatID := range seatIDs {
+		if p, ok := byID[seatID]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered, nil
+}
+
+func loadPricingContext(ctx context.Context, q querier, showtimeID int) (*pricingContext, error) {
+	var pc pricingContext
+	err := q.QueryRowContext(ctx, `
+		SELECT TO_CHAR(st.show_date, 'YYYY-MM-DD'), TO_CHAR(st.show_time, 'HH24:MI'),
+		       EXTRACT(DOW FROM st.show_date)::int,
+		       EXISTS(SELECT 1 FROM holidays h WHERE h.holiday_date = st.show_date),
+		       t.cinema_id, t.theater_type
+		FROM showtimes st
+		JOIN theaters t ON st.theater_id = t.theater_id
+		WHERE st.showtime_id = $1
+	`, showtimeID).Scan(&pc.showDate, &pc.showTime, &pc.dayOfWeek, &pc.isHoliday, &pc.cinemaID, &pc.theaterType)
+	if err == sql.ErrNoRows {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime for pricing: %w", err)
+	}
+	return &pc, nil
+}
+
+// loadPricingRules กฎที่เปิดใช้งานและใช้กับโรงภาพยนตร์ของรอบฉายได้ เรียงตามลำดับที่ต้องใช้
+func loadPricingRules(ctx context.Context, q querier, pc *pricingContext) ([]models.PricingRule, error) {
+	rows, err := q.QueryContext(ctx, pricingRuleSelectQuery+`
+		WHERE is_active = TRUE AND (cinema_id IS NULL OR cinema_id = $1)
+		ORDER BY priority, rule_id
+	`, pc.cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.PricingRule{}
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch pricing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// applyPricingRules ใช้กฎที่ตรงเงื่อนไขตามลำดับ หยุดเมื่อเจอกฎที่ตั้ง stop_processing
+func applyPricingRules(base float64, rules []models.PricingRule, pc *pricingContext, seatType string) (float64, []models.AppliedPricingRule) {
+	price := base
+	applied := []models.AppliedPricingRule{}
+	for _, rule := range rules {
+		if !pricingRuleMatches(rule, pc, seatType) {
+			continue
+		}
+
+		before := price
+		price = adjustPrice(price, rule.AdjustmentType, rule.AdjustmentValue)
+
+		applied = append(applied, models.AppliedPricingRule{
+			RuleID:          rule.RuleID,
+			Name:            rule.Name,
+			AdjustmentType:  rule.AdjustmentType,
+			AdjustmentValue: rule.AdjustmentValue,
+			PriceBefore:     before,
+			PriceAfter:      price,
+		})
+		if rule.StopProcessing {
+			break
+		}
+	}
+	return price, applied
+}
+
+func pricingRuleMatches(rule models.PricingRule, pc *pricingContext, seatType string) bool {
+	if rule.CinemaID != nil && *rule.CinemaID != pc.cinemaID {
+		return false
+	}
+	// วันที่และเวลาอยู่ในรูปแบบ YYYY-MM-DD / HH:MM จึงเทียบเป็น string ได้
+	if rule.ValidFrom != nil && pc.showDate < *rule.ValidFrom {
+		return false
+	}
+	if rule.ValidUntil != nil && pc.showDate > *rule.ValidUntil {
+		return false
+	}
+	if rule.StartTime != nil && pc.showTime < *rule.StartTime {
+		return false
+	}
+	if rule.EndTime != nil && pc.showTime >= *rule.EndTime {
+		return false
+	}
+
+	switch rule.Holidays {
+	case "only":
+		if !pc.isHoliday {
+			return false
+		}
+	case "exclude":
+		if pc.isHoliday {
+			return false
+		}
+	}
+	// 'include' ให้วันหยุดผ่านเงื่อนไขวันในสัปดาห์เสมอ (เช่น เสาร์-อาทิตย์และวันหยุด)
+	if len(rule.DaysOfWeek) > 0 && !(rule.Holidays == "include" && pc.isHoliday) {
+		matched := false
+		for _, day := range rule.DaysOfWeek {
+			if int(day) == pc.dayOfWeek {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(rule.TheaterTypes) > 0 {
+		if pc.theaterType == nil || !containsFold(rule.TheaterTypes, *pc.theaterType) {
+			return false
+		}
+	}
+	if len(rule.SeatTypes) > 0 && !containsFold(rule.SeatTypes, seatType) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
+
+const pricingRuleSelectQuery = `
+	SELECT rule_id, name, description, priority, adjustment_type, adjustment_value, stop_processing,
+	       days_of_week, holidays, TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'),
+	       TO_CHAR(valid_from, 'YYYY-MM-DD'), TO_CHAR(valid_until, 'YYYY-MM-DD'),
+	       cinema_id, theater_types, seat_types, is_active, created_at, updated_at
+	FROM pricing_rules
+`
+
+func scanPricingRule(row rowScanner) (*models.PricingRule, error) {
+	var rule models.PricingRule
+	err := row.Scan(
+		&rule.RuleID, &rule.Name, &rule.Description, &rule.Priority, &rule.AdjustmentType, &rule.AdjustmentValue, &rule.StopProcessing,
+		pq.Array(&rule.DaysOfWeek), &rule.Holidays, &rule.StartTime, &rule.EndTime,
+		&rule.ValidFrom, &rule.ValidUntil,
+		&rule.CinemaID, pq.Array(&rule.TheaterTypes), pq.Array(&rule.SeatTypes), &rule.IsActive, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules กฎราคาทั้งหมดเรียงตามลำดับที่ใช้
+func (s *PricingService) ListRules(ctx context.Context) ([]models.PricingRule, error) {
+	rows, err := s.db.QueryContext(ctx, pricingRuleSelectQuery+" ORDER BY priority, rule_id")
+	if err != nil {
+		return nil, fmt.Errorf("fetch pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.PricingRule{}
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan pricing rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *PricingService) GetRule(ctx context.Context, ruleID int) (*models.PricingRule, error) {
+	rule, err := scanPricingRule(s.db.QueryRowContext(ctx, pricingRuleSelectQuery+" WHERE rule_id = $1", ruleID))
+	if err == sql.ErrNoRows {
+		return nil, ErrPricingRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch pricing rule: %w", err)
+	}
+	return rule, nil
+}
+
+// CreateRule สร้างกฎราคาใหม่
+func (s *PricingService) CreateRule(ctx context.Context, req models.PricingRuleRequest) (*models.PricingRule, error) {
+	if err := validatePricingRule(req); err != nil {
+		return nil, err
+	}
+
+	var ruleID int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO pricing_rules (name, description, priority, adjustment_type, adjustment_value, stop_processing,
+		                           days_of_week, holidays, start_time, end_time, valid_from, valid_until,
+		                           cinema_id, theater_types, seat_types, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING rule_id
+	`, pricingRuleArgs(req)...).Scan(&ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("create pricing rule: %w", err)
+	}
+	return s.GetRule(ctx, ruleID)
+}
+
+// UpdateRule แทนที่กฎราคาทั้งกฎด้วยค่าที่ส่งมา
+func (s *PricingService) UpdateRule(ctx context.Context, ruleID int, req models.PricingRuleRequest) (*models.PricingRule, error) {
+	if err := validatePricingRule(req); err != nil {
+		return nil, err
+	}
+
+	args := append(pricingRuleArgs(req), ruleID)
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE pricing_rules
+		SET name = $1, description = $2, priority = $3, adjustment_type = $4, adjustment_value = $5,
+		    stop_processing = $6, days_of_week = $7, holidays = $8, start_time = $9, end_time = $10,
+		    valid_from = $11, valid_until = $12, cinema_id = $13, theater_types = $14, seat_types = $15,
+		    is_active = $16, updated_at = CURRENT_TIMESTAMP
+		WHERE rule_id = $17
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("update pricing rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrPricingRuleNotFound
+	}
+	return s.GetRule(ctx, ruleID)
+}
+
+func (s *PricingService) DeleteRule(ctx context.Context, ruleID int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM pricing_rules WHERE rule_id = $1", ruleID)
+	if err != nil {
+		return fmt.Errorf("delete pricing rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPricingRuleNotFound
+	}
+	return nil
+}
+
+func validatePricingRule(req models.PricingRuleRequest) error {
+	if req.AdjustmentType == "override" && req.AdjustmentValue < 0 {
+		return fmt.Errorf("%w: override price must not be negative", ErrInvalidPricingRule)
+	}
+	if req.AdjustmentType == "percentage" && req.AdjustmentValue < -100 {
+		return fmt.Errorf("%w: percentage discount cannot exceed 100", ErrInvalidPricingRule)
+	}
+	if req.StartTime != nil && req.EndTime != nil && *req.StartTime >= *req.EndTime {
+		return fmt.Errorf("%w: start_time must be before end_time", ErrInvalidPricingRule)
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && *req.ValidFrom > *req.ValidUntil {
+		return fmt.Errorf("%w: valid_from must not be after valid_until", ErrInvalidPricingRule)
+	}
+	return nil
+}
+
+func pricingRuleArgs(req models.PricingRuleRequest) []any {
+	priority := 100
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	holidays := req.Holidays
+	if holidays == "" {
+		holidays = "any"
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return []any{
+		req.Name, req.Description, priority, req.AdjustmentType, req.AdjustmentValue, req.StopProcessing,
+		nullableArray(len(req.DaysOfWeek), pq.Array(req.DaysOfWeek)), holidays, req.StartTime, req.EndTime,
+		req.ValidFrom, req.ValidUntil, req.CinemaID,
+		nullableArray(len(req.TheaterTypes), pq.Array(req.TheaterTypes)),
+		nullableArray(len(req.SeatTypes), pq.Array(req.SeatTypes)), isActive,
+	}
+}
+
+// nullableArray เก็บ array ว่างเป็น NULL (ไม่จำกัดเงื่อนไข)
+func nullableArray(length int, value any) any {
+	if length == 0 {
+		return nil
+	}
+	return value
+}
+
+// ListHolidays วันหยุดทั้งหมดเรียงตามวันที่
+func (s *PricingService) ListHolidays(ctx context.Context) ([]models.Holiday, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT TO_CHAR(holiday_date, 'YYYY-MM-DD'), name, created_at
+		FROM holidays
+		ORDER BY holiday_date
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("fetch holidays: %w", err)
+	}
+	defer rows.Close()
+
+	holidays := []models.Holiday{}
+	for rows.Next() {
+		var h models.Holiday
+		if err := rows.Scan(&h.HolidayDate, &h.Name, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, rows.Err()
+}
+
+func (s *PricingService) AddHoliday(ctx context.Context, req models.CreateHolidayRequest) (*models.Holiday, error) {
+	h := models.Holiday{HolidayDate: req.HolidayDate, Name: req.Name}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO holidays (holiday_date, name)
+		VALUES ($1, $2)
+		RETURNING created_at
+	`, req.HolidayDate, req.Name).Scan(&h.CreatedAt)
+	if isUniqueViolation(err) {
+		return nil, ErrHolidayExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create holiday: %w", err)
+	}
+	return &h, nil
+}
+
+func (s *PricingService) DeleteHoliday(ctx context.Context, date string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM holidays WHERE holiday_date = $1", date)
+	if err != nil {
+		return fmt.Errorf("delete holiday: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrHolidayNotFound
+	}
+	return nil
+}
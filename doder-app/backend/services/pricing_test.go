@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"movie-booking-system/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(n int) *int { return &n }
+
+// รอบฉายวันเสาร์ 14 มี.ค. 2026 เวลา 19:30 ที่สาขา 3 โรง IMAX
+func saturdayEvening() *pricingContext {
+	return &pricingContext{
+		showDate:    "2026-03-14",
+		showTime:    "19:30",
+		dayOfWeek:   6,
+		cinemaID:    3,
+		theaterType: strPtr("IMAX"),
+	}
+}
+
+func TestPricingRuleMatches(t *testing.T) {
+	holiday := saturdayEvening()
+	holiday.isHoliday = true
+	weekdayHoliday := &pricingContext{showDate: "2026-04-13", showTime: "14:00", dayOfWeek: 1, isHoliday: true, cinemaID: 3}
+	noTheaterType := saturdayEvening()
+	noTheaterType.theaterType = nil
+
+	tests := []struct {
+		name     string
+		rule     models.PricingRule
+		pc       *pricingContext
+		seatType string
+		want     bool
+	}{
+		{"no conditions", models.PricingRule{Holidays: "any"}, saturdayEvening(), "standard", true},
+		{"other cinema", models.PricingRule{Holidays: "any", CinemaID: intPtr(4)}, saturdayEvening(), "standard", false},
+		{"same cinema", models.PricingRule{Holidays: "any", CinemaID: intPtr(3)}, saturdayEvening(), "standard", true},
+		{"before valid_from", models.PricingRule{Holidays: "any", ValidFrom: strPtr("2026-03-15")}, saturdayEvening(), "standard", false},
+		{"on valid_until", models.PricingRule{Holidays: "any", ValidUntil: strPtr("2026-03-14")}, saturdayEvening(), "standard", true},
+		{"after valid_until", models.PricingRule{Holidays: "any", ValidUntil: strPtr("2026-03-13")}, saturdayEvening(), "standard", false},
+		{"on start_time", models.PricingRule{Holidays: "any", StartTime: strPtr("19:30")}, saturdayEvening(), "standard", true},
+		{"end_time is exclusive", models.PricingRule{Holidays: "any", EndTime: strPtr("19:30")}, saturdayEvening(), "standard", false},
+		{"weekend rule on saturday", models.PricingRule{Holidays: "any", DaysOfWeek: []int64{0, 6}}, saturdayEvening(), "standard", true},
+		{"weekday rule on saturday", models.PricingRule{Holidays: "any", DaysOfWeek: []int64{1, 2, 3, 4, 5}}, saturdayEvening(), "standard", false},
+		{"holidays only on normal day", models.PricingRule{Holidays: "only"}, saturdayEvening(), "standard", false},
+		{"holidays only on holiday", models.PricingRule{Holidays: "only"}, holiday, "standard", true},
+		{"exclude holidays", models.PricingRule{Holidays: "exclude"}, holiday, "standard", false},
+		{"weekend and holidays on weekday holiday", models.PricingRule{Holidays: "include", DaysOfWeek: []int64{0, 6}}, weekdayHoliday, "standard", true},
+		{"weekend without holidays on weekday holiday", models.PricingRule{Holidays: "any", DaysOfWeek: []int64{0, 6}}, weekdayHoliday, "standard", false},
+		{"theater type ignores case", models.PricingRule{Holidays: "any", TheaterTypes: []string{"imax"}}, saturdayEvening(), "standard", true},
+		{"theater type differs", models.PricingRule{Holidays: "any", TheaterTypes: []string{"4DX"}}, saturdayEvening(), "standard", false},
+		{"theater type unknown", models.PricingRule{Holidays: "any", TheaterTypes: []string{"IMAX"}}, noTheaterType, "standard", false},
+		{"seat type matches", models.PricingRule{Holidays: "any", SeatTypes: []string{"VIP", "couple"}}, saturdayEvening(), "vip", true},
+		{"seat type differs", models.PricingRule{Holidays: "any", SeatTypes: []string{"VIP"}}, saturdayEvening(), "standard", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pricingRuleMatches(tt.rule, tt.pc, tt.seatType); got != tt.want {
+				t.Errorf("pricingRuleMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPricingRules(t *testing.T) {
+	weekend := models.PricingRule{RuleID: 1, Name: "weekend", Holidays: "any", DaysOfWeek: []int64{0, 6}, AdjustmentType: "percentage", AdjustmentValue: 10}
+	vipFee := models.PricingRule{RuleID: 2, Name: "vip fee", Holidays: "any", SeatTypes: []string{"vip"}, AdjustmentType: "fixed", AdjustmentValue: 50}
+	matinee := models.PricingRule{RuleID: 3, Name: "matinee", Holidays: "any", EndTime: strPtr("12:00"), AdjustmentType: "percentage", AdjustmentValue: -20}
+	flat := models.PricingRule{RuleID: 4, Name: "flat", Holidays: "any", AdjustmentType: "override", AdjustmentValue: 99, StopProcessing: true}
+	bigDiscount := models.PricingRule{RuleID: 5, Name: "big discount", Holidays: "any", AdjustmentType: "fixed", AdjustmentValue: -500}
+	third := models.PricingRule{RuleID: 6, Name: "third off", Holidays: "any", AdjustmentType: "percentage", AdjustmentValue: -33.333}
+
+	tests := []struct {
+		name        string
+		base        float64
+		rules       []models.PricingRule
+		seatType    string
+		want        float64
+		wantApplied []int
+	}{
+		{"no rules", 200, nil, "standard", 200, []int{}},
+		{"rules apply in order", 200, []models.PricingRule{weekend, vipFee}, "vip", 270, []int{1, 2}},
+		{"order matters", 200, []models.PricingRule{vipFee, weekend}, "vip", 275, []int{2, 1}},
+		{"unmatched rules skipped", 200, []models.PricingRule{matinee, vipFee, weekend}, "standard", 220, []int{1}},
+		{"stop processing", 200, []models.PricingRule{flat, weekend}, "standard", 99, []int{4}},
+		{"stop processing after earlier rules", 200, []models.PricingRule{weekend, flat, vipFee}, "vip", 99, []int{1, 4}},
+		{"price never negative", 200, []models.PricingRule{bigDiscount}, "standard", 0, []int{5}},
+		{"rounded to satang", 100, []models.PricingRule{third}, "standard", 66.67, []int{6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied := applyPricingRules(tt.base, tt.rules, saturdayEvening(), tt.seatType)
+			if got != tt.want {
+				t.Errorf("price = %v, want %v", got, tt.want)
+			}
+			if len(applied) != len(tt.wantApplied) {
+				t.Fatalf("applied %d rules, want %v", len(applied), tt.wantApplied)
+			}
+			price := tt.base
+			for i, rule := range applied {
+				if rule.RuleID != tt.wantApplied[i] {
+					t.Errorf("applied[%d] = rule %d, want %d", i, rule.RuleID, tt.wantApplied[i])
+				}
+				if rule.PriceBefore != price {
+					t.Errorf("applied[%d] price before = %v, want %v", i, rule.PriceBefore, price)
+				}
+				price = rule.PriceAfter
+			}
+		})
+	}
+}
+
+func TestValidatePricingRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.PricingRuleRequest
+		wantErr bool
+	}{
+		{"valid discount", models.PricingRuleRequest{Name: "x", AdjustmentType: "percentage", AdjustmentValue: -20}, false},
+		{"free override", models.PricingRuleRequest{Name: "x", AdjustmentType: "override", AdjustmentValue: 0}, false},
+		{"negative override", models.PricingRuleRequest{Name: "x", AdjustmentType: "override", AdjustmentValue: -1}, true},
+		{"more than 100 percent off", models.PricingRuleRequest{Name: "x", AdjustmentType: "percentage", AdjustmentValue: -101}, true},
+		{"start after end", models.PricingRuleRequest{Name: "x", AdjustmentType: "fixed", StartTime: strPtr("18:00"), EndTime: strPtr("12:00")}, true},
+		{"empty time window", models.PricingRuleRequest{Name: "x", AdjustmentType: "fixed", StartTime: strPtr("12:00"), EndTime: strPtr("12:00")}, true},
+		{"valid_from after valid_until", models.PricingRuleRequest{Name: "x", AdjustmentType: "fixed", ValidFrom: strPtr("2026-03-15"), ValidUntil: strPtr("2026-03-14")}, true},
+		{"single day", models.PricingRuleRequest{Name: "x", AdjustmentType: "fixed", ValidFrom: strPtr("2026-03-14"), ValidUntil: strPtr("2026-03-14")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePricingRule(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validatePricingRule() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPricingRule) {
+				t.Errorf("error %v does not wrap ErrInvalidPricingRule", err)
+			}
+		})
+	}
+}
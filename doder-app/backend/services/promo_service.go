@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"movie-booking-system/models"
+)
+
+var (
+	ErrPromoCodeNotFound = errors.New("promo code not found")
+	ErrPromoCodeExists   = errors.New("promo code already exists")
+)
+
+// PromoCodeError ใช้โค้ดส่วนลดกับการจองนี้ไม่ได้ (Reason บอกสาเหตุให้ลูกค้า)
+type PromoCodeError struct {
+	Reason string
+}
+
+func (e *PromoCodeError) Error() string {
+	return "promo code cannot be applied: " + e.Reason
+}
+
+// PromoQuote ส่วนลดที่จะได้จากโค้ดสำหรับที่นั่งที่เลือก
+type PromoQuote struct {
+	Code     string  `json:"code"`
+	Subtotal float64 `json:"subtotal"`
+	Discount float64 `json:"discount"`
+	Total    float64 `json:"total"`
+}
+
+// appliedPromo โค้ดส่วนลดที่ผ่านการตรวจแล้วและส่วนลดที่คำนวณได้
+type appliedPromo struct {
+	promoID  int
+	code     string
+	discount float64
+}
+
+// PromoService จัดการโค้ดส่วนลด (admin) และตรวจโค้ดก่อนจอง
+type PromoService struct {
+	db *sql.DB
+}
+
+func NewPromoService(db *sql.DB) *PromoService {
+	return &PromoService{db: db}
+}
+
+// usedCount นับเฉพาะการจองที่ไม่ถูกยกเลิก การจองที่หมดเวลาหรือคืนเงินจึงคืนสิทธิ์ให้โค้ดอัตโนมัติ
+const promoSelectQuery = `
+	SELECT p.promo_id, p.code, p.description, p.discount_type, p.discount_value, p.max_discount,
+	       p.max_uses, p.max_uses_per_user, p.valid_from, p.valid_until, p.movie_id, p.cinema_id,
+	       p.min_seats, p.is_active,
+	       (SELECT COUNT(*) FROM promo_redemptions pr
+	        JOIN bookings b ON pr.booking_id = b.booking_id
+	        WHERE pr.promo_id = p.promo_id AND b.booking_status <> 'cancelled'),
+	       p.created_at, p.updated_at
+	FROM promo_codes p
+`
+
+func scanPromoCode(row rowScanner) (*models.PromoCode, error) {
+	var p models.PromoCode
+	err := row.Scan(
+		&p.PromoID, &p.Code, &p.Description, &p.DiscountType, &p.DiscountValue, &p.MaxDiscount,
+		&p.MaxUses, &p.MaxUsesPerUser, &p.ValidFrom, &p.ValidUntil, &p.MovieID, &p.CinemaID,
+		&p.MinSeats, &p.IsActive, &p.UsedCount, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// applyPromoCode ตรวจเงื่อนไขของโค้ดและคำนวณส่วนลดจากยอดรวม
+// ต้องเรียกใน transaction เดียวกับที่บันทึก promo_redemptions: แถวของโค้ดถูกล็อก (FOR UPDATE)
+// เพื่อให้การนับสิทธิ์ของการจองที่เกิดพร้อมกันไม่เกิน max_uses
+func applyPromoCode(ctx context.Context, q querier, code string, userID, showtimeID, seatCount int, subtotal float64) (*appliedPromo, error) {
+	var promo struct {
+		promoID        int
+		code           string
+		discountType   string
+		discountValue  float64
+		maxDiscount    sql.NullFloat64
+		maxUses        sql.NullInt64
+		maxUsesPerUser sql.NullInt64
+		started        bool
+		expired        bool
+		movieID        sql.NullInt64
+		cinemaID       sql.NullInt64
+		minSeats       sql.NullInt64
+	}
+	err := q.QueryRowContext(ctx, `
+		SELECT promo_id, code, discount_type, discount_value, max_discount, max_uses, max_uses_per_user,
+		       valid_from IS NULL OR valid_from <= LOCALTIMESTAMP,
+		       valid_until IS NOT NULL AND valid_until < LOCALTIMESTAMP,
+		       movie_id, cinema_id, min_seats
+		FROM promo_codes
+		WHERE code = $1 AND is_active = TRUE
+		FOR UPDATE
+	`, normalizePromoCode(code)).Scan(
+		&promo.promoID, &promo.code, &promo.discountType, &promo.discountValue, &promo.maxDiscount,
+		&promo.maxUses, &promo.maxUsesPerUser, &promo.started, &promo.expired,
+		&promo.movieID, &promo.cinemaID, &promo.minSeats,
+	)
+	if err == sql.ErrNoRows {
+		return nil, &PromoCodeError{Reason: "code does not exist"}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch promo code: %w", err)
+	}
+
+	if !promo.started {
+		return nil, &PromoCodeError{Reason: "code is not valid yet"}
+	}
+	if promo.expired {
+		return nil, &PromoCodeError{Reason: "code has expired"}
+	}
+	if promo.minSeats.Valid && int64(seatCount) < promo.minSeats.Int64 {
+		return nil, &PromoCodeError{Reason: fmt.Sprintf("at least %d seats are required", promo.minSeats.Int64)}
+	}
+
+	if promo.movieID.Valid || promo.cinemaID.Valid {
+		var movieID, cinemaID int64
+		err := q.QueryRowContext(ctx, `
+			SELECT s.movie_id, t.cinema_id
+			FROM showtimes s
+			JOIN theaters t ON s.theater_id = t.theater_id
+			WHERE s.showtime_id = $1
+		`, showtimeID).Scan(&movieID, &cinemaID)
+		if err == sql.ErrNoRows {
+			return nil, ErrShowtimeNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetch showtime: %w", err)
+		}
+		if promo.movieID.Valid && promo.movieID.Int64 != movieID {
+			return nil, &PromoCodeError{Reason: "code is not valid for this movie"}
+		}
+		if promo.cinemaID.Valid && promo.cinemaID.Int64 != cinemaID {
+			return nil, &PromoCodeError{Reason: "code is not valid at this cinema"}
+		}
+	}
+
+	if promo.maxUses.Valid || promo.maxUsesPerUser.Valid {
+		var totalUses, userUses int64
+		err := q.QueryRowContext(ctx, `
+			SELECT COUNT(*), COUNT(*) FILTER (WHERE pr.user_id = $2)
+			FROM promo_redemptions pr
+			JOIN bookings b ON pr.booking_id = b.booking_id
+			WHERE pr.promo_id = $1 AND b.booking_status <> 'cancelled'
+		`, promo.promoID, userID).Scan(&totalUses, &userUses)
+		if err != nil {
+			return nil, fmt.Errorf("count promo redemptions: %w", err)
+		}
+		if promo.maxUses.Valid && totalUses >= promo.maxUses.Int64 {
+			return nil, &PromoCodeError{Reason: "code has reached its usage limit"}
+		}
+		if promo.maxUsesPerUser.Valid && userUses >= promo.maxUsesPerUser.Int64 {
+			return nil, &PromoCodeError{Reason: "you have already used this code the maximum number of times"}
+		}
+	}
+
+	discount := promoDiscount(promo.discountType, promo.discountValue, promo.maxDiscount, subtotal)
+	return &appliedPromo{promoID: promo.promoID, code: promo.code, discount: discount}, nil
+}
+
+// promoDiscount ส่วนลดจากยอดรวม (ปัดเป็นสตางค์) ไม่เกินเพดานของแบบ percentage และไม่เกินยอดรวม
+func promoDiscount(discountType string, value float64, maxDiscount sql.NullFloat64, subtotal float64) float64 {
+	var discount float64
+	switch discountType {
+	case "percentage":
+		discount = subtotal * value / 100
+		if maxDiscount.Valid {
+			discount = math.Min(discount, maxDiscount.Float64)
+		}
+	case "fixed":
+		discount = value
+	}
+	return math.Min(math.Round(discount*100)/100, subtotal)
+}
+
+// recordPromoRedemption บันทึกการใช้โค้ดของการจอง (อยู่ใน transaction เดียวกับ applyPromoCode)
+func recordPromoRedemption(ctx context.Context, tx *sql.Tx, promo *appliedPromo, bookingID, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO promo_redemptions (promo_id, booking_id, user_id, discount_amount)
+		VALUES ($1, $2, $3, $4)
+	`, promo.promoID, bookingID, userID, promo.discount)
+	if err != nil {
+		return fmt.Errorf("record promo redemption: %w", err)
+	}
+	return nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Quote ตรวจโค้ดกับที่นั่งที่เลือกและคำนวณส่วนลด โดยยังไม่บันทึกการใช้โค้ด
+func (s *PromoService) Quote(ctx context.Context, userID int, req models.ValidatePromoCodeRequest) (*PromoQuote, error) {
+	prices, err := quoteSeats(ctx, s.db, req.ShowtimeID, req.SeatRequests())
+	if err != nil {
+		return nil, err
+	}
+
+	subtotal := 0.0
+	for _, p := range prices {
+		subtotal += p.Price
+	}
+
+	promo, err := applyPromoCode(ctx, s.db, req.Code, userID, req.ShowtimeID, len(prices), subtotal)
+	if err != nil {
+		return nil, err
+	}
+	return &PromoQuote{
+		Code:     promo.code,
+		Subtotal: subtotal,
+		Discount: promo.discount,
+		Total:    subtotal - promo.discount,
+	}, nil
+}
+
+// ListPromoCodes โค้ดส่วนลดทั้งหมด (รวมที่ปิดใช้งานแล้ว)
+func (s *PromoService) ListPromoCodes(ctx context.Context) ([]models.PromoCode, error) {
+	rows, err := s.db.QueryContext(ctx, promoSelectQuery+" ORDER BY p.created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("fetch promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan promo code: %w", err)
+		}
+		promos = append(promos, *promo)
+	}
+	return promos, rows.Err()
+}
+
+func (s *PromoService) GetPromoCode(ctx context.Context, promoID int) (*models.PromoCode, error) {
+	promo, err := scanPromoCode(s.db.QueryRowContext(ctx, promoSelectQuery+" WHERE p.promo_id = $1", promoID))
+	if err == sql.ErrNoRows {
+		return nil, ErrPromoCodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch promo code: %w", err)
+	}
+	return promo, nil
+}
+
+// CreatePromoCode สร้างโค้ดส่วนลด (เก็บโค้ดเป็นตัวพิมพ์ใหญ่)
+func (s *PromoService) CreatePromoCode(ctx context.Context, req models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	var promoID int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO promo_codes (code, description, discount_type, discount_value, max_discount, max_uses,
+		                         max_uses_per_user, valid_from, valid_until, movie_id, cinema_id, min_seats)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING promo_id
+	`, normalizePromoCode(req.Code), req.Description, req.DiscountType, req.DiscountValue, req.MaxDiscount, req.MaxUses,
+		req.MaxUsesPerUser, req.ValidFrom, req.ValidUntil, req.MovieID, req.CinemaID, req.MinSeats,
+	).Scan(&promoID)
+	if isUniqueViolation(err) {
+		return nil, ErrPromoCodeExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create promo code: %w", err)
+	}
+	return s.GetPromoCode(ctx, promoID)
+}
+
+// UpdatePromoCode แก้ไขเฉพาะ field ที่ส่งมา (โค้ด ประเภทส่วนลด และข้อจำกัดหนัง/โรงแก้ไม่ได้)
+func (s *PromoService) UpdatePromoCode(ctx context.Context, promoID int, req models.UpdatePromoCodeRequest) (*models.PromoCode, error) {
+	query := "UPDATE promo_codes SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argIndex := 1
+
+	set := func(column string, value interface{}) {
+		query += ", " + column + " = $" + strconv.Itoa(argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+	if req.Description != nil {
+		set("description", *req.Description)
+	}
+	if req.DiscountValue != nil {
+		set("discount_value", *req.DiscountValue)
+	}
+	if req.MaxDiscount != nil {
+		set("max_discount", *req.MaxDiscount)
+	}
+	if req.MaxUses != nil {
+		set("max_uses", *req.MaxUses)
+	}
+	if req.MaxUsesPerUser != nil {
+		set("max_uses_per_user", *req.MaxUsesPerUser)
+	}
+	if req.ValidFrom != nil {
+		set("valid_from", *req.ValidFrom)
+	}
+	if req.ValidUntil != nil {
+		set("valid_until", *req.ValidUntil)
+	}
+	if req.MinSeats != nil {
+		set("min_seats", *req.MinSeats)
+	}
+	if req.IsActive != nil {
+		set("is_active", *req.IsActive)
+	}
+
+	query += " WHERE promo_id = $" + strconv.Itoa(argIndex)
+	args = append(args, promoID)
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("update promo code: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrPromoCodeNotFound
+	}
+	return s.GetPromoCode(ctx, promoID)
+}
+
+// DeactivatePromoCode ปิดใช้งานโค้ด (ไม่ลบ เพื่อเก็บประวัติการใช้โค้ดไว้)
+func (s *PromoService) DeactivatePromoCode(ctx context.Context, promoID int) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE promo_codes SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE promo_id = $1", promoID)
+	if err != nil {
+		return fmt.Errorf("deactivate promo code: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPromoCodeNotFound
+	}
+	return nil
+}
+
+// RedemptionReport รายการการใช้โค้ดพร้อมสรุปยอด (สรุปไม่รวมการจองที่ถูกยกเลิก)
+func (s *PromoService) RedemptionReport(ctx context.Context, promoID int) (*models.PromoRedemptionReport, error) {
+	promo, err := s.GetPromoCode(ctx, promoID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT pr.redemption_id, pr.promo_id, pr.booking_id, b.booking_code, b.booking_status,
+		       pr.user_id, pr.discount_amount, pr.created_at
+		FROM promo_redemptions pr
+		JOIN bookings b ON pr.booking_id = b.booking_id
+		WHERE pr.promo_id = $1
+		ORDER BY pr.created_at DESC
+	`, promoID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch promo redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.PromoRedemptionReport{PromoCode: *promo, Items: []models.PromoRedemption{}}
+	users := map[int]bool{}
+	for rows.Next() {
+		var r models.PromoRedemption
+		err := rows.Scan(&r.RedemptionID, &r.PromoID, &r.BookingID, &r.BookingCode, &r.BookingStatus,
+			&r.UserID, &r.DiscountAmount, &r.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan promo redemption: %w", err)
+		}
+		report.Items = append(report.Items, r)
+		if r.BookingStatus != "cancelled" {
+			report.Redemptions++
+			report.TotalDiscount += r.DiscountAmount
+			users[r.UserID] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch promo redemptions: %w", err)
+	}
+	report.UniqueUsers = len(users)
+	report.TotalDiscount = math.Round(report.TotalDiscount*100) / 100
+	return report, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+)
+
+func TestPromoDiscount(t *testing.T) {
+	noCap := sql.NullFloat64{}
+	tests := []struct {
+		name         string
+		discountType string
+		value        float64
+		maxDiscount  sql.NullFloat64
+		subtotal     float64
+		want         float64
+	}{
+		{"percentage", "percentage", 20, noCap, 450, 90},
+		{"percentage under cap", "percentage", 20, sql.NullFloat64{Float64: 100, Valid: true}, 450, 90},
+		{"percentage capped", "percentage", 50, sql.NullFloat64{Float64: 100, Valid: true}, 450, 100},
+		{"percentage rounded to satang", "percentage", 15, noCap, 333.33, 50},
+		{"fixed", "fixed", 50, noCap, 450, 50},
+		{"cap ignored for fixed", "fixed", 150, sql.NullFloat64{Float64: 100, Valid: true}, 450, 150},
+		{"fixed above subtotal", "fixed", 500, noCap, 180, 180},
+		{"full percentage", "percentage", 100, noCap, 180, 180},
+		{"unknown type", "bogo", 50, noCap, 180, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promoDiscount(tt.discountType, tt.value, tt.maxDiscount, tt.subtotal); got != tt.want {
+				t.Errorf("promoDiscount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePromoCode(t *testing.T) {
+	if got := normalizePromoCode("  summer25 \n"); got != "SUMMER25" {
+		t.Errorf("normalizePromoCode() = %q, want %q", got, "SUMMER25")
+	}
+}
+
+// createPromoCode สร้างโค้ดส่วนลดของเทสต์ conditions คือค่าของคอลัมน์เงื่อนไขเพิ่มเติม (เช่น max_uses: 1)
+func createPromoCode(t *testing.T, db *sql.DB, discountType string, value float64, conditions map[string]any) string {
+	t.Helper()
+	code := fmt.Sprintf("TEST%d", time.Now().UnixNano())
+	columns := "code, discount_type, discount_value"
+	placeholders := "$1, $2, $3"
+	args := []any{code, discountType, value}
+	for column, v := range conditions {
+		args = append(args, v)
+		columns += ", " + column
+		placeholders += fmt.Sprintf(", $%d", len(args))
+	}
+	_, err := db.Exec("INSERT INTO promo_codes ("+columns+") VALUES ("+placeholders+")", args...)
+	if err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM promo_codes WHERE code = $1", code) })
+	return code
+}
+
+func TestApplyPromoCode(t *testing.T) {
+	db := openTestDB(t)
+	showtimeID, _ := createShowtimeFixture(t, db, 1)
+	userID := createTestUsers(t, db, 1)[0]
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		discountType string
+		value        float64
+		conditions   map[string]any
+		seatCount    int
+		want         float64
+		wantReason   string
+	}{
+		{"percentage with cap", "percentage", 50, map[string]any{"max_discount": 80}, 2, 80, ""},
+		{"fixed", "fixed", 30, nil, 1, 30, ""},
+		{"not started", "fixed", 30, map[string]any{"valid_from": time.Now().Add(24 * time.Hour)}, 1, 0, "code is not valid yet"},
+		{"expired", "fixed", 30, map[string]any{"valid_until": time.Now().Add(-24 * time.Hour)}, 1, 0, "code has expired"},
+		{"too few seats", "fixed", 30, map[string]any{"min_seats": 3}, 2, 0, "at least 3 seats are required"},
+		{"inactive", "fixed", 30, map[string]any{"is_active": false}, 1, 0, "code does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := createPromoCode(t, db, tt.discountType, tt.value, tt.conditions)
+			promo, err := applyPromoCode(ctx, db, " "+code+" ", userID, showtimeID, tt.seatCount, 200)
+			if tt.wantReason != "" {
+				var promoErr *PromoCodeError
+				if !errors.As(err, &promoErr) || promoErr.Reason != tt.wantReason {
+					t.Fatalf("applyPromoCode() error = %v, want reason %q", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPromoCode(): %v", err)
+			}
+			if promo.code != code || promo.discount != tt.want {
+				t.Errorf("applyPromoCode() = %s discount %v, want %s discount %v", promo.code, promo.discount, code, tt.want)
+			}
+		})
+	}
+}
+
+// ใช้โค้ดครบ max_uses แล้วใช้ไม่ได้ จนกว่าการจองที่ใช้โค้ดจะถูกยกเลิก (คืนสิทธิ์)
+func TestApplyPromoCodeUsageLimit(t *testing.T) {
+	db := openTestDB(t)
+	showtimeID, seatIDs := createShowtimeFixture(t, db, 2)
+	userIDs := createTestUsers(t, db, 2)
+	service := newTestBookingService(db)
+	ctx := context.Background()
+	code := createPromoCode(t, db, "fixed", 20, map[string]any{"max_uses": 1})
+
+	first, err := service.Reserve(ctx, userIDs[0], showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[0]}}, nil, code)
+	if err != nil {
+		t.Fatalf("reserve with promo: %v", err)
+	}
+	if first.DiscountAmount != 20 {
+		t.Errorf("discount = %v, want 20", first.DiscountAmount)
+	}
+
+	var promoErr *PromoCodeError
+	if _, err := applyPromoCode(ctx, db, code, userIDs[1], showtimeID, 1, 200); !errors.As(err, &promoErr) {
+		t.Fatalf("apply used-up code error = %v, want PromoCodeError", err)
+	}
+
+	if err := service.Cancel(ctx, first.BookingID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := applyPromoCode(ctx, db, code, userIDs[1], showtimeID, 1, 200); err != nil {
+		t.Errorf("apply code after cancellation: %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrBookingNotRefundable   = errors.New("only confirmed and paid bookings can be refunded")
+	ErrRefundCutoffPassed     = errors.New("refund cutoff before the showtime has passed")
+	ErrRefundAlreadyRequested = errors.New("a refund request for this booking is already pending")
+	ErrRefundNotFound         = errors.New("refund request not found")
+	ErrRefundAlreadyReviewed  = errors.New("refund request has already been reviewed")
+)
+
+// RefundService ขอคืนเงินสำหรับการจองที่ชำระเงินแล้ว (ลูกค้าขอ, admin อนุมัติ/ปฏิเสธ)
+type RefundService struct {
+	db       *sql.DB
+	gateway  payments.Gateway
+	broker   events.Broker
+	waitlist *WaitlistService
+	cutoff   time.Duration
+}
+
+func NewRefundService(db *sql.DB, gateway payments.Gateway, broker events.Broker, waitlist *WaitlistService, cutoff time.Duration) *RefundService {
+	return &RefundService{db: db, gateway: gateway, broker: broker, waitlist: waitlist, cutoff: cutoff}
+}
+
+const refundSelectQuery = `
+	SELECT r.refund_id, r.booking_id, b.booking_code, r.user_id, r.reason, r.amount, r.status,
+	       r.admin_note, r.reviewed_by, r.reviewed_at, r.provider_refund_ref, r.created_at, r.updated_at
+	FROM refund_requests r
+	JOIN bookings b ON r.booking_id = b.booking_id
+`
+
+// RequestRefund ลูกค้าขอคืนเงิน ต้องขอก่อนรอบฉายเริ่มอย่างน้อยตาม cutoff ที่ตั้งไว้
+func (s *RefundService) RequestRefund(ctx context.Context, bookingID, userID int, reason *string) (*models.RefundRequest, error) {
+	var bookingStatus, paymentStatus string
+	var totalAmount float64
+	var beforeCutoff bool
+	// เทียบเวลาใน database เพราะ show_date/show_time ไม่มี time zone
+	query := `
+		SELECT b.booking_status, b.payment_status, b.total_amount,
+		       s.show_date + s.show_time > LOCALTIMESTAMP + $2 * INTERVAL '1 second'
+		FROM bookings b
+		JOIN showtimes s ON b.showtime_id = s.showtime_id
+		WHERE b.booking_id = $1
+	`
+	err := s.db.QueryRowContext(ctx, query, bookingID, int(s.cutoff.Seconds())).Scan(&bookingStatus, &paymentStatus, &totalAmount, &beforeCutoff)
+	if err == sql.ErrNoRows {
+		return nil, ErrBookingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking: %w", err)
+	}
+
+	if bookingStatus != "confirmed" || paymentStatus != "paid" {
+		return nil, ErrBookingNotRefundable
+	}
+	if !beforeCutoff {
+		return nil, ErrRefundCutoffPassed
+	}
+
+	var refundID int
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO refund_requests (booking_id, user_id, reason, amount, status)
+		VALUES ($1, $2, $3, $4, 'pending')
+		RETURNING refund_id
+	`, bookingID, userID, reason, totalAmount).Scan(&refundID)
+	if isUniqueViolation(err) {
+		return nil, ErrRefundAlreadyRequested
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create refund request: %w", err)
+	}
+
+	return s.GetRefund(ctx, refundID)
+}
+
+// GetRefund ดึงคำขอคืนเงินตาม ID
+func (s *RefundService) GetRefund(ctx context.Context, refundID int) (*models.RefundRequest, error) {
+	row := s.db.QueryRowContext(ctx, refundSelectQuery+" WHERE r.refund_id = $1", refundID)
+	refund, err := scanRefund(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch refund request: %w", err)
+	}
+	return refund, nil
+}
+
+// ListRefunds ดึงคำขอคืนเงินทั้งหมด (กรองตามสถานะได้)
+func (s *RefundService) ListRefunds(ctx context.Context, status string) ([]models.RefundRequest, error) {
+	query := refundSelectQuery
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE r.status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY r.created_at DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch refund requests: %w", err)
+	}
+	defer rows.Close()
+
+	refunds := []models.RefundRequest{}
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan refund request: %w", err)
+		}
+		refunds = append(refunds, *refund)
+	}
+	return refunds, rows.Err()
+}
+
+// ApproveRefund อนุมัติคำขอคืนเงิน: ยกเลิกการจอง คืนที่นั่ง แล้วคืนเงินผ่าน payment gateway
+// บันทึกคำขอเป็น refunding แล้ว commit ก่อนเรียก gateway จากนั้นจึงเปลี่ยนเป็น refunded
+// ถ้า gateway ล้มเหลว คำขอยังเป็น refunding และ job retry_refunds จะส่งซ้ำด้วย idempotency key เดิม
+func (s *RefundService) ApproveRefund(ctx context.Context, refundID, adminID int, note *string) (*models.RefundRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	bookingID, err := lockPendingRefund(ctx, tx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	booking, err := lockBookingForPayment(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.BookingStatus != "confirmed" || booking.PaymentStatus != "paid" {
+		return nil, ErrBookingNotRefundable
+	}
+
+	// บันทึกว่าต้องคืนเงินทุก payment ที่เก็บเงินแล้วของการจองนี้ (key อ้างอิงคำขอคืนเงิน)
+	refunds := &pendingRefunds{}
+	captured, err := markCapturedPaymentsRefunding(ctx, tx, bookingID, fmt.Sprintf("refund-request-%d", refundID))
+	if err != nil {
+		return nil, err
+	}
+	refunds.add(captured...)
+
+	// ยกเลิกการจอง คืนที่นั่งและสต็อกสินค้าหน้าโรง และเปลี่ยน payment_status เป็น refunded
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bookings
+		SET booking_status = 'cancelled', payment_status = 'refunded', updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("cancel refunded booking: %w", err)
+	}
+	seatChanges := &seatEvents{}
+	if err := releaseSeats(ctx, tx, bookingID, booking.ShowtimeID, seatChanges); err != nil {
+		return nil, err
+	}
+	if err := restockConcessions(ctx, tx, bookingID); err != nil {
+		return nil, err
+	}
+	if err := s.waitlist.offerReleasedSeats(ctx, tx, booking.ShowtimeID, seatChanges); err != nil {
+		return nil, err
+	}
+
+	if err := reviewRefund(ctx, tx, refundID, "refunding", adminID, note, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	seatChanges.publish(s.broker)
+
+	if _, err := refunds.send(ctx, s.db, s.gateway); err != nil {
+		log.Printf("Refund %d is waiting for retry: %v", refundID, err)
+	} else if _, err := completeRefundRequests(ctx, s.db, &refundID); err != nil {
+		log.Printf("Failed to mark refund %d refunded: %v", refundID, err)
+	}
+
+	return s.GetRefund(ctx, refundID)
+}
+
+// RejectRefund ปฏิเสธคำขอคืนเงิน การจองยังคง confirmed เหมือนเดิม
+func (s *RefundService) RejectRefund(ctx context.Context, refundID, adminID int, note *string) (*models.RefundRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPendingRefund(ctx, tx, refundID); err != nil {
+		return nil, err
+	}
+	if err := reviewRefund(ctx, tx, refundID, "rejected", adminID, note, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return s.GetRefund(ctx, refundID)
+}
+
+// RetryRefunds ส่งคำขอคืนเงินของ payment ที่ค้างสถานะ refunding ซ้ำด้วย idempotency key เดิม (ผู้ให้บริการไม่คืนเงินซ้ำ)
+// แล้วปิดคำขอคืนเงินที่ทุก payment คืนเงินสำเร็จแล้ว คืนจำนวน payment ที่คืนเงินสำเร็จ
+func (s *RefundService) RetryRefunds(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT payment_id, provider_ref, amount, refund_idempotency_key
+		FROM payments
+		WHERE status = $1
+		ORDER BY payment_id
+	`, payments.StatusRefunding)
+	if err != nil {
+		return 0, fmt.Errorf("fetch refunding payments: %w", err)
+	}
+
+	refunds := &pendingRefunds{}
+	for rows.Next() {
+		var p paymentRefund
+		if err := rows.Scan(&p.PaymentID, &p.ProviderRef, &p.Amount, &p.IdempotencyKey); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan refunding payment: %w", err)
+		}
+		refunds.add(p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("fetch refunding payments: %w", err)
+	}
+
+	refundRefs, sendErr := refunds.send(ctx, s.db, s.gateway)
+	if _, err := completeRefundRequests(ctx, s.db, nil); err != nil {
+		return len(refundRefs), err
+	}
+	return len(refundRefs), sendErr
+}
+
+// completeRefundRequests เปลี่ยนคำขอคืนเงินที่ refunding เป็น refunded เมื่อไม่มี payment ของการจองค้าง refunding แล้ว
+// refundID = nil จะปิดทุกคำขอที่พร้อม คืนจำนวนคำขอที่ปิด
+func completeRefundRequests(ctx context.Context, db execer, refundID *int) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		UPDATE refund_requests r
+		SET status = 'refunded',
+		    provider_refund_ref = (
+		        SELECT string_agg(p.refund_ref, ',' ORDER BY p.payment_id) FROM payments p
+		        WHERE p.booking_id = r.booking_id AND p.status = $1 AND p.refund_ref IS NOT NULL
+		    ),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE r.status = 'refunding'
+		  AND ($3::int IS NULL OR r.refund_id = $3)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM payments p WHERE p.booking_id = r.booking_id AND p.status = $2
+		  )
+	`, payments.StatusRefunded, payments.StatusRefunding, refundID)
+	if err != nil {
+		return 0, fmt.Errorf("complete refund requests: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// lockPendingRefund ล็อกคำขอคืนเงินที่ยังรอพิจารณา คืน booking_id
+func lockPendingRefund(ctx context.Context, tx *sql.Tx, refundID int) (int, error) {
+	var bookingID int
+	var status string
+	err := tx.QueryRowContext(ctx, `
+		SELECT booking_id, status FROM refund_requests WHERE refund_id = $1 FOR UPDATE
+	`, refundID).Scan(&bookingID, &status)
+	if err == sql.ErrNoRows {
+		return 0, ErrRefundNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("fetch refund request: %w", err)
+	}
+	if status != "pending" {
+		return 0, ErrRefundAlreadyReviewed
+	}
+	return bookingID, nil
+}
+
+// reviewRefund บันทึกผลการพิจารณาคำขอคืนเงิน
+func reviewRefund(ctx context.Context, tx *sql.Tx, refundID int, status string, adminID int, note, providerRefundRef *string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE refund_requests
+		SET status = $1, reviewed_by = $2, reviewed_at = CURRENT_TIMESTAMP, admin_note = $3,
+		    provider_refund_ref = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE refund_id = $5
+	`, status, adminID, note, providerRefundRef, refundID)
+	if err != nil {
+		return fmt.Errorf("update refund request: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation ตรวจว่า error มาจาก unique constraint (23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRefund(row rowScanner) (*models.RefundRequest, error) {
+	var refund models.RefundRequest
+	err := row.Scan(
+		&refund.RefundID, &refund.BookingID, &refund.BookingCode, &refund.UserID, &refund.Reason,
+		&refund.Amount, &refund.Status, &refund.AdminNote, &refund.ReviewedBy, &refund.ReviewedAt,
+		&refund.ProviderRefundRef, &refund.CreatedAt, &refund.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
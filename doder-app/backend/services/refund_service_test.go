@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+)
+
+// unavailableRefundGateway ผู้ให้บริการที่ปฏิเสธการคืนเงินจนกว่าจะตั้ง available
+type unavailableRefundGateway struct {
+	payments.Gateway
+	available bool
+}
+
+func (g *unavailableRefundGateway) Refund(ctx context.Context, providerRef string, amount float64, idempotencyKey string) (*payments.Refund, error) {
+	if !g.available {
+		return nil, errors.New("provider unavailable")
+	}
+	return g.Gateway.Refund(ctx, providerRef, amount, idempotencyKey)
+}
+
+// createPaidBookingRefund จองและชำระเงินหนึ่งที่นั่ง แล้วยื่นคำขอคืนเงิน คืน booking_id และ refund_id
+func createPaidBookingRefund(t *testing.T, db *sql.DB, bookings *BookingService, refunds *RefundService) (int, int) {
+	t.Helper()
+	ctx := context.Background()
+	showtimeID, seatIDs := createShowtimeFixture(t, db, 1)
+	userID := createTestUsers(t, db, 1)[0]
+
+	booking, err := bookings.Reserve(ctx, userID, showtimeID, []models.BookingSeatRequest{{SeatID: seatIDs[0]}}, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if _, err := bookings.Confirm(ctx, booking.BookingID, "cash", ""); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	refund, err := refunds.RequestRefund(ctx, booking.BookingID, userID, nil)
+	if err != nil {
+		t.Fatalf("request refund: %v", err)
+	}
+	return booking.BookingID, refund.RefundID
+}
+
+func TestApproveRefund(t *testing.T) {
+	db := openTestDB(t)
+	bookings := newTestBookingService(db)
+	refunds := NewRefundService(db, bookings.gateway, bookings.broker, bookings.waitlist.(*WaitlistService), time.Hour)
+	bookingID, refundID := createPaidBookingRefund(t, db, bookings, refunds)
+	adminID := createTestUsers(t, db, 1)[0]
+
+	refund, err := refunds.ApproveRefund(context.Background(), refundID, adminID, nil)
+	if err != nil {
+		t.Fatalf("approve refund: %v", err)
+	}
+	if refund.Status != "refunded" || refund.ProviderRefundRef == nil {
+		t.Errorf("refund = %s (ref %v), want refunded with provider ref", refund.Status, refund.ProviderRefundRef)
+	}
+
+	status, key, refundRef := paymentRefundState(t, db, bookingID)
+	if status != payments.StatusRefunded || !refundRef.Valid || refundRef.String != *refund.ProviderRefundRef {
+		t.Errorf("payment = %s (ref %v), want refunded with ref %v", status, refundRef, *refund.ProviderRefundRef)
+	}
+	if !key.Valid || key.String == "" {
+		t.Error("payment refunded without idempotency key")
+	}
+
+	if _, err := refunds.ApproveRefund(context.Background(), refundID, adminID, nil); !errors.Is(err, ErrRefundAlreadyReviewed) {
+		t.Errorf("approve twice error = %v, want %v", err, ErrRefundAlreadyReviewed)
+	}
+}
+
+// ผู้ให้บริการล่มตอนอนุมัติ: คำขอค้าง refunding และ RetryRefunds คืนเงินด้วย key เดิมเมื่อผู้ให้บริการกลับมา
+func TestApproveRefundRetriesWhenProviderFails(t *testing.T) {
+	db := openTestDB(t)
+	bookings := newTestBookingService(db)
+	gateway := &unavailableRefundGateway{Gateway: bookings.gateway}
+	refunds := NewRefundService(db, gateway, bookings.broker, bookings.waitlist.(*WaitlistService), time.Hour)
+	bookingID, refundID := createPaidBookingRefund(t, db, bookings, refunds)
+	adminID := createTestUsers(t, db, 1)[0]
+	ctx := context.Background()
+
+	refund, err := refunds.ApproveRefund(ctx, refundID, adminID, nil)
+	if err != nil {
+		t.Fatalf("approve refund: %v", err)
+	}
+	if refund.Status != "refunding" {
+		t.Fatalf("refund status = %s, want refunding", refund.Status)
+	}
+	status, key, _ := paymentRefundState(t, db, bookingID)
+	if status != payments.StatusRefunding {
+		t.Fatalf("payment status = %s, want %s", status, payments.StatusRefunding)
+	}
+
+	gateway.available = true
+	// payment ที่ค้าง refunding จากข้อมูลอื่นใน database อาจคืนเงินไม่สำเร็จ จึงตรวจเฉพาะคำขอของเทสต์นี้
+	if _, err := refunds.RetryRefunds(ctx); err != nil {
+		t.Logf("retry refunds: %v", err)
+	}
+
+	refund, err = refunds.GetRefund(ctx, refundID)
+	if err != nil {
+		t.Fatalf("get refund: %v", err)
+	}
+	if refund.Status != "refunded" {
+		t.Errorf("refund status after retry = %s, want refunded", refund.Status)
+	}
+	status, retriedKey, _ := paymentRefundState(t, db, bookingID)
+	if status != payments.StatusRefunded || retriedKey != key {
+		t.Errorf("payment = %s with key %v, want refunded with key %v", status, retriedKey, key)
+	}
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"movie-booking-system/models"
+
+	"github.com/lib/pq"
+)
+
+var ErrCinemaNotFound = errors.New("cinema not found")
+
+const (
+	// planSlotMinutes รอบในแผนเริ่มตรงนาทีที่หารด้วยค่านี้ลงตัว
+	planSlotMinutes       = 5
+	defaultPrimeTimeStart = "18:00"
+	defaultPrimeTimeEnd   = "22:00"
+	defaultPrimeTimeBoost = 1.5
+)
+
+// minuteRange ช่วงเวลาเป็นนาทีนับจากเที่ยงคืนต้นวันของ show_date (เกิน 1440 = วันถัดไป)
+type minuteRange struct {
+	start int
+	end   int
+}
+
+// openingHours เวลาเปิด-ปิดของสาขา opens/closes เป็นนาทีจากเที่ยงคืน (closes เกิน 1440 = ปิดหลังเที่ยงคืน)
+type openingHours struct {
+	opensAt  string
+	closesAt string
+	opens    int
+	closes   int
+}
+
+type planMovie struct {
+	movieID     int
+	title       string
+	duration    int
+	weight      float64
+	minShowings int
+	planned     int
+}
+
+type planTheater struct {
+	theaterID int
+	name      string
+	cursor    int
+	blocked   []minuteRange // รอบฉายที่มีอยู่แล้ว (รวมเวลาทำความสะอาด) เรียงตามเวลาเริ่ม
+	showings  int
+	idle      int
+	done      bool
+}
+
+// PlanDay เสนอแผนรอบฉายหนึ่งวันของทุกโรงในสาขา ให้เต็มที่สุดภายในเวลาเปิด-ปิดของสาขา
+// วางรอบทีละรอบให้โรงที่ว่างเร็วที่สุดก่อน (รอบเริ่มเมื่อรอบก่อนหน้าทำความสะอาดเสร็จ) โดยเลือกภาพยนตร์:
+//  1. เรื่องที่ยังไม่ครบ min_showings ก่อน
+//  2. คะแนนสูงสุด = weight x prime_time_boost (ถ้าเริ่มใน prime time) / (1 + จำนวนรอบที่ได้ไปแล้ว)
+//  3. เสมอกันเลือกเรื่องที่ยาวกว่า (เหลือเวลาว่างน้อยกว่า)
+//
+// รอบฉายที่มีอยู่แล้วในวันนั้นถูกเว้นไว้ แผนยังไม่ถูกบันทึกจนกว่าจะเรียก AcceptPlan
+func (s *ShowtimeService) PlanDay(ctx context.Context, cinemaID int, req models.SchedulePlanRequest) (*models.SchedulePlan, error) {
+	showDate, err := time.Parse("2006-01-02", req.ShowDate)
+	if err != nil {
+		return nil, &ScheduleRequestError{Reason: "show_date must be YYYY-MM-DD"}
+	}
+	primeTime, err := primeTimeWindow(req.PrimeTimeStart, req.PrimeTimeEnd)
+	if err != nil {
+		return nil, err
+	}
+	primeBoost := req.PrimeTimeBoost
+	if primeBoost == 0 {
+		primeBoost = defaultPrimeTimeBoost
+	}
+
+	plan := &models.SchedulePlan{
+		CinemaID:  cinemaID,
+		ShowDate:  showDate.Format("2006-01-02"),
+		Showtimes: []models.PlannedShowtime{},
+		Movies:    []models.SchedulePlanMovieSummary{},
+		Theaters:  []models.SchedulePlanTheater{},
+		Warnings:  []string{},
+	}
+	hours, err := fetchOpeningHours(ctx, s.db, cinemaID)
+	if err != nil {
+		return nil, err
+	}
+	plan.OpensAt, plan.ClosesAt = hours.opensAt, hours.closesAt
+	opens, closes := hours.opens, hours.closes
+
+	theaters, err := s.planTheaters(ctx, cinemaID, req.TheaterIDs, opens)
+	if err != nil {
+		return nil, err
+	}
+	movies, err := s.planMovies(ctx, req.Movies)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadBlockedRanges(ctx, theaters, plan.ShowDate, opens, closes); err != nil {
+		return nil, err
+	}
+
+	trailer := int(s.trailerPadding / time.Minute)
+	cleaning := int(s.cleaningBuffer / time.Minute)
+	plan.Showtimes = planShowtimes(theaters, movies, trailer, cleaning, closes, primeTime, primeBoost)
+	for _, theater := range theaters {
+		plan.Theaters = append(plan.Theaters, models.SchedulePlanTheater{
+			TheaterID:   theater.theaterID,
+			TheaterName: theater.name,
+			Showings:    theater.showings,
+			IdleMinutes: theater.idle,
+		})
+	}
+	for _, movie := range movies {
+		plan.Movies = append(plan.Movies, models.SchedulePlanMovieSummary{
+			MovieID:     movie.movieID,
+			MovieTitle:  movie.title,
+			Showings:    movie.planned,
+			MinShowings: movie.minShowings,
+		})
+		if movie.planned < movie.minShowings {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: planned %d of minimum %d showings", movie.title, movie.planned, movie.minShowings))
+		}
+	}
+	return plan, nil
+}
+
+// AcceptPlan สร้างรอบฉายจากแผน (ที่ admin อาจแก้แล้ว) ใน transaction เดียว end_time คำนวณใหม่จากความยาวภาพยนตร์
+// ถ้ารอบใดทับรอบอื่นจะไม่สร้างเลยและคืน ShowtimeConflictError ของรอบนั้น รอบที่อยู่นอกเวลาเปิด-ปิดของสาขาคืน ScheduleRequestError
+func (s *ShowtimeService) AcceptPlan(ctx context.Context, cinemaID int, req models.AcceptSchedulePlanRequest) ([]models.Showtime, error) {
+	if _, err := time.Parse("2006-01-02", req.ShowDate); err != nil {
+		return nil, &ScheduleRequestError{Reason: "show_date must be YYYY-MM-DD"}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hours, err := fetchOpeningHours(ctx, tx, cinemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	theaterIDs := map[int]bool{}
+	for _, item := range req.Showtimes {
+		theaterIDs[item.TheaterID] = true
+	}
+	ids := make([]int, 0, len(theaterIDs))
+	for theaterID := range theaterIDs {
+		ids = append(ids, theaterID)
+	}
+	var found int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM theaters WHERE cinema_id = $1 AND theater_id = ANY($2)
+	`, cinemaID, pq.Array(ids)).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("fetch theaters: %w", err)
+	}
+	if found != len(ids) {
+		return nil, ErrTheaterNotFound
+	}
+
+	showtimes := []models.Showtime{}
+	cleaningMinutes := int(s.cleaningBuffer / time.Minute)
+	for _, item := range req.Showtimes {
+		if _, err := time.Parse("15:04", item.ShowTime); err != nil {
+			return nil, &ScheduleRequestError{Reason: fmt.Sprintf("show_time %q must be HH:MM", item.ShowTime)}
+		}
+		endTime, err := s.deriveEndTime(ctx, tx, item.MovieID, item.ShowTime)
+		if err != nil {
+			return nil, err
+		}
+		if !hours.fits(item.ShowTime, endTime) {
+			return nil, &ScheduleRequestError{Reason: fmt.Sprintf(
+				"showtime %s-%s in theater %d is outside opening hours %s-%s",
+				item.ShowTime, endTime, item.TheaterID, hours.opensAt, hours.closesAt,
+			)}
+		}
+		slot := showtimeSlot{
+			movieID:         item.MovieID,
+			theaterID:       item.TheaterID,
+			showDate:        req.ShowDate,
+			showTime:        item.ShowTime,
+			endTime:         endTime,
+			cleaningMinutes: cleaningMinutes,
+		}
+		conflict, err := findShowtimeConflict(ctx, tx, slot)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != nil {
+			return nil, conflict
+		}
+		showtime, err := insertShowtime(ctx, tx, slot, req.Price)
+		if err != nil {
+			return nil, s.scheduleWriteError(ctx, slot, err, "create showtime")
+		}
+		showtimes = append(showtimes, *showtime)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return showtimes, nil
+}
+
+// planShowtimes วางรอบแบบ greedy ตามที่อธิบายใน PlanDay ปรับ cursor/สถิติของ theaters และ movies ไปด้วย
+// คืนรอบที่วางได้เรียงตามโรงแล้วตามเวลา
+func planShowtimes(theaters []*planTheater, movies []*planMovie, trailer, cleaning, closes int, primeTime minuteRange, primeBoost float64) []models.PlannedShowtime {
+	showtimes := []models.PlannedShowtime{}
+	for {
+		theater := nextPlanTheater(theaters)
+		if theater == nil {
+			break
+		}
+
+		start := roundUpMinutes(theater.cursor, planSlotMinutes)
+		// ช่วงว่างถัดไปของโรงจบที่รอบฉายเดิมรอบถัดไป หรือเวลาปิด
+		nextBlock := theater.nextBlock(start)
+		if nextBlock != nil && nextBlock.start <= start {
+			theater.cursor = nextBlock.end
+			continue
+		}
+
+		movie := pickPlanMovie(movies, start, trailer, cleaning, closes, nextBlock, primeTime, primeBoost)
+		if movie == nil {
+			if nextBlock != nil {
+				theater.idle += nextBlock.start - theater.cursor
+				theater.cursor = nextBlock.end
+				continue
+			}
+			if closes > theater.cursor {
+				theater.idle += closes - theater.cursor
+			}
+			theater.done = true
+			continue
+		}
+
+		end := start + trailer + movie.duration
+		theater.idle += start - theater.cursor
+		theater.cursor = end + cleaning
+		theater.showings++
+		movie.planned++
+		showtimes = append(showtimes, models.PlannedShowtime{
+			TheaterID:   theater.theaterID,
+			TheaterName: theater.name,
+			MovieID:     movie.movieID,
+			MovieTitle:  movie.title,
+			ShowTime:    formatMinutes(start),
+			EndTime:     formatMinutes(end),
+			PrimeTime:   primeTime.contains(start),
+		})
+	}
+
+	// รอบถูกวางตามเวลาข้ามทุกโรง เรียงตามโรงโดยคงลำดับเวลาไว้
+	sort.SliceStable(showtimes, func(i, j int) bool {
+		return showtimes[i].TheaterID < showtimes[j].TheaterID
+	})
+	return showtimes
+}
+
+// fetchOpeningHours เวลาเปิด-ปิดของสาขาที่เปิดอยู่
+func fetchOpeningHours(ctx context.Context, q querier, cinemaID int) (*openingHours, error) {
+	hours := &openingHours{}
+	err := q.QueryRowContext(ctx, `
+		SELECT to_char(opens_at, 'HH24:MI'), to_char(closes_at, 'HH24:MI'),
+		       (EXTRACT(HOUR FROM opens_at) * 60 + EXTRACT(MINUTE FROM opens_at))::INTEGER,
+		       (EXTRACT(HOUR FROM closes_at) * 60 + EXTRACT(MINUTE FROM closes_at))::INTEGER
+		FROM cinemas WHERE cinema_id = $1 AND is_active = TRUE
+	`, cinemaID).Scan(&hours.opensAt, &hours.closesAt, &hours.opens, &hours.closes)
+	if err == sql.ErrNoRows {
+		return nil, ErrCinemaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch cinema: %w", err)
+	}
+	if hours.closes <= hours.opens {
+		hours.closes += 24 * 60
+	}
+	return hours, nil
+}
+
+// fits รอบ showTime-endTime (HH:MM) เริ่มหลังเวลาเปิดภายในวันเดียวกับ show_date และจบไม่เกินเวลาปิด
+// เหมือนเงื่อนไขที่ PlanDay ใช้วางรอบ
+func (h *openingHours) fits(showTime, endTime string) bool {
+	startAt, err := time.Parse("15:04", showTime)
+	if err != nil {
+		return false
+	}
+	endAt, err := time.Parse("15:04", endTime)
+	if err != nil {
+		return false
+	}
+	start := startAt.Hour()*60 + startAt.Minute()
+	end := endAt.Hour()*60 + endAt.Minute()
+	if end <= start {
+		end += 24 * 60
+	}
+	return start >= h.opens && end <= h.closes
+}
+
+// planTheaters โรงที่เปิดอยู่ของสาขา (theaterIDs ว่าง = ทุกโรง) ถ้าระบุโรงที่ไม่อยู่ในสาขาคืน ErrTheaterNotFound
+func (s *ShowtimeService) planTheaters(ctx context.Context, cinemaID int, theaterIDs []int, opens int) ([]*planTheater, error) {
+	query := `
+		SELECT theater_id, theater_name FROM theaters
+		WHERE cinema_id = $1 AND is_active = TRUE
+	`
+	args := []interface{}{cinemaID}
+	if len(theaterIDs) > 0 {
+		query += " AND theater_id = ANY($2)"
+		args = append(args, pq.Array(theaterIDs))
+	}
+	query += " ORDER BY theater_id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch theaters: %w", err)
+	}
+	defer rows.Close()
+
+	theaters := []*planTheater{}
+	for rows.Next() {
+		theater := &planTheater{cursor: opens}
+		if err := rows.Scan(&theater.theaterID, &theater.name); err != nil {
+			return nil, fmt.Errorf("scan theater: %w", err)
+		}
+		theaters = append(theaters, theater)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch theaters: %w", err)
+	}
+
+	if len(theaterIDs) > 0 {
+		requested := map[int]bool{}
+		for _, theaterID := range theaterIDs {
+			requested[theaterID] = true
+		}
+		if len(theaters) != len(requested) {
+			return nil, ErrTheaterNotFound
+		}
+	}
+	if len(theaters) == 0 {
+		return nil, &ScheduleRequestError{Reason: "cinema has no active theaters"}
+	}
+	return theaters, nil
+}
+
+// planMovies ภาพยนตร์ที่เปิดอยู่ตามคำขอ พร้อมความยาว
+func (s *ShowtimeService) planMovies(ctx context.Context, requested []models.SchedulePlanMovie) ([]*planMovie, error) {
+	byID := map[int]*planMovie{}
+	movies := make([]*planMovie, 0, len(requested))
+	ids := make([]int, 0, len(requested))
+	for _, m := range requested {
+		if byID[m.MovieID] != nil {
+			return nil, &ScheduleRequestError{Reason: fmt.Sprintf("movie %d is listed more than once", m.MovieID)}
+		}
+		weight := m.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		movie := &planMovie{movieID: m.MovieID, weight: weight, minShowings: m.MinShowings}
+		byID[m.MovieID] = movie
+		movies = append(movies, movie)
+		ids = append(ids, m.MovieID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT movie_id, title, duration FROM movies WHERE movie_id = ANY($1) AND is_active = TRUE
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("fetch movies: %w", err)
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var movieID int
+		var title string
+		var duration int
+		if err := rows.Scan(&movieID, &title, &duration); err != nil {
+			return nil, fmt.Errorf("scan movie: %w", err)
+		}
+		byID[movieID].title = title
+		byID[movieID].duration = duration
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch movies: %w", err)
+	}
+	if found != len(movies) {
+		return nil, ErrMovieNotFound
+	}
+	return movies, nil
+}
+
+// loadBlockedRanges โหลดรอบฉายที่เปิดอยู่แล้วซึ่งทับช่วงเวลาเปิด-ปิดของวันนั้น (รวมรอบดึกของเมื่อวานที่ข้ามเที่ยงคืน)
+func (s *ShowtimeService) loadBlockedRanges(ctx context.Context, theaters []*planTheater, showDate string, opens, closes int) error {
+	byID := map[int]*planTheater{}
+	ids := make([]int, 0, len(theaters))
+	for _, theater := range theaters {
+		byID[theater.theaterID] = theater
+		ids = append(ids, theater.theaterID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.theater_id,
+		       FLOOR(EXTRACT(EPOCH FROM lower(p.period) - $2::DATE::TIMESTAMP) / 60)::INTEGER,
+		       CEIL(EXTRACT(EPOCH FROM upper(p.period) - $2::DATE::TIMESTAMP) / 60)::INTEGER
+		FROM showtimes s
+		CROSS JOIN LATERAL showtime_period(s.show_date, s.show_time, s.end_time, s.cleaning_minutes) AS p(period)
+		WHERE s.theater_id = ANY($1) AND s.is_active = TRUE
+		  AND p.period && tsrange($2::DATE + make_interval(mins => $3), $2::DATE + make_interval(mins => $4))
+		ORDER BY s.theater_id, lower(p.period)
+	`, pq.Array(ids), showDate, opens, closes)
+	if err != nil {
+		return fmt.Errorf("fetch existing showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var theaterID int
+		var block minuteRange
+		if err := rows.Scan(&theaterID, &block.start, &block.end); err != nil {
+			return fmt.Errorf("scan showtime: %w", err)
+		}
+		byID[theaterID].blocked = append(byID[theaterID].blocked, block)
+	}
+	return rows.Err()
+}
+
+// nextBlock รอบฉายเดิมรอบแรกที่ยังไม่จบ ณ นาที minute (nil = ไม่มีแล้ว)
+func (t *planTheater) nextBlock(minute int) *minuteRange {
+	for i := range t.blocked {
+		if t.blocked[i].end > minute {
+			return &t.blocked[i]
+		}
+	}
+	return nil
+}
+
+// nextPlanTheater โรงที่ว่างเร็วที่สุดที่ยังวางรอบต่อได้
+func nextPlanTheater(theaters []*planTheater) *planTheater {
+	var next *planTheater
+	for _, theater := range theaters {
+		if theater.done {
+			continue
+		}
+		if next == nil || theater.cursor < next.cursor {
+			next = theater
+		}
+	}
+	return next
+}
+
+// pickPlanMovie เลือกภาพยนตร์สำหรับรอบที่เริ่ม start ที่จบก่อน closes และทำความสะอาดเสร็จก่อนรอบเดิมถัดไป (nextBlock)
+// รอบต้องเริ่มภายในวันเดียวกับ show_date
+func pickPlanMovie(movies []*planMovie, start, trailer, cleaning, closes int, nextBlock *minuteRange, primeTime minuteRange, primeBoost float64) *planMovie {
+	if start >= 24*60 {
+		return nil
+	}
+
+	fits := func(movie *planMovie) bool {
+		end := start + trailer + movie.duration
+		if end > closes {
+			return false
+		}
+		return nextBlock == nil || end+cleaning <= nextBlock.start
+	}
+
+	underMinimum := false
+	for _, movie := range movies {
+		if movie.planned < movie.minShowings && fits(movie) {
+			underMinimum = true
+			break
+		}
+	}
+
+	boost := 1.0
+	if primeTime.contains(start) {
+		boost = primeBoost
+	}
+
+	var best *planMovie
+	bestScore := 0.0
+	for _, movie := range movies {
+		if !fits(movie) || (underMinimum && movie.planned >= movie.minShowings) {
+			continue
+		}
+		score := movie.weight * boost / float64(1+movie.planned)
+		if best == nil || score > bestScore ||
+			(score == bestScore && movie.duration > best.duration) {
+			best = movie
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// primeTimeWindow แปลงช่วง prime time เป็นนาที (ว่าง = 18:00-22:00)
+func primeTimeWindow(startTime, endTime string) (minuteRange, error) {
+	if startTime == "" {
+		startTime = defaultPrimeTimeStart
+	}
+	if endTime == "" {
+		endTime = defaultPrimeTimeEnd
+	}
+	start, err := time.Parse("15:04", startTime)
+	if err != nil {
+		return minuteRange{}, &ScheduleRequestError{Reason: "prime_time_start must be HH:MM"}
+	}
+	end, err := time.Parse("15:04", endTime)
+	if err != nil {
+		return minuteRange{}, &ScheduleRequestError{Reason: "prime_time_end must be HH:MM"}
+	}
+	window := minuteRange{start: start.Hour()*60 + start.Minute(), end: end.Hour()*60 + end.Minute()}
+	if window.end <= window.start {
+		window.end += 24 * 60
+	}
+	return window, nil
+}
+
+func (r minuteRange) contains(minute int) bool {
+	return minute >= r.start && minute < r.end
+}
+
+func roundUpMinutes(minute, step int) int {
+	return (minute + step - 1) / step * step
+}
+
+// formatMinutes แปลงนาทีนับจากเที่ยงคืนเป็น HH:MM (เกินวันวนกลับ เช่น 1530 = 01:30)
+func formatMinutes(minute int) string {
+	minute %= 24 * 60
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
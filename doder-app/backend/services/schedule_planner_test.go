@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+)
+
+func TestOpeningHoursFits(t *testing.T) {
+	daytime := &openingHours{opens: 10 * 60, closes: 23 * 60}
+	overnight := &openingHours{opens: 10 * 60, closes: 26 * 60}
+	tests := []struct {
+		name     string
+		hours    *openingHours
+		showTime string
+		endTime  string
+		want     bool
+	}{
+		{"within hours", daytime, "21:00", "23:00", true},
+		{"starts at opening", daytime, "10:00", "12:15", true},
+		{"starts before opening", daytime, "09:30", "11:45", false},
+		{"ends after closing", daytime, "21:30", "23:15", false},
+		{"ends past midnight before closing", overnight, "23:30", "01:45", true},
+		{"ends past closing", overnight, "23:30", "02:30", false},
+		{"starts after midnight", overnight, "00:30", "01:45", false},
+		{"invalid time", daytime, "25:00", "12:00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hours.fits(tt.showTime, tt.endTime); got != tt.want {
+				t.Errorf("fits(%s, %s) = %v, want %v", tt.showTime, tt.endTime, got, tt.want)
+			}
+		})
+	}
+}
+
+func plannedSlots(showtimes []models.PlannedShowtime) []string {
+	slots := make([]string, len(showtimes))
+	for i, showtime := range showtimes {
+		slots[i] = showtime.MovieTitle + " " + showtime.ShowTime + "-" + showtime.EndTime
+	}
+	return slots
+}
+
+func assertPlannedSlots(t *testing.T, got []models.PlannedShowtime, want ...string) {
+	t.Helper()
+	slots := plannedSlots(got)
+	if len(slots) != len(want) {
+		t.Fatalf("planned %v, want %v", slots, want)
+	}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("planned %v, want %v", slots, want)
+		}
+	}
+}
+
+// เรื่องที่ยังไม่ครบ min_showings ได้ก่อน จากนั้นเลือกตามคะแนน weight / (1 + รอบที่ได้แล้ว)
+func TestPlanShowtimesMinimumThenWeight(t *testing.T) {
+	theaters := []*planTheater{{theaterID: 1, cursor: 600}}
+	popular := &planMovie{movieID: 1, title: "A", duration: 100, weight: 3}
+	required := &planMovie{movieID: 2, title: "B", duration: 100, weight: 1, minShowings: 1}
+	primeTime := minuteRange{start: 18 * 60, end: 22 * 60}
+
+	// รอบละ 10 + 100 + ทำความสะอาด 20 นาที พอดี 3 รอบก่อนปิด 16:10
+	got := planShowtimes(theaters, []*planMovie{popular, required}, 10, 20, 970, primeTime, 1.5)
+	assertPlannedSlots(t, got, "B 10:00-11:50", "A 12:10-14:00", "A 14:20-16:10")
+	if popular.planned != 2 || required.planned != 1 {
+		t.Errorf("planned A %d B %d, want 2 and 1", popular.planned, required.planned)
+	}
+	if theaters[0].showings != 3 || theaters[0].idle != 0 {
+		t.Errorf("theater showings %d idle %d, want 3 and 0", theaters[0].showings, theaters[0].idle)
+	}
+}
+
+// รอบเดิมถูกเว้นไว้ ช่วงว่างที่ไม่มีเรื่องไหนพอดีนับเป็นเวลาว่าง และคะแนนเท่ากันเลือกเรื่องที่ยาวกว่า
+func TestPlanShowtimesAroundExistingShowtimes(t *testing.T) {
+	theater := &planTheater{theaterID: 1, cursor: 600, blocked: []minuteRange{{start: 700, end: 850}}}
+	short := &planMovie{movieID: 1, title: "Short", duration: 60, weight: 1}
+	long := &planMovie{movieID: 2, title: "Long", duration: 80, weight: 1}
+	primeTime := minuteRange{start: 14 * 60, end: 16 * 60}
+
+	got := planShowtimes([]*planTheater{theater}, []*planMovie{short, long}, 10, 20, 1000, primeTime, 1.5)
+	assertPlannedSlots(t, got, "Short 10:00-11:10", "Long 14:10-15:40")
+	if got[0].PrimeTime || !got[1].PrimeTime {
+		t.Errorf("prime time = %v/%v, want false/true", got[0].PrimeTime, got[1].PrimeTime)
+	}
+	// 10 นาทีก่อนรอบเดิม และ 40 นาทีก่อนปิด
+	if theater.showings != 2 || theater.idle != 50 {
+		t.Errorf("theater showings %d idle %d, want 2 and 50", theater.showings, theater.idle)
+	}
+}
+
+// โรงที่ว่างเร็วที่สุดได้วางก่อน ผลลัพธ์เรียงตามโรง
+func TestPlanShowtimesAcrossTheaters(t *testing.T) {
+	theaters := []*planTheater{{theaterID: 1, cursor: 600}, {theaterID: 2, cursor: 600}}
+	short := &planMovie{movieID: 1, title: "Short", duration: 60, weight: 1}
+	long := &planMovie{movieID: 2, title: "Long", duration: 80, weight: 1}
+
+	got := planShowtimes(theaters, []*planMovie{short, long}, 10, 20, 710, minuteRange{}, 1.5)
+	assertPlannedSlots(t, got, "Long 10:00-11:30", "Short 10:00-11:10")
+	if got[0].TheaterID != 1 || got[1].TheaterID != 2 {
+		t.Errorf("theaters = %d, %d, want 1, 2", got[0].TheaterID, got[1].TheaterID)
+	}
+	if theaters[1].idle != 20 {
+		t.Errorf("theater 2 idle = %d, want 20", theaters[1].idle)
+	}
+}
+
+// รอบที่เริ่มก่อนเวลาเปิดของสาขาถูกปฏิเสธและไม่มีรอบใดถูกสร้าง
+func TestAcceptPlanRejectsShowtimesOutsideOpeningHours(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	showtimeID, _ := createShowtimeFixture(t, db, 1)
+
+	var cinemaID, theaterID, movieID int
+	err := db.QueryRow(`
+		SELECT t.cinema_id, s.theater_id, s.movie_id
+		FROM showtimes s JOIN theaters t ON t.theater_id = s.theater_id
+		WHERE s.showtime_id = $1
+	`, showtimeID).Scan(&cinemaID, &theaterID, &movieID)
+	if err != nil {
+		t.Fatalf("fetch showtime: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM showtimes WHERE theater_id = $1", theaterID) })
+
+	service := NewShowtimeService(db, 15*time.Minute, 15*time.Minute)
+	showDate := time.Now().AddDate(0, 0, 31).Format("2006-01-02")
+	// สาขาเปิด 10:00 (ค่าเริ่มต้น)
+	_, err = service.AcceptPlan(ctx, cinemaID, models.AcceptSchedulePlanRequest{
+		ShowDate: showDate,
+		Price:    180,
+		Showtimes: []models.AcceptPlanShowtime{
+			{TheaterID: theaterID, MovieID: movieID, ShowTime: "12:00"},
+			{TheaterID: theaterID, MovieID: movieID, ShowTime: "08:00"},
+		},
+	})
+	var scheduleErr *ScheduleRequestError
+	if !errors.As(err, &scheduleErr) {
+		t.Fatalf("accept plan error = %v, want ScheduleRequestError", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM showtimes WHERE theater_id = $1", theaterID).Scan(&count); err != nil {
+		t.Fatalf("count showtimes: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("showtimes = %d, want only the fixture", count)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"movie-booking-system/models"
+)
+
+// ReconcileAvailableSeats หารอบฉายที่ available_seats ไม่ตรงกับ showtime_available_seats() และแก้ให้ตรง
+// ถ้า dryRun จะรายงานอย่างเดียว แต่ละรอบฉายแก้ใน transaction ของตัวเองหลังล็อกแถว showtimes
+// (trigger ของ seat_status ล็อกแถวเดียวกัน จึงนับใหม่หลังการจองที่ค้างอยู่ commit แล้วเสมอ)
+func (s *BookingService) ReconcileAvailableSeats(ctx context.Context, dryRun bool) ([]models.SeatCountDrift, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT showtime_id FROM showtimes
+		WHERE available_seats <> showtime_available_seats(showtime_id)
+		ORDER BY showtime_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("find drifted showtimes: %w", err)
+	}
+	var showtimeIDs []int
+	for rows.Next() {
+		var showtimeID int
+		if err := rows.Scan(&showtimeID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan showtime: %w", err)
+		}
+		showtimeIDs = append(showtimeIDs, showtimeID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("find drifted showtimes: %w", err)
+	}
+
+	drifts := []models.SeatCountDrift{}
+	for _, showtimeID := range showtimeIDs {
+		drift, err := s.reconcileShowtimeSeats(ctx, showtimeID, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("reconcile showtime %d: %w", showtimeID, err)
+		}
+		if drift != nil {
+			drifts = append(drifts, *drift)
+		}
+	}
+	return drifts, nil
+}
+
+// reconcileShowtimeSeats นับที่นั่งว่างของรอบฉายใหม่ คืน nil ถ้าค่าตรงกันแล้ว (เช่นการจองที่ค้างอยู่ commit ไปก่อน)
+func (s *BookingService) reconcileShowtimeSeats(ctx context.Context, showtimeID int, dryRun bool) (*models.SeatCountDrift, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	drift := models.SeatCountDrift{ShowtimeID: showtimeID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT available_seats FROM showtimes WHERE showtime_id = $1 FOR UPDATE
+	`, showtimeID).Scan(&drift.StoredSeats)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// แยก statement จาก SELECT ... FOR UPDATE เพื่อให้เห็นข้อมูลที่ commit ระหว่างรอล็อก
+	err = tx.QueryRowContext(ctx, `SELECT showtime_available_seats($1)`, showtimeID).Scan(&drift.ActualSeats)
+	if err != nil {
+		return nil, err
+	}
+	if drift.StoredSeats == drift.ActualSeats {
+		return nil, nil
+	}
+	if dryRun {
+		return &drift, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE showtimes SET available_seats = $1, updated_at = CURRENT_TIMESTAMP WHERE showtime_id = $2
+	`, drift.ActualSeats, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	drift.Fixed = true
+	return &drift, nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"time"
+
+	"movie-booking-system/events"
+)
+
+// seatEvents เก็บการเปลี่ยนสถานะที่นั่งที่เกิดใน transaction
+// ต้องเรียก publish หลัง commit เท่านั้น เพื่อไม่ให้ client เห็นการเปลี่ยนแปลงที่ถูก rollback
+type seatEvents struct {
+	events []events.SeatEvent
+}
+
+func (e *seatEvents) add(showtimeID int, status string, seatIDs []int) {
+	if len(seatIDs) == 0 {
+		return
+	}
+	e.events = append(e.events, events.SeatEvent{
+		ShowtimeID: showtimeID,
+		SeatIDs:    seatIDs,
+		Status:     status,
+		At:         time.Now(),
+	})
+}
+
+func (e *seatEvents) publish(broker events.Broker) {
+	for _, event := range e.events {
+		broker.Publish(event)
+	}
+	e.events = nil
+}
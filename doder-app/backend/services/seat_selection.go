@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"movie-booking-system/events"
+
+	"github.com/lib/pq"
+)
+
+// MaxSelectedSeats จำนวนที่นั่งที่หนึ่ง session เลือกค้างไว้ได้พร้อมกัน
+const MaxSelectedSeats = 10
+
+var ErrTooManySeatsSelected = errors.New("too many seats selected")
+
+// heldSelection ที่นั่งที่ session หนึ่งกำลังเลือกอยู่ (ยังไม่ได้จอง)
+type heldSelection struct {
+	sessionID string
+	userID    int
+	expiresAt time.Time
+}
+
+// SeatSelections ล็อกชั่วคราวสถานะ "selecting" ขณะลูกค้ากำลังเลือกที่นั่งผ่าน WebSocket
+// เก็บในหน่วยความจำเท่านั้น (ไม่บันทึกใน seat_status) หมดอายุตาม TTL หรือเมื่อ session หลุด
+// ถ้ามีหลาย replica แต่ละ replica จะเห็นเฉพาะ selection ของตัวเอง เช่นเดียวกับ MemoryBroker
+type SeatSelections struct {
+	broker events.Broker
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	seats map[int]map[int]*heldSelection // showtime_id -> seat_id -> selection
+}
+
+func NewSeatSelections(broker events.Broker, ttl time.Duration) *SeatSelections {
+	return &SeatSelections{broker: broker, ttl: ttl, seats: map[int]map[int]*heldSelection{}}
+}
+
+// TTL อายุของ selection นับจากครั้งล่าสุดที่ session ต่ออายุ
+func (s *SeatSelections) TTL() time.Duration {
+	return s.ttl
+}
+
+// Select เลือกที่นั่งให้ session คืน seat_id ที่ session อื่นเลือกอยู่ (เลือกไม่สำเร็จ)
+func (s *SeatSelections) Select(showtimeID int, sessionID string, userID int, seatIDs []int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	held := s.seats[showtimeID]
+	if held == nil {
+		held = map[int]*heldSelection{}
+		s.seats[showtimeID] = held
+	}
+
+	owned := 0
+	for _, sel := range held {
+		if sel.sessionID == sessionID && sel.expiresAt.After(now) {
+			owned++
+		}
+	}
+
+	// ตรวจจำนวนที่นั่งก่อนแก้ไข selection เพื่อไม่ให้บันทึกที่นั่งบางส่วนไว้โดยไม่ส่ง event
+	taken := []int{}
+	renewed := []*heldSelection{}
+	selected := []int{}
+	for _, seatID := range uniqueSeatIDs(seatIDs) {
+		sel, ok := held[seatID]
+		if ok && sel.expiresAt.After(now) {
+			if sel.sessionID != sessionID {
+				taken = append(taken, seatID)
+			} else {
+				renewed = append(renewed, sel)
+			}
+			continue
+		}
+		selected = append(selected, seatID)
+	}
+	if owned+len(selected) > MaxSelectedSeats {
+		s.cleanupLocked(showtimeID)
+		return taken, ErrTooManySeatsSelected
+	}
+
+	expiresAt := now.Add(s.ttl)
+	for _, sel := range renewed {
+		sel.expiresAt = expiresAt
+	}
+	for _, seatID := range selected {
+		held[seatID] = &heldSelection{sessionID: sessionID, userID: userID, expiresAt: expiresAt}
+	}
+
+	s.publish(showtimeID, events.SeatSelecting, selected)
+	return taken, nil
+}
+
+// Deselect ยกเลิกการเลือกที่นั่งของ session
+func (s *SeatSelections) Deselect(showtimeID int, sessionID string, seatIDs []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	held := s.seats[showtimeID]
+	released := []int{}
+	for _, seatID := range seatIDs {
+		if sel, ok := held[seatID]; ok && sel.sessionID == sessionID {
+			delete(held, seatID)
+			released = append(released, seatID)
+		}
+	}
+	s.cleanupLocked(showtimeID)
+	s.publish(showtimeID, events.SeatDeselected, released)
+}
+
+// Renew ต่ออายุทุกที่นั่งที่ session เลือกอยู่
+func (s *SeatSelections) Renew(showtimeID int, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(s.ttl)
+	for _, sel := range s.seats[showtimeID] {
+		if sel.sessionID == sessionID {
+			sel.expiresAt = expiresAt
+		}
+	}
+}
+
+// ReleaseSession ปล่อยทุกที่นั่งของ session (เรียกเมื่อ WebSocket หลุด)
+func (s *SeatSelections) ReleaseSession(showtimeID int, sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	released := []int{}
+	for seatID, sel := range s.seats[showtimeID] {
+		if sel.sessionID == sessionID {
+			delete(s.seats[showtimeID], seatID)
+			released = append(released, seatID)
+		}
+	}
+	s.cleanupLocked(showtimeID)
+	s.publish(showtimeID, events.SeatDeselected, released)
+}
+
+// Selecting seat_id ที่กำลังถูกเลือกในรอบฉาย (สำหรับแสดงผังที่นั่ง)
+func (s *SeatSelections) Selecting(showtimeID int) map[int]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	selecting := map[int]bool{}
+	for seatID, sel := range s.seats[showtimeID] {
+		if sel.expiresAt.After(now) {
+			selecting[seatID] = true
+		}
+	}
+	return selecting
+}
+
+// HeldByOthers seat_id ที่ผู้ใช้อื่นกำลังเลือกอยู่ (ใช้ตอนจองเพื่อเคารพล็อกชั่วคราว)
+func (s *SeatSelections) HeldByOthers(showtimeID, userID int, seatIDs []int) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	held := []int{}
+	for _, seatID := range seatIDs {
+		if sel, ok := s.seats[showtimeID][seatID]; ok && sel.userID != userID && sel.expiresAt.After(now) {
+			held = append(held, seatID)
+		}
+	}
+	return held
+}
+
+// consume ลบ selection ของผู้ใช้ที่เพิ่งจองสำเร็จ ไม่ส่ง event เพราะ event reserved แทนที่แล้ว
+func (s *SeatSelections) consume(showtimeID, userID int, seatIDs []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, seatID := range seatIDs {
+		if sel, ok := s.seats[showtimeID][seatID]; ok && sel.userID == userID {
+			delete(s.seats[showtimeID], seatID)
+		}
+	}
+	s.cleanupLocked(showtimeID)
+}
+
+// Run ล้าง selection ที่หมดอายุเป็นระยะจนกว่า ctx จะถูกยกเลิก
+func (s *SeatSelections) Run(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.expire(now)
+		}
+	}
+}
+
+func (s *SeatSelections) expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for showtimeID, held := range s.seats {
+		expired := []int{}
+		for seatID, sel := range held {
+			if !sel.expiresAt.After(now) {
+				delete(held, seatID)
+				expired = append(expired, seatID)
+			}
+		}
+		s.cleanupLocked(showtimeID)
+		s.publish(showtimeID, events.SeatDeselected, expired)
+	}
+}
+
+func (s *SeatSelections) cleanupLocked(showtimeID int) {
+	if len(s.seats[showtimeID]) == 0 {
+		delete(s.seats, showtimeID)
+	}
+}
+
+func (s *SeatSelections) publish(showtimeID int, status string, seatIDs []int) {
+	if len(seatIDs) == 0 {
+		return
+	}
+	sort.Ints(seatIDs)
+	s.broker.Publish(events.SeatEvent{ShowtimeID: showtimeID, SeatIDs: seatIDs, Status: status, At: time.Now()})
+}
+
+// SelectSeats ตรวจว่าที่นั่งอยู่ในโรงของรอบฉายและยังว่างอยู่ แล้วล็อกชั่วคราวเป็น "selecting"
+// คืน seat_id ที่เลือกไม่ได้ (ถูกจองแล้วหรือมีคนอื่นเลือกอยู่)
+func (s *BookingService) SelectSeats(ctx context.Context, showtimeID int, sessionID string, userID int, seatIDs []int) ([]int, error) {
+	seatIDs = uniqueSeatIDs(seatIDs)
+	if len(seatIDs) == 0 {
+		return []int{}, nil
+	}
+
+	// ที่นั่งต้องอยู่ในโรงของรอบฉาย และไม่มีใครถือ/จองใน seat_status
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT se.seat_id, COALESCE(ss.status, 'available')
+		FROM showtimes st
+		JOIN seats se ON se.theater_id = st.theater_id AND se.is_active = TRUE
+		LEFT JOIN seat_status ss ON ss.showtime_id = st.showtime_id AND ss.seat_id = se.seat_id
+		WHERE st.showtime_id = $1 AND st.is_active = TRUE AND se.seat_id = ANY($2)
+	`, showtimeID, pq.Array(seatIDs))
+	if err != nil {
+		return nil, fmt.Errorf("check seats: %w", err)
+	}
+	defer rows.Close()
+
+	available := map[int]bool{}
+	found := 0
+	for rows.Next() {
+		var seatID int
+		var status string
+		if err := rows.Scan(&seatID, &status); err != nil {
+			return nil, fmt.Errorf("scan seat: %w", err)
+		}
+		found++
+		available[seatID] = status == "available"
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("check seats: %w", err)
+	}
+	if found != len(seatIDs) {
+		return nil, ErrInvalidSeats
+	}
+
+	unavailable := []int{}
+	selectable := []int{}
+	for _, seatID := range seatIDs {
+		if available[seatID] {
+			selectable = append(selectable, seatID)
+		} else {
+			unavailable = append(unavailable, seatID)
+		}
+	}
+
+	taken, err := s.selections.Select(showtimeID, sessionID, userID, selectable)
+	return append(unavailable, taken...), err
+}
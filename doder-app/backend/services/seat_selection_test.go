@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"movie-booking-system/events"
+)
+
+// recordingBroker เก็บ event ที่ publish ไว้ตรวจในเทสต์
+type recordingBroker struct {
+	published []events.SeatEvent
+}
+
+func (b *recordingBroker) Publish(event events.SeatEvent) {
+	b.published = append(b.published, event)
+}
+
+func (b *recordingBroker) Subscribe(showtimeID int) (<-chan events.SeatEvent, func()) {
+	ch := make(chan events.SeatEvent)
+	close(ch)
+	return ch, func() {}
+}
+
+func (b *recordingBroker) Close() {}
+
+func (b *recordingBroker) statuses() []string {
+	statuses := []string{}
+	for _, event := range b.published {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+func TestSeatSelectionsRenewsOwnSeats(t *testing.T) {
+	broker := &recordingBroker{}
+	selections := NewSeatSelections(broker, time.Minute)
+
+	if _, err := selections.Select(1, "a", 10, []int{1, 2}); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	stale := time.Now().Add(time.Second)
+	for _, sel := range selections.seats[1] {
+		sel.expiresAt = stale
+	}
+
+	// เลือกที่นั่งเดิมซ้ำ ต่ออายุโดยไม่ส่ง event ใหม่
+	taken, err := selections.Select(1, "a", 10, []int{1})
+	if err != nil || len(taken) != 0 {
+		t.Fatalf("reselect = %v, %v, want no taken seats", taken, err)
+	}
+	if !selections.seats[1][1].expiresAt.After(stale) {
+		t.Error("reselected seat was not renewed")
+	}
+	if len(broker.published) != 1 {
+		t.Errorf("published %d events, want 1", len(broker.published))
+	}
+
+	selections.Renew(1, "a")
+	if !selections.seats[1][2].expiresAt.After(stale) {
+		t.Error("Renew did not extend the session's other seats")
+	}
+}
+
+func TestSeatSelectionsMaxSelectedSeats(t *testing.T) {
+	broker := &recordingBroker{}
+	selections := NewSeatSelections(broker, time.Minute)
+
+	first := []int{}
+	for seatID := 1; seatID < MaxSelectedSeats; seatID++ {
+		first = append(first, seatID)
+	}
+	if _, err := selections.Select(1, "a", 10, first); err != nil {
+		t.Fatalf("select %d seats: %v", len(first), err)
+	}
+
+	// เกินจำนวนที่กำหนด ต้องไม่บันทึกที่นั่งใดเลย
+	_, err := selections.Select(1, "a", 10, []int{100, 101})
+	if !errors.Is(err, ErrTooManySeatsSelected) {
+		t.Fatalf("select over limit error = %v, want %v", err, ErrTooManySeatsSelected)
+	}
+	if selecting := selections.Selecting(1); selecting[100] || selecting[101] {
+		t.Errorf("seats were recorded after the limit error: %v", selecting)
+	}
+	if len(broker.published) != 1 {
+		t.Errorf("published %d events, want 1", len(broker.published))
+	}
+
+	// ที่นั่งที่เลือกไว้แล้วไม่นับซ้ำ
+	if _, err := selections.Select(1, "a", 10, []int{1, 100}); err != nil {
+		t.Fatalf("select up to the limit: %v", err)
+	}
+	if got := len(selections.Selecting(1)); got != MaxSelectedSeats {
+		t.Errorf("selecting %d seats, want %d", got, MaxSelectedSeats)
+	}
+
+	// รอบฉายที่ยังไม่มี selection ต้องไม่ค้างอยู่ใน map หลังถูกปฏิเสธ
+	many := []int{}
+	for seatID := 1; seatID <= MaxSelectedSeats+1; seatID++ {
+		many = append(many, seatID)
+	}
+	if _, err := selections.Select(3, "a", 10, many); !errors.Is(err, ErrTooManySeatsSelected) {
+		t.Fatalf("select %d seats error = %v, want %v", len(many), err, ErrTooManySeatsSelected)
+	}
+	if _, ok := selections.seats[3]; ok {
+		t.Error("rejected selection left an empty showtime entry")
+	}
+}
+
+func TestSeatSelectionsTakenByOtherSession(t *testing.T) {
+	broker := &recordingBroker{}
+	selections := NewSeatSelections(broker, time.Minute)
+
+	if _, err := selections.Select(1, "a", 10, []int{1}); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	taken, err := selections.Select(1, "b", 20, []int{1, 2})
+	if err != nil {
+		t.Fatalf("select from other session: %v", err)
+	}
+	if !reflect.DeepEqual(taken, []int{1}) {
+		t.Errorf("taken = %v, want [1]", taken)
+	}
+	if got := selections.HeldByOthers(1, 20, []int{1, 2}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("HeldByOthers(user 20) = %v, want [1]", got)
+	}
+	if got := selections.HeldByOthers(1, 10, []int{1, 2}); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("HeldByOthers(user 10) = %v, want [2]", got)
+	}
+
+	// selection ที่หมดอายุแล้ว session อื่นเลือกต่อได้
+	selections.seats[1][1].expiresAt = time.Now().Add(-time.Second)
+	taken, err = selections.Select(1, "b", 20, []int{1})
+	if err != nil || len(taken) != 0 {
+		t.Fatalf("select expired seat = %v, %v, want no taken seats", taken, err)
+	}
+	if sel := selections.seats[1][1]; sel.sessionID != "b" {
+		t.Errorf("seat 1 held by session %s, want b", sel.sessionID)
+	}
+}
+
+func TestSeatSelectionsExpire(t *testing.T) {
+	broker := &recordingBroker{}
+	selections := NewSeatSelections(broker, time.Minute)
+
+	if _, err := selections.Select(1, "a", 10, []int{2, 1}); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	selections.expire(time.Now())
+	if len(broker.published) != 1 {
+		t.Fatalf("expire before TTL published %d events, want 1", len(broker.published))
+	}
+
+	selections.expire(time.Now().Add(time.Minute))
+	if _, ok := selections.seats[1]; ok {
+		t.Error("expired showtime was not cleaned up")
+	}
+	if got := broker.statuses(); !reflect.DeepEqual(got, []string{events.SeatSelecting, events.SeatDeselected}) {
+		t.Fatalf("published statuses = %v", got)
+	}
+	if got := broker.published[1].SeatIDs; !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("deselected seats = %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+)
+
+// ErrShowtimeCancellationIncomplete ปิดรอบฉายแล้วแต่ยังยกเลิกบางการจองไม่สำเร็จ (ดู FailedBookings แล้วเรียกยกเลิกซ้ำ)
+var ErrShowtimeCancellationIncomplete = errors.New("some bookings of the cancelled showtime could not be cancelled")
+
+// cancelledShowtime ข้อมูลรอบฉายที่ใช้ในข้อความแจ้งเตือน
+type cancelledShowtime struct {
+	showtimeID int
+	movieTitle string
+	showDate   string
+	showTime   string
+	reason     *string
+}
+
+// CancellationImpact สรุปการจอง ที่นั่ง ยอดเงินที่ต้องคืน และผู้ใช้ที่ได้รับผลกระทบถ้ายกเลิกรอบฉาย
+func (s *BookingService) CancellationImpact(ctx context.Context, showtimeID int) (*models.ShowtimeCancellationImpact, error) {
+	impact := &models.ShowtimeCancellationImpact{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT s.showtime_id, m.title, to_char(s.show_date, 'YYYY-MM-DD'), to_char(s.show_time, 'HH24:MI'), s.is_active
+		FROM showtimes s
+		JOIN movies m ON s.movie_id = m.movie_id
+		WHERE s.showtime_id = $1
+	`, showtimeID).Scan(&impact.ShowtimeID, &impact.MovieTitle, &impact.ShowDate, &impact.ShowTime, &impact.IsActive)
+	if err == sql.ErrNoRows {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		WITH active_bookings AS (
+			SELECT booking_id, user_id, booking_status FROM bookings
+			WHERE showtime_id = $1 AND booking_status IN ('pending', 'confirmed')
+		)
+		SELECT
+			(SELECT COUNT(*) FROM active_bookings WHERE booking_status = 'pending'),
+			(SELECT COUNT(*) FROM active_bookings WHERE booking_status = 'confirmed'),
+			(SELECT COUNT(*) FROM booking_seats bs JOIN active_bookings ab ON bs.booking_id = ab.booking_id),
+			(SELECT COALESCE(SUM(p.amount), 0) FROM payments p JOIN active_bookings ab ON p.booking_id = ab.booking_id WHERE p.status = $2),
+			(SELECT COUNT(*) FROM waitlist_entries WHERE showtime_id = $1 AND status = 'waiting'),
+			(SELECT COUNT(DISTINCT user_id) FROM (
+				SELECT user_id FROM active_bookings
+				UNION SELECT bsh.user_id FROM booking_shares bsh JOIN active_bookings ab ON bsh.booking_id = ab.booking_id
+				UNION SELECT user_id FROM waitlist_entries WHERE showtime_id = $1 AND status = 'waiting'
+			) affected)
+	`, showtimeID, payments.StatusCaptured).Scan(
+		&impact.PendingBookings, &impact.ConfirmedBookings, &impact.Seats,
+		&impact.RefundAmount, &impact.WaitlistEntries, &impact.AffectedUsers,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("summarize cancellation impact: %w", err)
+	}
+	return impact, nil
+}
+
+// CancelShowtime ปิดรอบฉาย ยกเลิกคิวรอที่นั่ง แล้วยกเลิกทุกการจองที่ยัง pending หรือ confirmed
+// แต่ละการจองยกเลิกใน transaction ของตัวเอง เพื่อไม่ให้การคืนเงินที่ส่งให้ payment gateway ไปแล้วถูก rollback
+// เมื่อการจองอื่นล้มเหลว การจองที่ล้มเหลวจะอยู่ใน FailedBookings พร้อม ErrShowtimeCancellationIncomplete
+// เรียกซ้ำกับรอบฉายที่ปิดไปแล้วได้ จะยกเลิกเฉพาะการจองที่ยังค้างอยู่
+// การจองใหม่ล็อกแถวรอบฉาย (FOR KEY SHARE) จึงเสร็จก่อน closeShowtime หรือเห็นว่ารอบฉายปิดแล้ว ไล่ยกเลิกรอบเดียวพอ
+func (s *BookingService) CancelShowtime(ctx context.Context, showtimeID int, reason *string) (*models.ShowtimeCancellation, error) {
+	showtime, waitlistUsers, err := s.closeShowtime(ctx, showtimeID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ShowtimeCancellation{
+		ShowtimeID:        showtimeID,
+		Reason:            reason,
+		CancelledBookings: []models.CancelledBooking{},
+		FailedBookings:    []models.FailedCancellation{},
+		WaitlistCancelled: len(waitlistUsers),
+	}
+	notifiedUsers := map[int]bool{}
+	for _, userID := range waitlistUsers {
+		notifiedUsers[userID] = true
+	}
+
+	bookingIDs, err := s.activeShowtimeBookings(ctx, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	for _, bookingID := range bookingIDs {
+		booking, userIDs, err := s.cancelShowtimeBooking(ctx, showtime, bookingID)
+		if err != nil {
+			log.Printf("Cancel booking %d of cancelled showtime %d error: %v", bookingID, showtimeID, err)
+			result.FailedBookings = append(result.FailedBookings, models.FailedCancellation{
+				BookingID: bookingID,
+				Reason:    err.Error(),
+			})
+			continue
+		}
+		if booking == nil {
+			continue
+		}
+		result.CancelledBookings = append(result.CancelledBookings, *booking)
+		result.SeatsReleased += booking.Seats
+		result.RefundedAmount += booking.RefundedAmount
+		for _, userID := range userIDs {
+			notifiedUsers[userID] = true
+		}
+	}
+	result.NotifiedUsers = len(notifiedUsers)
+
+	if len(result.FailedBookings) > 0 {
+		return result, ErrShowtimeCancellationIncomplete
+	}
+	return result, nil
+}
+
+// closeShowtime ล็อกและปิดรอบฉาย (การจองใหม่จะไม่ผ่านการตรวจ is_active) ยกเลิกคิวที่ยังรอและแจ้งผู้ใช้ในคิว
+// คืนข้อมูลรอบฉายและ user_id ของคิวที่ถูกยกเลิก
+func (s *BookingService) closeShowtime(ctx context.Context, showtimeID int, reason *string) (*cancelledShowtime, []int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	showtime := &cancelledShowtime{showtimeID: showtimeID, reason: reason}
+	err = tx.QueryRowContext(ctx, `
+		SELECT m.title, to_char(s.show_date, 'YYYY-MM-DD'), to_char(s.show_time, 'HH24:MI')
+		FROM showtimes s
+		JOIN movies m ON s.movie_id = m.movie_id
+		WHERE s.showtime_id = $1
+		FOR UPDATE OF s
+	`, showtimeID).Scan(&showtime.movieTitle, &showtime.showDate, &showtime.showTime)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("lock showtime: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE showtimes SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP
+		WHERE showtime_id = $1 AND is_active = TRUE
+	`, showtimeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("deactivate showtime: %w", err)
+	}
+
+	// คิวที่ได้ที่นั่งแล้ว (offered) ถูกปิดพร้อมการจองที่ถือที่นั่งไว้ใน cancelShowtimeBooking
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE waitlist_entries SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE showtime_id = $1 AND status = 'waiting'
+		RETURNING entry_id, user_id
+	`, showtimeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cancel waitlist entries: %w", err)
+	}
+	type cancelledEntry struct {
+		entryID int
+		userID  int
+	}
+	entries := []cancelledEntry{}
+	for rows.Next() {
+		var entry cancelledEntry
+		if err := rows.Scan(&entry.entryID, &entry.userID); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan waitlist entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("cancel waitlist entries: %w", err)
+	}
+
+	userIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		err := notify(ctx, tx, entry.userID, "showtime_cancelled", "รอบฉายถูกยกเลิก",
+			showtime.announcement()+" คุณถูกนำออกจากรายชื่อรอที่นั่งแล้ว",
+			showtime.notificationData(map[string]interface{}{"entry_id": entry.entryID}))
+		if err != nil {
+			return nil, nil, err
+		}
+		userIDs = append(userIDs, entry.userID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return showtime, userIDs, nil
+}
+
+// activeShowtimeBookings หา booking_id ที่ยัง pending หรือ confirmed ของรอบฉาย
+func (s *BookingService) activeShowtimeBookings(ctx context.Context, showtimeID int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT booking_id FROM bookings
+		WHERE showtime_id = $1 AND booking_status IN ('pending', 'confirmed')
+		ORDER BY booking_id
+	`, showtimeID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime bookings: %w", err)
+	}
+	defer rows.Close()
+
+	bookingIDs := []int{}
+	for rows.Next() {
+		var bookingID int
+		if err := rows.Scan(&bookingID); err != nil {
+			return nil, fmt.Errorf("scan booking: %w", err)
+		}
+		bookingIDs = append(bookingIDs, bookingID)
+	}
+	return bookingIDs, rows.Err()
+}
+
+// cancelShowtimeBooking ยกเลิกการจองหนึ่งรายการของรอบฉายที่ถูกยกเลิก: คืนเงินที่เก็บแล้ว ปิดคำขอคืนเงินที่ยังรอพิจารณา
+// คืนที่นั่งและสต็อกสินค้าหน้าโรง
+// และแจ้งเจ้าของการจองกับทุกคนที่ร่วมจ่าย ที่นั่งที่คืนไม่ถูกส่งต่อให้คิวรอที่นั่งเพราะรอบฉายถูกปิดแล้ว
+// คืนค่า nil ถ้าการจองไม่ได้ค้างอยู่แล้ว (ถูกยกเลิกหรือหมดเวลาไปก่อน) พร้อม user_id ที่ได้รับแจ้ง
+func (s *BookingService) cancelShowtimeBooking(ctx context.Context, showtime *cancelledShowtime, bookingID int) (*models.CancelledBooking, []int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	booking := &models.CancelledBooking{BookingID: bookingID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT booking_code, user_id, booking_status FROM bookings
+		WHERE booking_id = $1 AND showtime_id = $2
+		FOR UPDATE
+	`, bookingID, showtime.showtimeID).Scan(&booking.BookingCode, &booking.UserID, &booking.PreviousStatus)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("lock booking: %w", err)
+	}
+	if booking.PreviousStatus != "pending" && booking.PreviousStatus != "confirmed" {
+		return nil, nil, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM booking_seats WHERE booking_id = $1),
+			(SELECT COALESCE(SUM(amount), 0) FROM payments WHERE booking_id = $1 AND status = $2)
+	`, bookingID, payments.StatusCaptured).Scan(&booking.Seats, &booking.RefundedAmount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("summarize booking: %w", err)
+	}
+
+	// บันทึกการคืนเงินไว้ก่อน แล้วส่งให้ผู้ให้บริการหลัง commit
+	refunds := &pendingRefunds{}
+	captured, err := markCapturedPaymentsRefunding(ctx, tx, bookingID, fmt.Sprintf("booking-cancel-%d", bookingID))
+	if err != nil {
+		return nil, nil, err
+	}
+	refunds.add(captured...)
+	paymentStatus := ""
+	if len(captured) > 0 {
+		paymentStatus = "refunded"
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bookings
+		SET booking_status = 'cancelled', payment_status = COALESCE(NULLIF($2, ''), payment_status), updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1
+	`, bookingID, paymentStatus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cancel booking: %w", err)
+	}
+	refundIDs, err := closePendingRefundRequests(ctx, tx, bookingID, len(captured) > 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seatChanges := &seatEvents{}
+	if err := releaseSeats(ctx, tx, bookingID, showtime.showtimeID, seatChanges); err != nil {
+		return nil, nil, err
+	}
+	if err := restockConcessions(ctx, tx, bookingID); err != nil {
+		return nil, nil, err
+	}
+	if err := closeWaitlistOffer(ctx, tx, bookingID, "cancelled"); err != nil {
+		return nil, nil, err
+	}
+
+	userIDs, err := bookingUserIDs(ctx, tx, bookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	message := fmt.Sprintf("%s การจอง %s ของคุณถูกยกเลิกแล้ว", showtime.announcement(), booking.BookingCode)
+	if booking.RefundedAmount > 0 {
+		message += " เงินที่ชำระแล้วจะถูกคืนไปยังช่องทางที่ชำระ"
+	}
+	data := showtime.notificationData(map[string]interface{}{
+		"booking_id":   bookingID,
+		"booking_code": booking.BookingCode,
+		"refunded":     len(captured) > 0,
+	})
+	for _, userID := range userIDs {
+		if err := notify(ctx, tx, userID, "showtime_cancelled", "รอบฉายถูกยกเลิก", message, data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	seatChanges.publish(s.broker)
+	s.sendRefunds(ctx, refunds)
+	// คืนเงินไม่สำเร็จคำขอยังเป็น refunding และถูกปิดโดย RetryRefunds
+	for _, refundID := range refundIDs {
+		if _, err := completeRefundRequests(ctx, s.db, &refundID); err != nil {
+			log.Printf("Failed to mark refund %d refunded: %v", refundID, err)
+		}
+	}
+	return booking, userIDs, nil
+}
+
+// closePendingRefundRequests ปิดคำขอคืนเงินที่ยังรอพิจารณาของการจองที่ถูกยกเลิกพร้อมรอบฉาย
+// refunding = มีเงินที่กำลังคืนตามการยกเลิก (คำขอเป็น refunding แล้วเป็น refunded เมื่อคืนเสร็จ)
+// ไม่มีเงินให้คืนคำขอเป็น rejected คืน refund_id ที่ถูกปิด
+func closePendingRefundRequests(ctx context.Context, tx *sql.Tx, bookingID int, refunding bool) ([]int, error) {
+	status, note := "rejected", "Showtime cancelled, no captured payment to refund"
+	if refunding {
+		status, note = "refunding", "Showtime cancelled, payment refunded with the cancellation"
+	}
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE refund_requests
+		SET status = $2, admin_note = $3, reviewed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $1 AND status = 'pending'
+		RETURNING refund_id
+	`, bookingID, status, note)
+	if err != nil {
+		return nil, fmt.Errorf("close refund requests: %w", err)
+	}
+	defer rows.Close()
+
+	refundIDs := []int{}
+	for rows.Next() {
+		var refundID int
+		if err := rows.Scan(&refundID); err != nil {
+			return nil, fmt.Errorf("scan refund request: %w", err)
+		}
+		refundIDs = append(refundIDs, refundID)
+	}
+	return refundIDs, rows.Err()
+}
+
+// bookingUserIDs คืน user_id ของเจ้าของการจองและทุกคนที่มีส่วนในการจองแบบกลุ่ม
+func bookingUserIDs(ctx context.Context, tx *sql.Tx, bookingID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id FROM bookings WHERE booking_id = $1
+		UNION
+		SELECT user_id FROM booking_shares WHERE booking_id = $1
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch booking users: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scan booking user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// announcement ข้อความแจ้งว่ารอบฉายถูกยกเลิก พร้อมเหตุผลถ้ามี
+func (st *cancelledShowtime) announcement() string {
+	message := fmt.Sprintf("รอบฉาย %s วันที่ %s เวลา %s ถูกยกเลิก", st.movieTitle, st.showDate, st.showTime)
+	if st.reason != nil && *st.reason != "" {
+		message += fmt.Sprintf(" (%s)", *st.reason)
+	}
+	return message
+}
+
+// notificationData ข้อมูลรอบฉายที่แนบไปกับการแจ้งเตือน showtime_cancelled
+func (st *cancelledShowtime) notificationData(extra map[string]interface{}) map[string]interface{} {
+	data := map[string]interface{}{
+		"showtime_id": st.showtimeID,
+		"movie_title": st.movieTitle,
+		"show_date":   st.showDate,
+		"show_time":   st.showTime,
+	}
+	if st.reason != nil {
+		data["reason"] = *st.reason
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+	return data
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+	"movie-booking-system/payments"
+)
+
+// ยกเลิกรอบฉาย: การจองที่จ่ายแล้วถูกคืนเงิน คำขอคืนเงินที่ค้างถูกปิด และจองรอบที่ปิดแล้วไม่ได้
+func TestCancelShowtimeClosesPendingRefundRequests(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	bookings := newTestBookingService(db)
+	refunds := NewRefundService(db, bookings.gateway, bookings.broker, bookings.waitlist.(*WaitlistService), time.Hour)
+	bookingID, refundID := createPaidBookingRefund(t, db, bookings, refunds)
+
+	var showtimeID, userID int
+	err := db.QueryRow("SELECT showtime_id, user_id FROM bookings WHERE booking_id = $1", bookingID).Scan(&showtimeID, &userID)
+	if err != nil {
+		t.Fatalf("fetch booking: %v", err)
+	}
+
+	result, err := bookings.CancelShowtime(ctx, showtimeID, nil)
+	if err != nil {
+		t.Fatalf("cancel showtime: %v", err)
+	}
+	if len(result.CancelledBookings) != 1 || result.CancelledBookings[0].BookingID != bookingID {
+		t.Fatalf("cancelled = %+v, want booking %d", result.CancelledBookings, bookingID)
+	}
+
+	var status string
+	var note, refundRef sql.NullString
+	err = db.QueryRow(`
+		SELECT status, admin_note, provider_refund_ref FROM refund_requests WHERE refund_id = $1
+	`, refundID).Scan(&status, &note, &refundRef)
+	if err != nil {
+		t.Fatalf("fetch refund request: %v", err)
+	}
+	if status != "refunded" || !note.Valid || !refundRef.Valid {
+		t.Errorf("refund request = %s (note %v, ref %v), want refunded with note and ref", status, note, refundRef)
+	}
+	if paymentStatus, _, _ := paymentRefundState(t, db, bookingID); paymentStatus != payments.StatusRefunded {
+		t.Errorf("payment = %s, want %s", paymentStatus, payments.StatusRefunded)
+	}
+
+	var seatID int
+	if err := db.QueryRow("SELECT seat_id FROM booking_seats WHERE booking_id = $1", bookingID).Scan(&seatID); err != nil {
+		t.Fatalf("fetch seat: %v", err)
+	}
+	_, err = bookings.Reserve(ctx, userID, showtimeID, []models.BookingSeatRequest{{SeatID: seatID}}, nil, "")
+	if !errors.Is(err, ErrShowtimeNotFound) {
+		t.Errorf("reserve on cancelled showtime error = %v, want %v", err, ErrShowtimeNotFound)
+	}
+}
@@ -0,0 +1,635 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"movie-booking-system/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMovieNotFound   = errors.New("movie not found")
+	ErrTheaterNotFound = errors.New("theater not found")
+	ErrInvalidSchedule = errors.New("invalid show date or time")
+)
+
+// ShowtimeConflictError รอบฉายทับกับรอบอื่นในโรงเดียวกัน (รวมเวลาทำความสะอาด)
+type ShowtimeConflictError struct {
+	ShowtimeID int
+	MovieTitle string
+	ShowDate   string
+	ShowTime   string
+	EndTime    string
+}
+
+func (e *ShowtimeConflictError) Error() string {
+	if e.ShowtimeID == 0 {
+		return "showtime overlaps another showtime"
+	}
+	return fmt.Sprintf("showtime overlaps showtime %d (%s, %s %s-%s)", e.ShowtimeID, e.MovieTitle, e.ShowDate, e.ShowTime, e.EndTime)
+}
+
+// ShowtimeTooShortError ช่วง show_time ถึง end_time สั้นกว่าความยาวภาพยนตร์
+type ShowtimeTooShortError struct {
+	MovieDuration    int
+	ScheduledMinutes int
+}
+
+func (e *ShowtimeTooShortError) Error() string {
+	return fmt.Sprintf("showtime is %d minutes but the movie runs %d minutes", e.ScheduledMinutes, e.MovieDuration)
+}
+
+// ShowtimeService สร้างและแก้ไขรอบฉายโดยตรวจไม่ให้ทับกันในโรงเดียวกัน
+// ฐานข้อมูลบังคับซ้ำด้วย exclusion constraint showtimes_no_overlap
+// end_time ที่ไม่ได้ระบุคำนวณจาก show_time + trailerPadding + movies.duration
+type ShowtimeService struct {
+	db             *sql.DB
+	trailerPadding time.Duration
+	cleaningBuffer time.Duration
+}
+
+func NewShowtimeService(db *sql.DB, trailerPadding, cleaningBuffer time.Duration) *ShowtimeService {
+	return &ShowtimeService{db: db, trailerPadding: trailerPadding, cleaningBuffer: cleaningBuffer}
+}
+
+// showtimeSlot เวลาฉายของรอบฉายที่ต้องตรวจ (showtimeID = 0 คือรอบใหม่)
+type showtimeSlot struct {
+	showtimeID      int
+	movieID         int
+	theaterID       int
+	showDate        string
+	showTime        string
+	endTime         string
+	cleaningMinutes int
+}
+
+// overlaps ตรวจว่าสองรอบในโรงเดียวกันทับกันหรือไม่ ด้วยช่วงเวลาเดียวกับ showtime_period ใน init.sql
+// (end_time <= show_time = จบวันถัดไป, รวมเวลาทำความสะอาด, ขอบท้ายเปิด) ใช้กับรอบที่ยังไม่ได้บันทึก
+func (a showtimeSlot) overlaps(b showtimeSlot) bool {
+	if a.theaterID != b.theaterID {
+		return false
+	}
+	aStart, aEnd, err := a.period()
+	if err != nil {
+		return false
+	}
+	bStart, bEnd, err := b.period()
+	if err != nil {
+		return false
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// period ช่วงเวลาที่รอบครองโรง [เริ่ม, จบ + ทำความสะอาด)
+func (s showtimeSlot) period() (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02 15:04", s.showDate+" "+s.showTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.Parse("2006-01-02 15:04", s.showDate+" "+s.endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end.Add(time.Duration(s.cleaningMinutes) * time.Minute), nil
+}
+
+// Create สร้างรอบฉายใหม่ (end_time ว่าง = คำนวณจากความยาวภาพยนตร์)
+func (s *ShowtimeService) Create(ctx context.Context, req models.CreateShowtimeRequest) (*models.Showtime, error) {
+	var theaterExists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM theaters WHERE theater_id = $1)", req.TheaterID).Scan(&theaterExists)
+	if err != nil {
+		return nil, fmt.Errorf("fetch theater: %w", err)
+	}
+	if !theaterExists {
+		return nil, ErrTheaterNotFound
+	}
+
+	if req.EndTime == "" {
+		req.EndTime, err = s.deriveEndTime(ctx, s.db, req.MovieID, req.ShowTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slot := showtimeSlot{
+		movieID:         req.MovieID,
+		theaterID:       req.TheaterID,
+		showDate:        req.ShowDate,
+		showTime:        req.ShowTime,
+		endTime:         req.EndTime,
+		cleaningMinutes: int(s.cleaningBuffer / time.Minute),
+	}
+	if err := checkShowtimeSlot(ctx, s.db, slot); err != nil {
+		return nil, err
+	}
+
+	showtime, err := insertShowtime(ctx, s.db, slot, req.Price)
+	if err != nil {
+		return nil, s.scheduleWriteError(ctx, slot, err, "create showtime")
+	}
+	return showtime, nil
+}
+
+// insertShowtime บันทึกรอบฉายที่ตรวจแล้ว available_seats ตั้งโดย trigger จากที่นั่ง active ของโรง
+func insertShowtime(ctx context.Context, q querier, slot showtimeSlot, price float64) (*models.Showtime, error) {
+	showtime := models.Showtime{
+		MovieID:   slot.movieID,
+		TheaterID: slot.theaterID,
+		ShowDate:  slot.showDate,
+		ShowTime:  slot.showTime,
+		EndTime:   slot.endTime,
+		Price:     price,
+		IsActive:  true,
+	}
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, cleaning_minutes, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE)
+		RETURNING showtime_id, available_seats, created_at, updated_at
+	`, slot.movieID, slot.theaterID, slot.showDate, slot.showTime, slot.endTime, price, slot.cleaningMinutes).Scan(
+		&showtime.ShowtimeID, &showtime.AvailableSeats, &showtime.CreatedAt, &showtime.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &showtime, nil
+}
+
+// Update แก้ไขรอบฉายบางฟิลด์ ถ้าเวลาเปลี่ยนหรือเปิดรอบฉายกลับมาจะตรวจการทับซ้อนกับเวลาใหม่
+func (s *ShowtimeService) Update(ctx context.Context, showtimeID int, req models.UpdateShowtimeRequest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	slot := showtimeSlot{showtimeID: showtimeID}
+	var isActive sql.NullBool
+	err = tx.QueryRowContext(ctx, `
+		SELECT movie_id, theater_id, to_char(show_date, 'YYYY-MM-DD'), to_char(show_time, 'HH24:MI'),
+		       to_char(end_time, 'HH24:MI'), cleaning_minutes, is_active
+		FROM showtimes WHERE showtime_id = $1
+		FOR UPDATE
+	`, showtimeID).Scan(&slot.movieID, &slot.theaterID, &slot.showDate, &slot.showTime, &slot.endTime, &slot.cleaningMinutes, &isActive)
+	if err == sql.ErrNoRows {
+		return ErrShowtimeNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("fetch showtime: %w", err)
+	}
+
+	query := "UPDATE showtimes SET updated_at = CURRENT_TIMESTAMP"
+	args := []interface{}{}
+	argIndex := 1
+
+	if req.ShowDate != nil {
+		query += ", show_date = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.ShowDate)
+		argIndex++
+		slot.showDate = *req.ShowDate
+	}
+	if req.ShowTime != nil {
+		query += ", show_time = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.ShowTime)
+		argIndex++
+		slot.showTime = *req.ShowTime
+
+		// ย้ายเวลาเริ่มโดยไม่ระบุ end_time ให้คำนวณเวลาจบใหม่จากเวลาเริ่มใหม่
+		if req.EndTime == nil {
+			endTime, err := s.deriveEndTime(ctx, tx, slot.movieID, slot.showTime)
+			if err != nil {
+				return err
+			}
+			req.EndTime = &endTime
+		}
+	}
+	if req.EndTime != nil {
+		query += ", end_time = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.EndTime)
+		argIndex++
+		slot.endTime = *req.EndTime
+	}
+	if req.Price != nil {
+		query += ", price = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.Price)
+		argIndex++
+	}
+	if req.IsActive != nil {
+		query += ", is_active = $" + strconv.Itoa(argIndex)
+		args = append(args, *req.IsActive)
+		argIndex++
+		isActive = sql.NullBool{Bool: *req.IsActive, Valid: true}
+	}
+
+	// admin ย้ายหรือปิดรอบเองแล้ว (รอบที่เปิดอยู่ผ่าน checkShowtimeSlot ที่ตรวจความยาวภาพยนตร์) จึงเลิกรอตรวจสอบ
+	scheduleChanged := req.ShowDate != nil || req.ShowTime != nil || req.EndTime != nil || req.IsActive != nil
+	if scheduleChanged {
+		query += ", needs_review = FALSE"
+	}
+
+	query += " WHERE showtime_id = $" + strconv.Itoa(argIndex)
+	args = append(args, showtimeID)
+
+	// รอบฉายที่ปิดอยู่ไม่กันเวลาโรง จึงตรวจเฉพาะเมื่อผลลัพธ์ยังเปิดอยู่
+	if scheduleChanged && isActive.Bool {
+		if err := checkShowtimeSlot(ctx, tx, slot); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return s.scheduleWriteError(ctx, slot, err, "update showtime")
+	}
+	if err := tx.Commit(); err != nil {
+		return s.scheduleWriteError(ctx, slot, err, "commit transaction")
+	}
+	return nil
+}
+
+// deriveEndTime เวลาจบของรอบฉาย = show_time + ตัวอย่างหนัง/โฆษณา + ความยาวภาพยนตร์
+// TIME วนรอบเที่ยงคืนเอง (เช่น 23:00 + 150 นาที = 01:30) และ showtime_period นับเป็นวันถัดไป
+func (s *ShowtimeService) deriveEndTime(ctx context.Context, q querier, movieID int, showTime string) (string, error) {
+	var endTime string
+	err := q.QueryRowContext(ctx, `
+		SELECT to_char($2::TIME + make_interval(mins => m.duration + $3), 'HH24:MI')
+		FROM movies m WHERE m.movie_id = $1
+	`, movieID, showTime, int(s.trailerPadding/time.Minute)).Scan(&endTime)
+	if err == sql.ErrNoRows {
+		return "", ErrMovieNotFound
+	}
+	if IsInvalidDateTime(err) {
+		return "", ErrInvalidSchedule
+	}
+	if err != nil {
+		return "", fmt.Errorf("derive end time: %w", err)
+	}
+	return endTime, nil
+}
+
+// RescheduleMovieShowtimes เลื่อน end_time ของรอบฉายในอนาคตที่เปิดอยู่ตามความยาวภาพยนตร์ที่เปลี่ยนไป (durationDelta นาที)
+// ช่วงตัวอย่างหนังที่แต่ละรอบตั้งไว้คงเดิม รอบที่ยาวขึ้นแล้วทับรอบถัดไปจะไม่ถูกเลื่อน แต่ถูกตั้ง needs_review
+// (ค้นได้จากรายการรอบฉาย ?needs_review=true) และคืนไว้ใน NeedsReview
+// รอบที่ needs_review อยู่แล้วยังมี end_time ตามความยาวเดิมก่อนถูกตั้ง จึงคำนวณใหม่จาก show_time + ตัวอย่างหนัง + ความยาวใหม่
+// และล้าง flag เมื่อไม่ทับรอบอื่นแล้วเท่านั้น
+// ต้องเรียกภายใน transaction เดียวกับที่แก้ movies.duration
+func (s *ShowtimeService) RescheduleMovieShowtimes(ctx context.Context, tx *sql.Tx, movieID, durationDelta int) (*models.ShowtimeReschedule, error) {
+	result := &models.ShowtimeReschedule{
+		UpdatedShowtimeIDs: []int{},
+		NeedsReview:        []models.ShowtimeReview{},
+	}
+	if durationDelta == 0 {
+		return result, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.showtime_id, s.theater_id, to_char(s.show_date, 'YYYY-MM-DD'), to_char(s.show_time, 'HH24:MI'),
+		       to_char(CASE WHEN s.needs_review THEN s.show_time + make_interval(mins => m.duration + $3)
+		                    ELSE s.end_time + make_interval(mins => $2) END, 'HH24:MI'),
+		       s.cleaning_minutes, s.needs_review
+		FROM showtimes s
+		JOIN movies m ON s.movie_id = m.movie_id
+		WHERE s.movie_id = $1 AND s.is_active = TRUE AND (s.show_date + s.show_time) > NOW()
+		ORDER BY s.show_date, s.show_time
+		FOR UPDATE OF s
+	`, movieID, durationDelta, int(s.trailerPadding/time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("fetch future showtimes: %w", err)
+	}
+	var slots []showtimeSlot
+	flagged := map[int]bool{}
+	for rows.Next() {
+		slot := showtimeSlot{movieID: movieID}
+		var needsReview bool
+		if err := rows.Scan(&slot.showtimeID, &slot.theaterID, &slot.showDate, &slot.showTime, &slot.endTime, &slot.cleaningMinutes, &needsReview); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan showtime: %w", err)
+		}
+		slots = append(slots, slot)
+		flagged[slot.showtimeID] = needsReview
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch future showtimes: %w", err)
+	}
+
+	for _, slot := range slots {
+		// หนังสั้นลงไม่มีทางทับรอบอื่นเพิ่ม ตรวจเฉพาะเมื่อยาวขึ้น หรือรอบที่ทับอยู่แล้วตั้งแต่ก่อนหน้า
+		if durationDelta > 0 || flagged[slot.showtimeID] {
+			conflict, err := findShowtimeConflict(ctx, tx, slot)
+			if err != nil {
+				return nil, err
+			}
+			if conflict != nil {
+				_, err := tx.ExecContext(ctx, `
+					UPDATE showtimes SET needs_review = TRUE, updated_at = CURRENT_TIMESTAMP WHERE showtime_id = $1
+				`, slot.showtimeID)
+				if err != nil {
+					return nil, fmt.Errorf("flag showtime %d for review: %w", slot.showtimeID, err)
+				}
+				result.NeedsReview = append(result.NeedsReview, models.ShowtimeReview{
+					ShowtimeID:            slot.showtimeID,
+					ConflictingShowtimeID: conflict.ShowtimeID,
+					Reason:                conflict.Error(),
+				})
+				continue
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE showtimes SET end_time = $1, needs_review = FALSE, updated_at = CURRENT_TIMESTAMP WHERE showtime_id = $2
+		`, slot.endTime, slot.showtimeID)
+		if err != nil {
+			return nil, fmt.Errorf("update end time of showtime %d: %w", slot.showtimeID, err)
+		}
+		result.UpdatedShowtimeIDs = append(result.UpdatedShowtimeIDs, slot.showtimeID)
+	}
+	return result, nil
+}
+
+// checkShowtimeSlot ตรวจว่ารอบฉายยาวพอสำหรับภาพยนตร์และไม่ทับรอบอื่นที่เปิดอยู่ในโรงเดียวกัน
+func checkShowtimeSlot(ctx context.Context, q querier, slot showtimeSlot) error {
+	var movieDuration, scheduledMinutes int
+	err := q.QueryRowContext(ctx, `
+		SELECT m.duration,
+		       (EXTRACT(EPOCH FROM upper(p.period) - lower(p.period)) / 60)::INTEGER
+		FROM movies m
+		CROSS JOIN (SELECT showtime_period($2::DATE, $3::TIME, $4::TIME, 0) AS period) p
+		WHERE m.movie_id = $1
+	`, slot.movieID, slot.showDate, slot.showTime, slot.endTime).Scan(&movieDuration, &scheduledMinutes)
+	if err == sql.ErrNoRows {
+		return ErrMovieNotFound
+	}
+	if IsInvalidDateTime(err) {
+		return ErrInvalidSchedule
+	}
+	if err != nil {
+		return fmt.Errorf("fetch movie duration: %w", err)
+	}
+	if scheduledMinutes < movieDuration {
+		return &ShowtimeTooShortError{MovieDuration: movieDuration, ScheduledMinutes: scheduledMinutes}
+	}
+
+	conflict, err := findShowtimeConflict(ctx, q, slot)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return conflict
+	}
+	return nil
+}
+
+// findShowtimeConflict หารอบฉายแรกที่ช่วงเวลา (รวมเวลาทำความสะอาดของทั้งสองรอบ) ทับกับ slot
+func findShowtimeConflict(ctx context.Context, q querier, slot showtimeSlot) (*ShowtimeConflictError, error) {
+	var conflict ShowtimeConflictError
+	err := q.QueryRowContext(ctx, `
+		SELECT s.showtime_id, m.title, to_char(s.show_date, 'YYYY-MM-DD'),
+		       to_char(s.show_time, 'HH24:MI'), to_char(s.end_time, 'HH24:MI')
+		FROM showtimes s
+		JOIN movies m ON s.movie_id = m.movie_id
+		WHERE s.theater_id = $1 AND s.is_active = TRUE AND s.showtime_id <> $2
+		  AND showtime_period(s.show_date, s.show_time, s.end_time, s.cleaning_minutes)
+		      && showtime_period($3::DATE, $4::TIME, $5::TIME, $6)
+		ORDER BY s.show_date, s.show_time
+		LIMIT 1
+	`, slot.theaterID, slot.showtimeID, slot.showDate, slot.showTime, slot.endTime, slot.cleaningMinutes).Scan(
+		&conflict.ShowtimeID, &conflict.MovieTitle, &conflict.ShowDate, &conflict.ShowTime, &conflict.EndTime,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("check showtime conflict: %w", err)
+	}
+	return &conflict, nil
+}
+
+// scheduleWriteError แปลง error ตอนบันทึกรอบฉาย ถ้าชน exclusion constraint (มีอีกรอบถูกบันทึกไปพร้อมกัน)
+// จะหารอบที่ทับเพื่อบอกผู้ใช้
+func (s *ShowtimeService) scheduleWriteError(ctx context.Context, slot showtimeSlot, err error, action string) error {
+	if IsInvalidDateTime(err) {
+		return ErrInvalidSchedule
+	}
+	if !isExclusionViolation(err) {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	conflict, findErr := findShowtimeConflict(ctx, s.db, slot)
+	if findErr != nil || conflict == nil {
+		return &ShowtimeConflictError{}
+	}
+	return conflict
+}
+
+func isExclusionViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01"
+}
+
+// IsInvalidDateTime วันที่หรือเวลาที่ส่งมาแปลงเป็น DATE/TIME ไม่ได้ (SQLSTATE class 22)
+func IsInvalidDateTime(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Class() == "22"
+}
+
+const (
+	maxScheduleDays      = 92
+	maxScheduleShowtimes = 2000
+)
+
+// ErrScheduleConflicts รอบฉายที่จะสร้างบางรอบทับรอบอื่น และไม่ได้ขอให้ข้าม (ไม่มีรอบไหนถูกสร้าง)
+var ErrScheduleConflicts = errors.New("generated showtimes overlap existing showtimes")
+
+// ScheduleRequestError คำขอสร้างรอบฉายซ้ำไม่ถูกต้อง
+type ScheduleRequestError struct {
+	Reason string
+}
+
+func (e *ScheduleRequestError) Error() string {
+	return "invalid schedule: " + e.Reason
+}
+
+// Generate สร้างรอบฉายตามแม่แบบ (โรง x วันในช่วงที่ตรง weekdays x เวลาเริ่ม) ใน transaction เดียว
+// end_time คำนวณจากความยาวภาพยนตร์ รอบที่ทับรอบอื่น (รวมรอบก่อนหน้าในคำขอเดียวกัน) ถูกข้ามหรือทำให้ยกเลิกทั้งหมด
+// แผนคำนวณจากการอ่านรอบเดิมด้วย findShowtimeConflict และเทียบกันเองในหน่วยความจำ dry run คืนแผนโดยไม่บันทึก
+// (Created ไม่มี showtime_id)
+// ถ้าคืน ErrScheduleConflicts จะคืนผลลัพธ์ (Skipped) มาด้วยเพื่อให้ admin เห็นรอบที่ทับ
+func (s *ShowtimeService) Generate(ctx context.Context, req models.GenerateShowtimesRequest) (*models.GeneratedSchedule, error) {
+	dates, err := scheduleDates(req.StartDate, req.EndDate, req.Weekdays)
+	if err != nil {
+		return nil, err
+	}
+	startTimes := make([]string, 0, len(req.StartTimes))
+	seenTimes := map[string]bool{}
+	for _, startTime := range req.StartTimes {
+		parsed, err := time.Parse("15:04", startTime)
+		if err != nil {
+			return nil, &ScheduleRequestError{Reason: fmt.Sprintf("start time %q must be HH:MM", startTime)}
+		}
+		normalized := parsed.Format("15:04")
+		if !seenTimes[normalized] {
+			seenTimes[normalized] = true
+			startTimes = append(startTimes, normalized)
+		}
+	}
+	sort.Strings(startTimes)
+	theaterIDs := make([]int, 0, len(req.TheaterIDs))
+	seenTheaters := map[int]bool{}
+	for _, theaterID := range req.TheaterIDs {
+		if !seenTheaters[theaterID] {
+			seenTheaters[theaterID] = true
+			theaterIDs = append(theaterIDs, theaterID)
+		}
+	}
+	if total := len(dates) * len(theaterIDs) * len(startTimes); total > maxScheduleShowtimes {
+		return nil, &ScheduleRequestError{Reason: fmt.Sprintf("template would create %d showtimes, the limit is %d", total, maxScheduleShowtimes)}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var foundTheaters int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM theaters WHERE theater_id = ANY($1)", pq.Array(theaterIDs)).Scan(&foundTheaters)
+	if err != nil {
+		return nil, fmt.Errorf("fetch theaters: %w", err)
+	}
+	if foundTheaters != len(theaterIDs) {
+		return nil, ErrTheaterNotFound
+	}
+
+	// เวลาจบขึ้นกับเวลาเริ่มเท่านั้น คำนวณครั้งเดียวต่อเวลาเริ่ม
+	endTimes := make(map[string]string, len(startTimes))
+	for _, startTime := range startTimes {
+		endTimes[startTime], err = s.deriveEndTime(ctx, tx, req.MovieID, startTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.GeneratedSchedule{
+		DryRun:  req.DryRun,
+		Created: []models.Showtime{},
+		Skipped: []models.ShowtimeSkip{},
+	}
+	cleaningMinutes := int(s.cleaningBuffer / time.Minute)
+	planned := []showtimeSlot{}
+	for _, theaterID := range theaterIDs {
+		for _, date := range dates {
+			for _, startTime := range startTimes {
+				slot := showtimeSlot{
+					movieID:         req.MovieID,
+					theaterID:       theaterID,
+					showDate:        date,
+					showTime:        startTime,
+					endTime:         endTimes[startTime],
+					cleaningMinutes: cleaningMinutes,
+				}
+				conflict, err := findShowtimeConflict(ctx, tx, slot)
+				if err != nil {
+					return nil, err
+				}
+				if conflict == nil {
+					conflict = plannedConflict(planned, slot)
+				}
+				if conflict != nil {
+					result.Skipped = append(result.Skipped, models.ShowtimeSkip{
+						TheaterID:             theaterID,
+						ShowDate:              date,
+						ShowTime:              startTime,
+						EndTime:               slot.endTime,
+						ConflictingShowtimeID: conflict.ShowtimeID,
+						Reason:                conflict.Error(),
+					})
+					continue
+				}
+				planned = append(planned, slot)
+			}
+		}
+	}
+
+	if len(result.Skipped) > 0 && !req.SkipConflicts {
+		return result, ErrScheduleConflicts
+	}
+	if req.DryRun {
+		for _, slot := range planned {
+			result.Created = append(result.Created, models.Showtime{
+				MovieID:   slot.movieID,
+				TheaterID: slot.theaterID,
+				ShowDate:  slot.showDate,
+				ShowTime:  slot.showTime,
+				EndTime:   slot.endTime,
+				Price:     req.Price,
+				IsActive:  true,
+			})
+		}
+		return result, nil
+	}
+
+	for _, slot := range planned {
+		showtime, err := insertShowtime(ctx, tx, slot, req.Price)
+		if err != nil {
+			return nil, s.scheduleWriteError(ctx, slot, err, "create showtime")
+		}
+		result.Created = append(result.Created, *showtime)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// plannedConflict รอบก่อนหน้าในแผนเดียวกันที่ทับ slot (nil = ไม่ทับ) คืนเป็น ShowtimeConflictError ที่ไม่มี ShowtimeID
+// เพราะรอบนั้นยังไม่ได้บันทึก
+func plannedConflict(planned []showtimeSlot, slot showtimeSlot) *ShowtimeConflictError {
+	for _, other := range planned {
+		if other.overlaps(slot) {
+			return &ShowtimeConflictError{ShowDate: other.showDate, ShowTime: other.showTime, EndTime: other.endTime}
+		}
+	}
+	return nil
+}
+
+// scheduleDates วันที่ (YYYY-MM-DD) ในช่วง startDate ถึง endDate ที่ตรงกับ weekdays (ว่าง = ทุกวัน)
+func scheduleDates(startDate, endDate string, weekdays []int) ([]string, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, &ScheduleRequestError{Reason: "start_date must be YYYY-MM-DD"}
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, &ScheduleRequestError{Reason: "end_date must be YYYY-MM-DD"}
+	}
+	if end.Before(start) {
+		return nil, &ScheduleRequestError{Reason: "end_date must not be before start_date"}
+	}
+	if end.Sub(start) >= maxScheduleDays*24*time.Hour {
+		return nil, &ScheduleRequestError{Reason: fmt.Sprintf("date range must be at most %d days", maxScheduleDays)}
+	}
+
+	allowed := map[time.Weekday]bool{}
+	for _, weekday := range weekdays {
+		allowed[time.Weekday(weekday)] = true
+	}
+
+	dates := []string{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if len(allowed) == 0 || allowed[day.Weekday()] {
+			dates = append(dates, day.Format("2006-01-02"))
+		}
+	}
+	if len(dates) == 0 {
+		return nil, &ScheduleRequestError{Reason: "no dates in range match weekdays"}
+	}
+	return dates, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"movie-booking-system/models"
+)
+
+// หนังยาวขึ้นจนรอบแรกทับรอบถัดไป: รอบแรกถูกตั้ง needs_review และค้างไว้จน admin ย้ายรอบ
+func TestRescheduleFlagsShowtimesThatCannotShift(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	firstID, _ := createShowtimeFixture(t, db, 1)
+
+	// รอบถัดไปเริ่มพอดีหลังรอบแรกจบ + ทำความสะอาด 15 นาที
+	var movieID, secondID int
+	err := db.QueryRow(`
+		INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price)
+		SELECT movie_id, theater_id, show_date, '20:30', '22:45', price FROM showtimes WHERE showtime_id = $1
+		RETURNING movie_id, showtime_id
+	`, firstID).Scan(&movieID, &secondID)
+	if err != nil {
+		t.Fatalf("create next showtime: %v", err)
+	}
+
+	service := NewShowtimeService(db, 15*time.Minute, 15*time.Minute)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE movies SET duration = duration + 10 WHERE movie_id = $1", movieID); err != nil {
+		t.Fatalf("update duration: %v", err)
+	}
+	result, err := service.RescheduleMovieShowtimes(ctx, tx, movieID, 10)
+	if err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if len(result.NeedsReview) != 1 || result.NeedsReview[0].ShowtimeID != firstID {
+		t.Errorf("needs review = %+v, want showtime %d", result.NeedsReview, firstID)
+	}
+	if len(result.UpdatedShowtimeIDs) != 1 || result.UpdatedShowtimeIDs[0] != secondID {
+		t.Errorf("updated = %v, want [%d]", result.UpdatedShowtimeIDs, secondID)
+	}
+
+	needsReview := func(showtimeID int) bool {
+		t.Helper()
+		var flagged bool
+		if err := db.QueryRow("SELECT needs_review FROM showtimes WHERE showtime_id = $1", showtimeID).Scan(&flagged); err != nil {
+			t.Fatalf("fetch needs_review: %v", err)
+		}
+		return flagged
+	}
+	if !needsReview(firstID) || needsReview(secondID) {
+		t.Fatalf("needs_review = %v/%v, want true/false", needsReview(firstID), needsReview(secondID))
+	}
+
+	// admin ย้ายรอบแรกให้เริ่มเร็วขึ้น (เวลาจบคำนวณจากความยาวใหม่) แล้ว flag ต้องถูกล้าง
+	showTime := "17:00"
+	if err := service.Update(ctx, firstID, models.UpdateShowtimeRequest{ShowTime: &showTime}); err != nil {
+		t.Fatalf("move showtime: %v", err)
+	}
+	if needsReview(firstID) {
+		t.Error("needs_review still set after the showtime was moved")
+	}
+}
+
+// รอบที่ needs_review ค้างอยู่ยังมี end_time ตามความยาวเดิม ต้องคำนวณเวลาจบใหม่จากความยาวล่าสุด ไม่ใช่เลื่อนตามส่วนต่าง
+func TestRescheduleRecomputesFlaggedShowtimes(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	firstID, _ := createShowtimeFixture(t, db, 1)
+
+	var movieID int
+	err := db.QueryRow(`
+		INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price)
+		SELECT movie_id, theater_id, show_date, '20:30', '22:45', price FROM showtimes WHERE showtime_id = $1
+		RETURNING movie_id
+	`, firstID).Scan(&movieID)
+	if err != nil {
+		t.Fatalf("create next showtime: %v", err)
+	}
+	if _, err := db.Exec("UPDATE showtimes SET needs_review = TRUE WHERE showtime_id = $1", firstID); err != nil {
+		t.Fatalf("flag showtime: %v", err)
+	}
+
+	service := NewShowtimeService(db, 15*time.Minute, 15*time.Minute)
+	changeDuration := func(delta int) *models.ShowtimeReschedule {
+		t.Helper()
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("begin: %v", err)
+		}
+		defer tx.Rollback()
+		if _, err := tx.Exec("UPDATE movies SET duration = duration + $1 WHERE movie_id = $2", delta, movieID); err != nil {
+			t.Fatalf("update duration: %v", err)
+		}
+		result, err := service.RescheduleMovieShowtimes(ctx, tx, movieID, delta)
+		if err != nil {
+			t.Fatalf("reschedule: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+		return result
+	}
+	firstState := func() (string, bool) {
+		t.Helper()
+		var endTime string
+		var flagged bool
+		err := db.QueryRow(`
+			SELECT to_char(end_time, 'HH24:MI'), needs_review FROM showtimes WHERE showtime_id = $1
+		`, firstID).Scan(&endTime, &flagged)
+		if err != nil {
+			t.Fatalf("fetch showtime: %v", err)
+		}
+		return endTime, flagged
+	}
+
+	// 125 นาที: 18:00 + ตัวอย่าง 15 + 125 = 20:20 ทำความสะอาดถึง 20:35 ยังทับรอบ 20:30 จึงค้าง flag และเวลาจบเดิม
+	result := changeDuration(5)
+	if len(result.NeedsReview) != 1 || result.NeedsReview[0].ShowtimeID != firstID {
+		t.Errorf("needs review = %+v, want showtime %d", result.NeedsReview, firstID)
+	}
+	if endTime, flagged := firstState(); endTime != "20:15" || !flagged {
+		t.Errorf("first showtime = %s (needs_review %v), want 20:15 still flagged", endTime, flagged)
+	}
+
+	// 120 นาที: จบ 20:15 ไม่ทับแล้ว flag ถูกล้าง (ถ้าเลื่อนตามส่วนต่างจะได้ 20:10)
+	result = changeDuration(-5)
+	if len(result.NeedsReview) != 0 {
+		t.Errorf("needs review = %+v, want none", result.NeedsReview)
+	}
+	if endTime, flagged := firstState(); endTime != "20:15" || flagged {
+		t.Errorf("first showtime = %s (needs_review %v), want 20:15 unflagged", endTime, flagged)
+	}
+}
+
+func TestShowtimeSlotOverlaps(t *testing.T) {
+	slot := func(theaterID int, date, start, end string) showtimeSlot {
+		return showtimeSlot{theaterID: theaterID, showDate: date, showTime: start, endTime: end, cleaningMinutes: 15}
+	}
+	evening := slot(1, "2026-03-14", "18:00", "20:15")
+	tests := []struct {
+		name  string
+		other showtimeSlot
+		want  bool
+	}{
+		{"same time", slot(1, "2026-03-14", "18:00", "20:15"), true},
+		{"starts during cleaning", slot(1, "2026-03-14", "20:20", "22:35"), true},
+		{"starts after cleaning", slot(1, "2026-03-14", "20:30", "22:45"), false},
+		{"ends before start", slot(1, "2026-03-14", "15:00", "17:45"), false},
+		{"cleaning runs into start", slot(1, "2026-03-14", "15:00", "17:50"), true},
+		{"other theater", slot(2, "2026-03-14", "18:00", "20:15"), false},
+		{"other date", slot(1, "2026-03-15", "18:00", "20:15"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evening.overlaps(tt.other); got != tt.want {
+				t.Errorf("overlaps() = %v, want %v", got, tt.want)
+			}
+			if got := tt.other.overlaps(evening); got != tt.want {
+				t.Errorf("reversed overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// รอบดึกข้ามเที่ยงคืนทับรอบเช้ามืดของวันถัดไป
+	late := slot(1, "2026-03-14", "23:00", "01:15")
+	if !late.overlaps(slot(1, "2026-03-15", "01:00", "03:15")) {
+		t.Error("late showtime does not overlap the next day's 01:00 showtime")
+	}
+	if late.overlaps(slot(1, "2026-03-15", "01:30", "03:45")) {
+		t.Error("late showtime overlaps the next day's 01:30 showtime")
+	}
+}
+
+// dry run คืนแผนโดยไม่บันทึก และรอบในแม่แบบที่ทับกันเองถูกข้ามเหมือนรอบเดิมที่ทับ
+func TestGenerateDryRunDoesNotInsert(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	existingID, _ := createShowtimeFixture(t, db, 1)
+
+	var movieID, theaterID int
+	var showDate string
+	err := db.QueryRow(`
+		SELECT movie_id, theater_id, to_char(show_date, 'YYYY-MM-DD') FROM showtimes WHERE showtime_id = $1
+	`, existingID).Scan(&movieID, &theaterID, &showDate)
+	if err != nil {
+		t.Fatalf("fetch showtime: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM showtimes WHERE theater_id = $1", theaterID) })
+
+	countShowtimes := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM showtimes WHERE theater_id = $1", theaterID).Scan(&n); err != nil {
+			t.Fatalf("count showtimes: %v", err)
+		}
+		return n
+	}
+
+	// หนัง 120 นาที + ตัวอย่าง 15 + ทำความสะอาด 15: 10:00 ไม่ทับ, 11:00 ทับ 10:00, 17:00 ทับรอบเดิม 18:00
+	service := NewShowtimeService(db, 15*time.Minute, 15*time.Minute)
+	req := models.GenerateShowtimesRequest{
+		MovieID:       movieID,
+		TheaterIDs:    []int{theaterID},
+		StartDate:     showDate,
+		EndDate:       showDate,
+		StartTimes:    []string{"17:00", "11:00", "10:00"},
+		Price:         180,
+		SkipConflicts: true,
+		DryRun:        true,
+	}
+	result, err := service.Generate(ctx, req)
+	if err != nil {
+		t.Fatalf("generate dry run: %v", err)
+	}
+	if len(result.Created) != 1 || result.Created[0].ShowTime != "10:00" || result.Created[0].ShowtimeID != 0 {
+		t.Errorf("created = %+v, want unsaved 10:00", result.Created)
+	}
+	if len(result.Skipped) != 2 {
+		t.Fatalf("skipped = %+v, want 11:00 and 17:00", result.Skipped)
+	}
+	for _, skip := range result.Skipped {
+		want := 0
+		if skip.ShowTime == "17:00" {
+			want = existingID
+		}
+		if skip.ConflictingShowtimeID != want {
+			t.Errorf("%s conflicts with showtime %d, want %d", skip.ShowTime, skip.ConflictingShowtimeID, want)
+		}
+	}
+	if n := countShowtimes(); n != 1 {
+		t.Fatalf("dry run left %d showtimes, want 1", n)
+	}
+
+	req.DryRun = false
+	result, err = service.Generate(ctx, req)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(result.Created) != 1 || result.Created[0].ShowtimeID == 0 {
+		t.Errorf("created = %+v, want saved 10:00", result.Created)
+	}
+	if n := countShowtimes(); n != 2 {
+		t.Errorf("showtimes = %d, want 2", n)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"movie-booking-system/models"
+)
+
+// DefaultTicketType ประเภทตั๋วเมื่อไม่ได้ระบุ
+const DefaultTicketType = "adult"
+
+// ratingMinimumAge อายุขั้นต่ำของผู้ชมตามเรตภาพยนตร์
+var ratingMinimumAge = map[string]int{
+	"G":   0,
+	"13+": 13,
+	"15+": 15,
+	"18+": 18,
+	"20+": 20,
+}
+
+// TicketTypeError ใช้ประเภทตั๋วนี้กับรอบฉายไม่ได้ (ไม่มีประเภทนี้ หรือไม่ผ่านเรตอายุ)
+type TicketTypeError struct {
+	TicketType string
+	Reason     string
+}
+
+func (e *TicketTypeError) Error() string {
+	return fmt.Sprintf("ticket type %q cannot be used: %s", e.TicketType, e.Reason)
+}
+
+// fareContext ประเภทตั๋วที่เปิดใช้งานและค่าตั๋วที่ใช้กับสาขาของรอบฉาย
+type fareContext struct {
+	ageRating *string
+	minAge    int
+	types     map[string]models.TicketType
+	fares     []models.TicketFare
+}
+
+func loadFareContext(ctx context.Context, q querier, showtimeID int) (*fareContext, error) {
+	fc := &fareContext{types: map[string]models.TicketType{}}
+
+	var cinemaID int
+	err := q.QueryRowContext(ctx, `
+		SELECT m.age_rating, t.cinema_id
+		FROM showtimes s
+		JOIN movies m ON s.movie_id = m.movie_id
+		JOIN theaters t ON s.theater_id = t.theater_id
+		WHERE s.showtime_id = $1
+	`, showtimeID).Scan(&fc.ageRating, &cinemaID)
+	if err == sql.ErrNoRows {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime rating: %w", err)
+	}
+	if fc.ageRating != nil {
+		fc.minAge = ratingMinimumAge[*fc.ageRating]
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT ticket_type, name, min_age, max_age, is_active, created_at, updated_at
+		FROM ticket_types
+		WHERE is_active = TRUE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("fetch ticket types: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.TicketType
+		if err := rows.Scan(&t.TicketType, &t.Name, &t.MinAge, &t.MaxAge, &t.IsActive, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan ticket type: %w", err)
+		}
+		fc.types[t.TicketType] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch ticket types: %w", err)
+	}
+
+	fareRows, err := q.QueryContext(ctx, `
+		SELECT fare_id, ticket_type, cinema_id, seat_type, adjustment_type, adjustment_value, created_at, updated_at
+		FROM ticket_fares
+		WHERE cinema_id IS NULL OR cinema_id = $1
+	`, cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch ticket fares: %w", err)
+	}
+	defer fareRows.Close()
+	for fareRows.Next() {
+		var f models.TicketFare
+		err := fareRows.Scan(&f.FareID, &f.TicketType, &f.CinemaID, &f.SeatType, &f.AdjustmentType, &f.AdjustmentValue, &f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan ticket fare: %w", err)
+		}
+		fc.fares = append(fc.fares, f)
+	}
+	if err := fareRows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch ticket fares: %w", err)
+	}
+	return fc, nil
+}
+
+// check ตรวจว่าประเภทตั๋วเปิดใช้งานอยู่และไม่ขัดกับเรตอายุของภาพยนตร์
+// ตั๋วที่กำหนดอายุสูงสุดต่ำกว่าอายุขั้นต่ำของเรต (เช่น ตั๋วเด็กกับหนัง 15+) ใช้ไม่ได้
+func (fc *fareContext) check(ticketType string) error {
+	t, ok := fc.types[ticketType]
+	if !ok {
+		return &TicketTypeError{TicketType: ticketType, Reason: "unknown ticket type"}
+	}
+	if fc.minAge > 0 && t.MaxAge != nil && *t.MaxAge < fc.minAge {
+		return &TicketTypeError{TicketType: ticketType, Reason: fmt.Sprintf("not allowed for %s movies", *fc.ageRating)}
+	}
+	return nil
+}
+
+// price ราคาตั๋วจากราคาที่นั่ง ใช้ค่าตั๋วที่เจาะจงที่สุด (สาขา + ประเภทที่นั่ง > สาขา > ประเภทที่นั่ง > ทุกสาขา)
+func (fc *fareContext) price(ticketType, seatType string, seatPrice float64) float64 {
+	var best *models.TicketFare
+	bestScore := -1
+	for i := range fc.fares {
+		f := &fc.fares[i]
+		if f.TicketType != ticketType || (f.SeatType != nil && *f.SeatType != seatType) {
+			continue
+		}
+		score := 0
+		if f.CinemaID != nil {
+			score += 2
+		}
+		if f.SeatType != nil {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = f, score
+		}
+	}
+	if best == nil {
+		return seatPrice
+	}
+	return adjustPrice(seatPrice, best.AdjustmentType, best.AdjustmentValue)
+}
+
+// adjustPrice ปรับราคาแบบ percentage / fixed / override ปัดเป็นสตางค์และไม่ติดลบ
+func adjustPrice(price float64, adjustmentType string, value float64) float64 {
+	switch adjustmentType {
+	case "percentage":
+		price = price * (1 + value/100)
+	case "fixed":
+		price = price + value
+	case "override":
+		price = value
+	}
+	return math.Max(0, math.Round(price*100)/100)
+}
+
+// ticketPrices ราคาของทุกประเภทตั๋วที่ใช้กับรอบฉายนี้ได้ สำหรับแสดงผล
+func (fc *fareContext) ticketPrices(seatType string, seatPrice float64) map[string]float64 {
+	prices := map[string]float64{}
+	for ticketType := range fc.types {
+		if fc.check(ticketType) == nil {
+			prices[ticketType] = fc.price(ticketType, seatType, seatPrice)
+		}
+	}
+	return prices
+}
+
+// quoteSeats ราคาสุดท้ายของที่นั่งตามประเภทที่นั่ง กฎราคา และประเภทตั๋ว เรียงตามที่ส่งมา
+// ที่นั่งซ้ำจะถูกตัดออก (ใช้ประเภทตั๋วของรายการแรก)
+func quoteSeats(ctx context.Context, q querier, showtimeID int, seats []models.BookingSeatRequest) ([]SeatPrice, error) {
+	seats = uniqueSeatRequests(seats)
+	seatIDs := make([]int, len(seats))
+	for i, seat := range seats {
+		seatIDs[i] = seat.SeatID
+	}
+
+	prices, err := seatPrices(ctx, q, showtimeID, seatIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) != len(seatIDs) {
+		return nil, ErrInvalidSeats
+	}
+
+	fc, err := loadFareContext(ctx, q, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range prices {
+		ticketType := seats[i].TicketType
+		if err := fc.check(ticketType); err != nil {
+			return nil, err
+		}
+		prices[i].TicketType = ticketType
+		prices[i].Price = fc.price(ticketType, prices[i].SeatType, prices[i].Price)
+	}
+	return prices, nil
+}
+
+// uniqueSeatRequests ตัดที่นั่งซ้ำ และใส่ประเภทตั๋วเริ่มต้นให้รายการที่ไม่ได้ระบุ
+func uniqueSeatRequests(seats []models.BookingSeatRequest) []models.BookingSeatRequest {
+	seen := make(map[int]bool, len(seats))
+	result := make([]models.BookingSeatRequest, 0, len(seats))
+	for _, seat := range seats {
+		if seen[seat.SeatID] {
+			continue
+		}
+		seen[seat.SeatID] = true
+		if seat.TicketType == "" {
+			seat.TicketType = DefaultTicketType
+		}
+		result = append(result, seat)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"movie-booking-system/models"
+)
+
+func TestFareContextPrice(t *testing.T) {
+	global := models.TicketFare{TicketType: "child", AdjustmentType: "percentage", AdjustmentValue: -10}
+	seat := models.TicketFare{TicketType: "child", SeatType: strPtr("vip"), AdjustmentType: "fixed", AdjustmentValue: -30}
+	cinema := models.TicketFare{TicketType: "child", CinemaID: intPtr(3), AdjustmentType: "fixed", AdjustmentValue: -50}
+	cinemaSeat := models.TicketFare{TicketType: "child", CinemaID: intPtr(3), SeatType: strPtr("vip"), AdjustmentType: "override", AdjustmentValue: 99}
+	senior := models.TicketFare{TicketType: "senior", AdjustmentType: "override", AdjustmentValue: 80}
+
+	tests := []struct {
+		name       string
+		fares      []models.TicketFare
+		ticketType string
+		seatType   string
+		want       float64
+	}{
+		{"no fare keeps seat price", nil, "child", "standard", 200},
+		{"other ticket type ignored", []models.TicketFare{senior}, "child", "standard", 200},
+		{"global fare", []models.TicketFare{global}, "child", "standard", 180},
+		{"seat type fare for other seat ignored", []models.TicketFare{global, seat}, "child", "standard", 180},
+		{"seat type beats global", []models.TicketFare{global, seat}, "child", "vip", 170},
+		{"cinema beats seat type", []models.TicketFare{seat, cinema, global}, "child", "vip", 150},
+		{"cinema and seat type beats cinema", []models.TicketFare{cinema, cinemaSeat, seat, global}, "child", "vip", 99},
+		{"cinema used when seat type differs", []models.TicketFare{cinemaSeat, cinema}, "child", "standard", 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &fareContext{fares: tt.fares}
+			if got := fc.price(tt.ticketType, tt.seatType, 200); got != tt.want {
+				t.Errorf("price = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFareContextCheck(t *testing.T) {
+	types := map[string]models.TicketType{
+		"adult":  {TicketType: "adult", MinAge: intPtr(13)},
+		"child":  {TicketType: "child", MaxAge: intPtr(12)},
+		"teen":   {TicketType: "teen", MinAge: intPtr(13), MaxAge: intPtr(17)},
+		"senior": {TicketType: "senior", MinAge: intPtr(60)},
+	}
+	rated := func(rating string) *fareContext {
+		return &fareContext{ageRating: strPtr(rating), minAge: ratingMinimumAge[rating], types: types}
+	}
+
+	tests := []struct {
+		name       string
+		fc         *fareContext
+		ticketType string
+		wantErr    bool
+	}{
+		{"unknown ticket type", rated("G"), "student", true},
+		{"child ticket for G", rated("G"), "child", false},
+		{"child ticket without rating", &fareContext{types: types}, "child", false},
+		{"child ticket for 13+", rated("13+"), "child", true},
+		{"teen ticket for 15+", rated("15+"), "teen", false},
+		{"teen ticket for 18+", rated("18+"), "teen", true},
+		{"adult ticket for 20+", rated("20+"), "adult", false},
+		{"senior ticket for 20+", rated("20+"), "senior", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fc.check(tt.ticketType)
+			var typeErr *TicketTypeError
+			if tt.wantErr != errors.As(err, &typeErr) {
+				t.Fatalf("check(%q) = %v, want error %v", tt.ticketType, err, tt.wantErr)
+			}
+			if typeErr != nil && typeErr.TicketType != tt.ticketType {
+				t.Errorf("error ticket type = %q, want %q", typeErr.TicketType, tt.ticketType)
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"movie-booking-system/events"
+	"movie-booking-system/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWaitlistEntryNotFound   = errors.New("waitlist entry not found")
+	ErrWaitlistEntryNotWaiting = errors.New("waitlist entry is no longer waiting")
+	ErrAlreadyWaitlisted       = errors.New("already on the waitlist for this showtime")
+	ErrSeatsAvailable          = errors.New("enough seats are available to book directly")
+	ErrShowtimeStarted         = errors.New("showtime has already started")
+)
+
+// WaitlistService คิวรอที่นั่งของรอบฉายที่เต็ม
+// เมื่อมีที่นั่งคืน (ยกเลิก/หมดเวลาถือ/คืนเงิน) คิวแรกที่จำนวนที่นั่งพอจะได้การจอง pending ที่ถือที่นั่งไว้ให้
+// ภายใน offerDuration ถ้าไม่จ่ายทัน cron ยกเลิกการจองตามปกติและที่นั่งจะส่งต่อให้คิวถัดไป
+type WaitlistService struct {
+	db            *sql.DB
+	selections    seatSelectionLocks // ไม่เสนอที่นั่งที่ลูกค้าคนอื่นกำลังเลือกอยู่
+	offerDuration time.Duration
+}
+
+func NewWaitlistService(db *sql.DB, selections *SeatSelections, offerDuration time.Duration) *WaitlistService {
+	return &WaitlistService{db: db, selections: selections, offerDuration: offerDuration}
+}
+
+const waitlistSelectQuery = `
+	SELECT w.entry_id, w.showtime_id, w.user_id, w.seat_count, w.status, w.booking_id,
+	       w.offered_at, w.offer_expires_at, w.created_at, w.updated_at,
+	       CASE WHEN w.status = 'waiting' THEN (
+	           SELECT COUNT(*) FROM waitlist_entries q
+	           WHERE q.showtime_id = w.showtime_id AND q.status = 'waiting' AND q.entry_id <= w.entry_id
+	       ) END
+	FROM waitlist_entries w
+`
+
+// Join ลงชื่อรอที่นั่งจำนวน seatCount ของรอบฉาย ลงได้เฉพาะเมื่อที่นั่งว่างไม่พอและรอบฉายยังไม่เริ่ม
+func (s *WaitlistService) Join(ctx context.Context, userID, showtimeID, seatCount int) (*models.WaitlistEntry, error) {
+	var availableSeats int
+	var upcoming bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT available_seats, (show_date + show_time) > NOW()
+		FROM showtimes
+		WHERE showtime_id = $1 AND is_active = TRUE
+	`, showtimeID).Scan(&availableSeats, &upcoming)
+	if err == sql.ErrNoRows {
+		return nil, ErrShowtimeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch showtime: %w", err)
+	}
+	if !upcoming {
+		return nil, ErrShowtimeStarted
+	}
+	if availableSeats >= seatCount {
+		return nil, ErrSeatsAvailable
+	}
+
+	var entryID int
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO waitlist_entries (showtime_id, user_id, seat_count)
+		VALUES ($1, $2, $3)
+		RETURNING entry_id
+	`, showtimeID, userID, seatCount).Scan(&entryID)
+	if isUniqueViolation(err) {
+		return nil, ErrAlreadyWaitlisted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("join waitlist: %w", err)
+	}
+
+	return scanWaitlistEntry(s.db.QueryRowContext(ctx, waitlistSelectQuery+" WHERE w.entry_id = $1", entryID))
+}
+
+// UserEntries ดึงคิวรอที่นั่งของผู้ใช้ (ล่าสุดก่อน)
+func (s *WaitlistService) UserEntries(ctx context.Context, userID int) ([]models.WaitlistEntry, error) {
+	return s.listEntries(ctx, waitlistSelectQuery+" WHERE w.user_id = $1 ORDER BY w.entry_id DESC", userID)
+}
+
+// ShowtimeEntries ดึงคิวที่ยังรออยู่หรือได้รับที่นั่งแล้วของรอบฉาย เรียงตามลำดับคิว
+func (s *WaitlistService) ShowtimeEntries(ctx context.Context, showtimeID int) ([]models.WaitlistEntry, error) {
+	return s.listEntries(ctx, waitlistSelectQuery+`
+		WHERE w.showtime_id = $1 AND w.status IN ('waiting', 'offered')
+		ORDER BY w.entry_id
+	`, showtimeID)
+}
+
+// Leave ออกจากคิว (เฉพาะคิวที่ยังรออยู่ ถ้าได้รับที่นั่งแล้วให้ยกเลิกการจองแทน)
+func (s *WaitlistService) Leave(ctx context.Context, entryID, userID int) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE waitlist_entries SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE entry_id = $1 AND user_id = $2 AND status = 'waiting'
+	`, entryID, userID)
+	if err != nil {
+		return fmt.Errorf("leave waitlist: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM waitlist_entries WHERE entry_id = $1 AND user_id = $2)
+	`, entryID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("fetch waitlist entry: %w", err)
+	}
+	if !exists {
+		return ErrWaitlistEntryNotFound
+	}
+	return ErrWaitlistEntryNotWaiting
+}
+
+// Depth ความยาวคิวของรอบฉายที่มีคนรออยู่ (showtimeID = 0 คือทุกรอบฉาย)
+func (s *WaitlistService) Depth(ctx context.Context, showtimeID int) ([]models.WaitlistDepth, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT st.showtime_id, m.title, c.cinema_name, t.theater_name, st.show_date, st.show_time, st.available_seats,
+		       COUNT(*) FILTER (WHERE w.status = 'waiting'),
+		       COALESCE(SUM(w.seat_count) FILTER (WHERE w.status = 'waiting'), 0),
+		       COUNT(*) FILTER (WHERE w.status = 'offered')
+		FROM waitlist_entries w
+		JOIN showtimes st ON w.showtime_id = st.showtime_id
+		JOIN movies m ON st.movie_id = m.movie_id
+		JOIN theaters t ON st.theater_id = t.theater_id
+		JOIN cinemas c ON t.cinema_id = c.cinema_id
+		WHERE w.status IN ('waiting', 'offered') AND ($1 = 0 OR st.showtime_id = $1)
+		GROUP BY st.showtime_id, m.title, c.cinema_name, t.theater_name, st.show_date, st.show_time, st.available_seats
+		ORDER BY COUNT(*) FILTER (WHERE w.status = 'waiting') DESC, st.show_date, st.show_time
+	`, showtimeID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch waitlist depth: %w", err)
+	}
+	defer rows.Close()
+
+	depths := []models.WaitlistDepth{}
+	for rows.Next() {
+		var d models.WaitlistDepth
+		var showDate time.Time
+		var showTime string
+		err := rows.Scan(
+			&d.ShowtimeID, &d.MovieTitle, &d.CinemaName, &d.TheaterName, &showDate, &showTime, &d.AvailableSeats,
+			&d.WaitingEntries, &d.WaitingSeats, &d.OfferedEntries,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan waitlist depth: %w", err)
+		}
+		d.ShowDate = showDate.Format("2006-01-02")
+		d.ShowTime = showTime
+		depths = append(depths, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch waitlist depth: %w", err)
+	}
+	return depths, nil
+}
+
+func (s *WaitlistService) listEntries(ctx context.Context, query string, args ...interface{}) ([]models.WaitlistEntry, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+func scanWaitlistEntry(row rowScanner) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	var bookingID, position sql.NullInt64
+	var offeredAt, offerExpiresAt sql.NullTime
+	err := row.Scan(
+		&entry.EntryID, &entry.ShowtimeID, &entry.UserID, &entry.SeatCount, &entry.Status, &bookingID,
+		&offeredAt, &offerExpiresAt, &entry.CreatedAt, &entry.UpdatedAt, &position,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan waitlist entry: %w", err)
+	}
+	if bookingID.Valid {
+		id := int(bookingID.Int64)
+		entry.BookingID = &id
+	}
+	if position.Valid {
+		p := int(position.Int64)
+		entry.Position = &p
+	}
+	if offeredAt.Valid {
+		entry.OfferedAt = &offeredAt.Time
+	}
+	if offerExpiresAt.Valid {
+		entry.OfferExpiresAt = &offerExpiresAt.Time
+	}
+	return &entry, nil
+}
+
+// offerReleasedSeats ส่งที่นั่งที่เพิ่งว่างให้คิวที่รออยู่ ภายใน transaction ที่คืนที่นั่ง
+// ทำใน savepoint ถ้าเสนอที่นั่งไม่สำเร็จจะ log แล้วย้อนเฉพาะส่วนนี้ การยกเลิก/คืนที่นั่งของผู้เรียกยังดำเนินต่อ
+// (คิวจะได้ที่นั่งเมื่อมีที่นั่งว่างครั้งถัดไป) คืน error เฉพาะเมื่อ savepoint ใช้ไม่ได้
+func (s *WaitlistService) offerReleasedSeats(ctx context.Context, tx *sql.Tx, showtimeID int, seatChanges *seatEvents) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT waitlist_offer"); err != nil {
+		return fmt.Errorf("create waitlist savepoint: %w", err)
+	}
+	offers := &seatEvents{}
+	if err := s.offerSeats(ctx, tx, showtimeID, offers); err != nil {
+		log.Printf("Failed to offer released seats of showtime %d to the waitlist: %v", showtimeID, err)
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT waitlist_offer"); err != nil {
+			return fmt.Errorf("roll back waitlist offer: %w", err)
+		}
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT waitlist_offer"); err != nil {
+		return fmt.Errorf("release waitlist savepoint: %w", err)
+	}
+	seatChanges.events = append(seatChanges.events, offers.events...)
+	return nil
+}
+
+// offerSeats เสนอที่นั่งว่างให้คิวตามลำดับ คิวแรกที่ขอจำนวนที่นั่งไม่เกินที่ว่างจะได้การจอง pending (ตั๋วผู้ใหญ่)
+// ที่ถือที่นั่งไว้ถึง offer_expires_at
+func (s *WaitlistService) offerSeats(ctx context.Context, tx *sql.Tx, showtimeID int, seatChanges *seatEvents) error {
+	for {
+		var availableSeats int
+		var upcoming bool
+		// ล็อกรอบฉายแบบเดียวกับ Reserve เพื่อไม่ให้สร้างการจองหลังรอบฉายถูกปิด
+		err := tx.QueryRowContext(ctx, `
+			SELECT available_seats, (show_date + show_time) > NOW()
+			FROM showtimes WHERE showtime_id = $1 AND is_active = TRUE
+			FOR KEY SHARE
+		`, showtimeID).Scan(&availableSeats, &upcoming)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch showtime: %w", err)
+		}
+		if !upcoming || availableSeats == 0 {
+			return nil
+		}
+
+		var entryID, userID, seatCount int
+		err = tx.QueryRowContext(ctx, `
+			SELECT entry_id, user_id, seat_count FROM waitlist_entries
+			WHERE showtime_id = $1 AND status = 'waiting' AND seat_count <= $2
+			ORDER BY entry_id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`, showtimeID, availableSeats).Scan(&entryID, &userID, &seatCount)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch waitlist entry: %w", err)
+		}
+
+		offered, err := s.offer(ctx, tx, showtimeID, entryID, userID, seatCount, seatChanges)
+		if err != nil || !offered {
+			return err
+		}
+	}
+}
+
+// offer สร้างการจองที่ถือที่นั่งไว้ให้คิว คืนค่า false ถ้าเลือกที่นั่งว่างได้ไม่ครบ
+// ข้ามที่นั่งที่ผู้ใช้อื่นกำลังเลือกอยู่ เช่นเดียวกับ Reserve
+func (s *WaitlistService) offer(ctx context.Context, tx *sql.Tx, showtimeID, entryID, userID, seatCount int, seatChanges *seatEvents) (bool, error) {
+	candidates, err := querySeatIDs(ctx, tx, `
+		SELECT s.seat_id
+		FROM showtimes st
+		JOIN seats s ON s.theater_id = st.theater_id
+		WHERE st.showtime_id = $1 AND s.is_active = TRUE
+		  AND NOT EXISTS (
+			SELECT 1 FROM seat_status ss
+			WHERE ss.showtime_id = st.showtime_id AND ss.seat_id = s.seat_id AND ss.status <> 'available'
+		  )
+		ORDER BY s.seat_row, s.seat_number
+	`, showtimeID)
+	if err != nil {
+		return false, fmt.Errorf("find available seats: %w", err)
+	}
+	selecting := map[int]bool{}
+	for _, seatID := range s.selections.HeldByOthers(showtimeID, userID, candidates) {
+		selecting[seatID] = true
+	}
+	seatIDs := []int{}
+	for _, seatID := range candidates {
+		if len(seatIDs) == seatCount {
+			break
+		}
+		if !selecting[seatID] {
+			seatIDs = append(seatIDs, seatID)
+		}
+	}
+	if len(seatIDs) < seatCount {
+		return false, nil
+	}
+
+	lostSeatIDs, err := lockSeatsForHold(ctx, tx, showtimeID, seatIDs)
+	if err != nil {
+		return false, fmt.Errorf("lock seats: %w", err)
+	}
+	if len(lostSeatIDs) > 0 {
+		return false, nil
+	}
+
+	seats := make([]models.BookingSeatRequest, len(seatIDs))
+	for i, seatID := range seatIDs {
+		seats[i] = models.BookingSeatRequest{SeatID: seatID, TicketType: DefaultTicketType}
+	}
+	prices, err := quoteSeats(ctx, tx, showtimeID, seats)
+	if err != nil {
+		return false, err
+	}
+	totalAmount := 0.0
+	for _, p := range prices {
+		totalAmount += p.Price
+	}
+
+	bookingCode := fmt.Sprintf("WL%d%d", entryID, time.Now().Unix())
+	var bookingID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO bookings (user_id, showtime_id, total_amount, booking_code, booking_status, payment_status)
+		VALUES ($1, $2, $3, $4, 'pending', 'pending')
+		RETURNING booking_id
+	`, userID, showtimeID, totalAmount, bookingCode).Scan(&bookingID)
+	if err != nil {
+		return false, fmt.Errorf("create waitlist booking: %w", err)
+	}
+	for _, p := range prices {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO booking_seats (booking_id, seat_id, ticket_type, price)
+			VALUES ($1, $2, $3, $4)
+		`, bookingID, p.SeatID, p.TicketType, p.Price)
+		if err != nil {
+			return false, fmt.Errorf("add seat %d to waitlist booking: %w", p.SeatID, err)
+		}
+	}
+
+	expiresAt := time.Now().Add(s.offerDuration)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE seat_status
+		SET status = 'reserved', booking_id = $1, reserved_until = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE showtime_id = $3 AND seat_id = ANY($4)
+	`, bookingID, expiresAt, showtimeID, pq.Array(seatIDs))
+	if err != nil {
+		return false, fmt.Errorf("hold seats for waitlist: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE waitlist_entries
+		SET status = 'offered', booking_id = $1, offered_at = CURRENT_TIMESTAMP, offer_expires_at = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE entry_id = $3
+	`, bookingID, expiresAt, entryID)
+	if err != nil {
+		return false, fmt.Errorf("mark waitlist entry offered: %w", err)
+	}
+
+	err = notify(ctx, tx, userID, "waitlist_offer", "ได้ที่นั่งจากรายชื่อรอแล้ว",
+		fmt.Sprintf("ที่นั่ง %d ที่ถูกถือไว้ให้คุณ กรุณาชำระเงินภายใน %s", len(seatIDs), expiresAt.Format("15:04")),
+		map[string]interface{}{
+			"entry_id":     entryID,
+			"booking_id":   bookingID,
+			"booking_code": bookingCode,
+			"showtime_id":  showtimeID,
+			"seat_ids":     seatIDs,
+			"total_amount": totalAmount,
+			"expires_at":   expiresAt,
+		})
+	if err != nil {
+		return false, err
+	}
+
+	seatChanges.add(showtimeID, events.SeatReserved, seatIDs)
+	return true, nil
+}
+
+// closeWaitlistOffer ปิดคิวที่ได้รับการจองนี้ (fulfilled เมื่อจ่ายแล้ว, expired เมื่อการจองถูกยกเลิก)
+func closeWaitlistOffer(ctx context.Context, tx *sql.Tx, bookingID int, status string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE waitlist_entries SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE booking_id = $2 AND status = 'offered'
+	`, status, bookingID)
+	if err != nil {
+		return fmt.Errorf("close waitlist offer: %w", err)
+	}
+	return nil
+}
+
+// notify บันทึกการแจ้งเตือนในแอปให้ผู้ใช้ (อยู่ใน transaction เดียวกับเหตุการณ์)
+func notify(ctx context.Context, tx *sql.Tx, userID int, notificationType, title, message string, data map[string]interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode notification data: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, type, title, message, data)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, notificationType, title, message, string(payload))
+	if err != nil {
+		return fmt.Errorf("create notification: %w", err)
+	}
+	return nil
+}
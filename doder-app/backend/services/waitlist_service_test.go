@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"movie-booking-system/models"
+)
+
+// waitlistFixture รอบฉายที่ผู้ใช้คนแรกจองเต็มทุกที่นั่ง และผู้ใช้คนที่สองรอ 1 ที่นั่ง
+func waitlistFixture(t *testing.T, db *sql.DB, bookings *BookingService, seatCount int) (showtimeID int, seatIDs []int, bookingID, entryID int, userIDs []int) {
+	t.Helper()
+	ctx := context.Background()
+	showtimeID, seatIDs = createShowtimeFixture(t, db, seatCount)
+	userIDs = createTestUsers(t, db, 3)
+
+	seats := make([]models.BookingSeatRequest, len(seatIDs))
+	for i, seatID := range seatIDs {
+		seats[i] = models.BookingSeatRequest{SeatID: seatID}
+	}
+	booking, err := bookings.Reserve(ctx, userIDs[0], showtimeID, seats, nil, "")
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	entry, err := bookings.waitlist.(*WaitlistService).Join(ctx, userIDs[1], showtimeID, 1)
+	if err != nil {
+		t.Fatalf("join waitlist: %v", err)
+	}
+	return showtimeID, seatIDs, booking.BookingID, entry.EntryID, userIDs
+}
+
+func waitlistEntryState(t *testing.T, db *sql.DB, entryID int) (string, sql.NullInt64) {
+	t.Helper()
+	var status string
+	var bookingID sql.NullInt64
+	if err := db.QueryRow(`SELECT status, booking_id FROM waitlist_entries WHERE entry_id = $1`, entryID).Scan(&status, &bookingID); err != nil {
+		t.Fatalf("fetch waitlist entry: %v", err)
+	}
+	return status, bookingID
+}
+
+// ที่นั่งที่ลูกค้าอื่นกำลังเลือกอยู่ต้องไม่ถูกเสนอให้คิว
+func TestOfferReleasedSeatsSkipsSelectedSeats(t *testing.T) {
+	db := openTestDB(t)
+	bookings := newTestBookingService(db)
+	ctx := context.Background()
+	showtimeID, seatIDs, bookingID, entryID, userIDs := waitlistFixture(t, db, bookings, 2)
+
+	if taken, err := bookings.selections.Select(showtimeID, "other", userIDs[2], []int{seatIDs[0]}); err != nil || len(taken) != 0 {
+		t.Fatalf("select seat = %v, %v", taken, err)
+	}
+	if err := bookings.Cancel(ctx, bookingID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	status, offerID := waitlistEntryState(t, db, entryID)
+	if status != "offered" || !offerID.Valid {
+		t.Fatalf("waitlist entry = %s (booking %v), want offered", status, offerID)
+	}
+	var seatID int
+	if err := db.QueryRow(`SELECT seat_id FROM booking_seats WHERE booking_id = $1`, offerID.Int64).Scan(&seatID); err != nil {
+		t.Fatalf("fetch offered seat: %v", err)
+	}
+	if seatID != seatIDs[1] {
+		t.Errorf("offered seat %d, want %d (seat %d is being selected)", seatID, seatIDs[1], seatIDs[0])
+	}
+}
+
+// เสนอที่นั่งให้คิวไม่สำเร็จ (ประเภทตั๋วเริ่มต้นถูกปิด) ต้องไม่ทำให้การยกเลิกล้มเหลว
+func TestCancelSucceedsWhenWaitlistOfferFails(t *testing.T) {
+	db := openTestDB(t)
+	bookings := newTestBookingService(db)
+	ctx := context.Background()
+	_, seatIDs, bookingID, entryID, _ := waitlistFixture(t, db, bookings, 1)
+
+	if _, err := db.Exec(`UPDATE ticket_types SET is_active = FALSE WHERE ticket_type = $1`, DefaultTicketType); err != nil {
+		t.Fatalf("deactivate default ticket type: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`UPDATE ticket_types SET is_active = TRUE WHERE ticket_type = $1`, DefaultTicketType)
+	})
+
+	if err := bookings.Cancel(ctx, bookingID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	var bookingStatus, seatStatus string
+	if err := db.QueryRow(`SELECT booking_status FROM bookings WHERE booking_id = $1`, bookingID).Scan(&bookingStatus); err != nil {
+		t.Fatalf("fetch booking: %v", err)
+	}
+	if err := db.QueryRow(`SELECT status FROM seat_status WHERE seat_id = $1`, seatIDs[0]).Scan(&seatStatus); err != nil {
+		t.Fatalf("fetch seat status: %v", err)
+	}
+	if bookingStatus != "cancelled" || seatStatus != "available" {
+		t.Errorf("booking %s with seat %s, want cancelled with seat available", bookingStatus, seatStatus)
+	}
+	if status, _ := waitlistEntryState(t, db, entryID); status != "waiting" {
+		t.Errorf("waitlist entry = %s, want waiting", status)
+	}
+}
@@ -7,6 +7,9 @@
 -- ส่วนที่ 1: สร้างตาราง (CREATE TABLES)
 -- =====================================================
 
+-- ใช้ theater_id WITH = ร่วมกับช่วงเวลาใน exclusion constraint ของ showtimes
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
 -- ผู้ใช้งาน
 CREATE TABLE users (
     user_id SERIAL PRIMARY KEY,
@@ -25,6 +28,9 @@ CREATE TABLE cinemas (
     cinema_name VARCHAR(255) NOT NULL,
     address TEXT NOT NULL,
     city VARCHAR(100) NOT NULL,
+    hold_minutes INTEGER CHECK (hold_minutes > 0), -- เวลาถือที่นั่งเฉพาะสาขา (NULL = ใช้ค่าจาก SEAT_HOLD_MINUTES)
+    opens_at TIME NOT NULL DEFAULT '10:00', -- เวลาเปิด รอบแรกเริ่มได้ตั้งแต่เวลานี้
+    closes_at TIME NOT NULL DEFAULT '01:00', -- เวลาปิด รอบสุดท้ายต้องจบก่อน (ไม่มากกว่า opens_at = วันถัดไป)
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -65,6 +71,7 @@ CREATE TABLE movies (
     subtitle VARCHAR(50),
     poster_url TEXT,
     release_date DATE,
+    age_rating VARCHAR(10) CHECK (age_rating IN ('G', '13+', '15+', '18+', '20+')), -- NULL = ยังไม่จัดเรต
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -73,6 +80,18 @@ CREATE TABLE movies (
 -- สร้าง index สำหรับการค้นหาตาม genre
 CREATE INDEX idx_movies_genres ON movies USING GIN(genres);
 
+-- ช่วงเวลาที่โรงถูกใช้โดยรอบฉาย รวมเวลาทำความสะอาดหลังจบ
+-- end_time ที่ไม่มากกว่า show_time คือจบหลังเที่ยงคืน (วันถัดไป)
+CREATE FUNCTION showtime_period(p_show_date DATE, p_show_time TIME, p_end_time TIME, p_cleaning_minutes INTEGER)
+RETURNS TSRANGE AS $$
+    SELECT tsrange(
+        p_show_date + p_show_time,
+        p_show_date + p_end_time
+            + CASE WHEN p_end_time <= p_show_time THEN INTERVAL '1 day' ELSE INTERVAL '0' END
+            + p_cleaning_minutes * INTERVAL '1 minute'
+    )
+$$ LANGUAGE SQL IMMUTABLE;
+
 -- รอบฉาย
 CREATE TABLE showtimes (
     showtime_id SERIAL PRIMARY KEY,
@@ -82,10 +101,17 @@ CREATE TABLE showtimes (
     show_time TIME NOT NULL,
     end_time TIME NOT NULL,
     price DECIMAL(10, 2) NOT NULL,
-    available_seats INTEGER NOT NULL,
+    available_seats INTEGER NOT NULL DEFAULT 0, -- ดูแลโดย trigger จาก seats และ seat_status (ห้ามแก้เอง)
+    cleaning_minutes INTEGER NOT NULL DEFAULT 15 CHECK (cleaning_minutes >= 0), -- เวลาทำความสะอาดหลังจบรอบ (SHOWTIME_CLEANING_MINUTES)
     is_active BOOLEAN DEFAULT TRUE,
+    needs_review BOOLEAN NOT NULL DEFAULT FALSE, -- ความยาวภาพยนตร์เปลี่ยนแต่เลื่อนเวลาจบไม่ได้ (จะทับรอบอื่น) admin ต้องย้ายรอบเอง
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- รอบฉายที่เปิดอยู่ในโรงเดียวกันห้ามทับกัน (นับเวลาทำความสะอาดด้วย)
+    CONSTRAINT showtimes_no_overlap EXCLUDE USING gist (
+        theater_id WITH =,
+        showtime_period(show_date, show_time, end_time, cleaning_minutes) WITH &&
+    ) WHERE (is_active)
 );
 
 -- จองตั๋ว
@@ -98,6 +124,36 @@ CREATE TABLE bookings (
     booking_status VARCHAR(20) NOT NULL DEFAULT 'pending',
     payment_status VARCHAR(20) NOT NULL DEFAULT 'pending',
     booking_code VARCHAR(50) UNIQUE NOT NULL,
+    hold_extended BOOLEAN NOT NULL DEFAULT FALSE, -- ขยายเวลาถือที่นั่งได้ครั้งเดียว
+    discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0, -- ส่วนลดจากโค้ดโปรโมชัน (total_amount หักส่วนลดแล้ว)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ส่วนที่แต่ละคนต้องจ่ายในการจองแบบกลุ่ม (ผู้จัดมีส่วนของตัวเองด้วย)
+-- การจองจะ confirmed เมื่อทุกส่วนจ่ายครบ ถ้าหมดเวลาถือที่นั่งก่อน ส่วนที่จ่ายแล้วจะถูกคืนเงิน
+CREATE TABLE booking_shares (
+    share_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    is_organizer BOOLEAN NOT NULL DEFAULT FALSE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'refunded')),
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(booking_id, user_id)
+);
+
+CREATE INDEX idx_booking_shares_user_id ON booking_shares(user_id);
+
+-- ประเภทตั๋ว min_age/max_age ใช้ตรวจกับเรตอายุของภาพยนตร์
+CREATE TABLE ticket_types (
+    ticket_type VARCHAR(20) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    min_age INTEGER CHECK (min_age >= 0),
+    max_age INTEGER CHECK (max_age >= 0),
+    is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -107,6 +163,8 @@ CREATE TABLE booking_seats (
     booking_seat_id SERIAL PRIMARY KEY,
     booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
     seat_id INTEGER NOT NULL REFERENCES seats(seat_id),
+    ticket_type VARCHAR(20) NOT NULL DEFAULT 'adult' REFERENCES ticket_types(ticket_type),
+    share_id INTEGER REFERENCES booking_shares(share_id) ON DELETE SET NULL, -- ผู้จ่ายที่นั่งนี้ในการจองแบบกลุ่ม
     price DECIMAL(10, 2) NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(booking_id, seat_id)
@@ -125,6 +183,359 @@ CREATE TABLE seat_status (
     UNIQUE(showtime_id, seat_id)
 );
 
+-- available_seats = ที่นั่ง active ของโรงที่ไม่มีสถานะอื่นนอกจาก 'available' ใน seat_status (ไม่มีแถว = ว่าง)
+-- trigger ปรับค่าเป็นส่วนต่าง (available_seats +/- 1) เพื่อให้ transaction ที่ทำพร้อมกันไม่เขียนทับกัน
+CREATE FUNCTION showtime_available_seats(p_showtime_id INTEGER) RETURNS INTEGER AS $$
+    SELECT COUNT(*)::INTEGER
+    FROM showtimes st
+    JOIN seats se ON se.theater_id = st.theater_id AND se.is_active = TRUE
+    LEFT JOIN seat_status ss ON ss.showtime_id = st.showtime_id AND ss.seat_id = se.seat_id
+    WHERE st.showtime_id = p_showtime_id AND COALESCE(ss.status, 'available') = 'available'
+$$ LANGUAGE SQL STABLE;
+
+-- รอบฉายใหม่เริ่มจากจำนวนที่นั่ง active ของโรง (ไม่ใช้ theaters.total_seats)
+CREATE FUNCTION showtimes_init_available_seats() RETURNS TRIGGER AS $$
+BEGIN
+    SELECT COUNT(*) INTO NEW.available_seats
+    FROM seats WHERE theater_id = NEW.theater_id AND is_active = TRUE;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_showtimes_available_seats
+    BEFORE INSERT ON showtimes
+    FOR EACH ROW EXECUTE FUNCTION showtimes_init_available_seats();
+
+-- ที่นั่งเปลี่ยนจากว่างเป็นไม่ว่าง (หรือกลับกัน) ในรอบฉาย
+CREATE FUNCTION seat_status_sync_available_seats() RETURNS TRIGGER AS $$
+DECLARE
+    delta INTEGER := 0;
+    v_showtime_id INTEGER;
+    v_seat_id INTEGER;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        v_showtime_id := OLD.showtime_id;
+        v_seat_id := OLD.seat_id;
+        IF OLD.status <> 'available' THEN
+            delta := delta + 1;
+        END IF;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        v_showtime_id := NEW.showtime_id;
+        v_seat_id := NEW.seat_id;
+        IF NEW.status <> 'available' THEN
+            delta := delta - 1;
+        END IF;
+    END IF;
+
+    IF delta <> 0 AND EXISTS (SELECT 1 FROM seats WHERE seat_id = v_seat_id AND is_active = TRUE) THEN
+        UPDATE showtimes SET available_seats = available_seats + delta
+        WHERE showtime_id = v_showtime_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_seat_status_available_seats
+    AFTER INSERT OR UPDATE OF status OR DELETE ON seat_status
+    FOR EACH ROW EXECUTE FUNCTION seat_status_sync_available_seats();
+
+-- เปิด/ปิดที่นั่ง มีผลกับทุกรอบฉายของโรงที่ที่นั่งนั้นยังว่างอยู่
+CREATE FUNCTION seats_sync_available_seats() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.is_active THEN
+        UPDATE showtimes st SET available_seats = available_seats - 1
+        WHERE st.theater_id = OLD.theater_id
+          AND NOT EXISTS (
+            SELECT 1 FROM seat_status ss
+            WHERE ss.showtime_id = st.showtime_id AND ss.seat_id = OLD.seat_id AND ss.status <> 'available'
+          );
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.is_active THEN
+        UPDATE showtimes st SET available_seats = available_seats + 1
+        WHERE st.theater_id = NEW.theater_id
+          AND NOT EXISTS (
+            SELECT 1 FROM seat_status ss
+            WHERE ss.showtime_id = st.showtime_id AND ss.seat_id = NEW.seat_id AND ss.status <> 'available'
+          );
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_seats_available_seats
+    AFTER INSERT OR UPDATE OF is_active, theater_id OR DELETE ON seats
+    FOR EACH ROW EXECUTE FUNCTION seats_sync_available_seats();
+
+-- การชำระเงิน
+CREATE TABLE payments (
+    payment_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL,
+    method VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'captured', 'failed', 'refunding', 'refunded'
+    share_id INTEGER REFERENCES booking_shares(share_id) ON DELETE SET NULL, -- จ่ายส่วนของใครในการจองแบบกลุ่ม
+    refund_idempotency_key VARCHAR(100), -- key ที่ส่งให้ผู้ให้บริการตอนคืนเงิน (ส่งซ้ำด้วย key เดิมได้)
+    refund_ref VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(provider, provider_ref)
+);
+
+CREATE INDEX idx_payments_booking_id ON payments(booking_id);
+CREATE INDEX idx_payments_refunding ON payments(payment_id) WHERE status = 'refunding';
+
+-- Idempotency-Key ที่ใช้แล้ว (กันการยืนยันการชำระเงินซ้ำเมื่อ client retry)
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(50) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    result VARCHAR(20) NOT NULL,
+    conflict_seat_id INTEGER,
+    payment_id INTEGER REFERENCES payments(payment_id) ON DELETE SET NULL, -- payment ของผลลัพธ์ confirmed (ใช้ตอบผลเดิม)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+-- คำขอคืนเงิน (ลูกค้าขอ, admin อนุมัติ/ปฏิเสธ)
+CREATE TABLE refund_requests (
+    refund_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(user_id),
+    reason TEXT,
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending', -- 'pending', 'refunding', 'refunded', 'rejected'
+    admin_note TEXT,
+    reviewed_by INTEGER REFERENCES users(user_id),
+    reviewed_at TIMESTAMP,
+    provider_refund_ref VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- หนึ่งการจองมีคำขอที่รอพิจารณาได้ครั้งละหนึ่งรายการ
+CREATE UNIQUE INDEX idx_refund_requests_pending ON refund_requests(booking_id) WHERE status = 'pending';
+
+-- สถานะล่าสุดของ job ใน scheduler (ใช้ร่วมกันทุก replica)
+CREATE TABLE scheduled_jobs (
+    job_name VARCHAR(100) PRIMARY KEY,
+    schedule VARCHAR(100) NOT NULL,
+    last_run_at TIMESTAMP,
+    last_duration_ms BIGINT,
+    last_status VARCHAR(20), -- 'succeeded', 'failed'
+    last_error TEXT,
+    last_instance VARCHAR(255),
+    next_run_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ประวัติการรัน job แต่ละรอบ
+CREATE TABLE job_runs (
+    run_id SERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX idx_job_runs_job_name ON job_runs(job_name, started_at DESC);
+
+-- การจองที่ยกเลิกแล้วและถูกย้ายออกจาก bookings โดย retention job
+-- เก็บ booking_seats / payments / refund_requests ของการจองไว้เป็น JSONB (ไม่มี FK เพื่อให้ลบข้อมูลต้นทางได้)
+CREATE TABLE archived_bookings (
+    booking_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    showtime_id INTEGER NOT NULL,
+    booking_date TIMESTAMP,
+    total_amount DECIMAL(10, 2) NOT NULL,
+    booking_status VARCHAR(20) NOT NULL,
+    payment_status VARCHAR(20) NOT NULL,
+    booking_code VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    seats JSONB NOT NULL DEFAULT '[]',
+    payments JSONB NOT NULL DEFAULT '[]',
+    refund_requests JSONB NOT NULL DEFAULT '[]',
+    discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    promo_redemptions JSONB NOT NULL DEFAULT '[]',
+    booking_items JSONB NOT NULL DEFAULT '[]',
+    booking_shares JSONB NOT NULL DEFAULT '[]',
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_archived_bookings_user_id ON archived_bookings(user_id);
+
+-- ราคาตามประเภทที่นั่ง กำหนดได้ทั้งระดับโรง (theater) และระดับรอบฉาย (showtime)
+CREATE TABLE seat_prices (
+    seat_price_id SERIAL PRIMARY KEY,
+    theater_id INTEGER REFERENCES theaters(theater_id) ON DELETE CASCADE,
+    showtime_id INTEGER REFERENCES showtimes(showtime_id) ON DELETE CASCADE,
+    seat_type VARCHAR(50) NOT NULL CHECK (seat_type IN ('standard', 'premium', 'couple', 'vip')),
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((theater_id IS NULL) <> (showtime_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_seat_prices_theater ON seat_prices(theater_id, seat_type) WHERE theater_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_seat_prices_showtime ON seat_prices(showtime_id, seat_type) WHERE showtime_id IS NOT NULL;
+
+-- ราคาจริงของแต่ละที่นั่งในแต่ละรอบฉาย: ราคารอบฉาย > ราคาโรง > showtimes.price
+CREATE VIEW showtime_seat_prices AS
+SELECT
+    st.showtime_id,
+    se.seat_id,
+    se.seat_type,
+    se.is_active,
+    COALESCE(sps.price, spt.price, st.price) AS price
+FROM showtimes st
+JOIN seats se ON se.theater_id = st.theater_id
+LEFT JOIN seat_prices sps ON sps.showtime_id = st.showtime_id AND sps.seat_type = se.seat_type
+LEFT JOIN seat_prices spt ON spt.theater_id = st.theater_id AND spt.seat_type = se.seat_type;
+
+-- วันหยุดนักขัตฤกษ์ ใช้กับกฎราคาที่อ้างถึงวันหยุด
+CREATE TABLE holidays (
+    holiday_date DATE PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- กฎปรับราคาตามวัน เวลา ประเภทโรง และประเภทที่นั่ง (เงื่อนไขที่เป็น NULL = ไม่จำกัด)
+-- ใช้ตามลำดับ priority จากน้อยไปมาก ต่อจากราคาตามประเภทที่นั่ง (showtime_seat_prices)
+CREATE TABLE pricing_rules (
+    rule_id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    priority INTEGER NOT NULL DEFAULT 100,
+    adjustment_type VARCHAR(20) NOT NULL CHECK (adjustment_type IN ('percentage', 'fixed', 'override')),
+    adjustment_value DECIMAL(10, 2) NOT NULL,
+    stop_processing BOOLEAN NOT NULL DEFAULT FALSE,
+    days_of_week INTEGER[],   -- 0 = อาทิตย์ ... 6 = เสาร์
+    holidays VARCHAR(20) NOT NULL DEFAULT 'any' CHECK (holidays IN ('any', 'only', 'include', 'exclude')),
+    start_time TIME,
+    end_time TIME,
+    valid_from DATE,
+    valid_until DATE,
+    cinema_id INTEGER REFERENCES cinemas(cinema_id) ON DELETE CASCADE,
+    theater_types VARCHAR(50)[],
+    seat_types VARCHAR(50)[],
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- โค้ดโปรโมชัน/บัตรส่วนลด ใช้ตอนสร้างการจอง (เงื่อนไขที่เป็น NULL = ไม่จำกัด)
+CREATE TABLE promo_codes (
+    promo_id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL, -- เก็บเป็นตัวพิมพ์ใหญ่
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value DECIMAL(10, 2) NOT NULL CHECK (discount_value > 0),
+    max_discount DECIMAL(10, 2), -- เพดานส่วนลดสำหรับแบบ percentage
+    max_uses INTEGER CHECK (max_uses > 0),
+    max_uses_per_user INTEGER CHECK (max_uses_per_user > 0),
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    movie_id INTEGER REFERENCES movies(movie_id) ON DELETE CASCADE,
+    cinema_id INTEGER REFERENCES cinemas(cinema_id) ON DELETE CASCADE,
+    min_seats INTEGER CHECK (min_seats > 0),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- การใช้โค้ดโปรโมชัน (หนึ่งการจองใช้ได้หนึ่งโค้ด) นับสิทธิ์เฉพาะการจองที่ไม่ถูกยกเลิก
+CREATE TABLE promo_redemptions (
+    redemption_id SERIAL PRIMARY KEY,
+    promo_id INTEGER NOT NULL REFERENCES promo_codes(promo_id) ON DELETE CASCADE,
+    booking_id INTEGER UNIQUE NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    discount_amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promo_redemptions_promo_id ON promo_redemptions(promo_id);
+CREATE INDEX idx_promo_redemptions_user_id ON promo_redemptions(promo_id, user_id);
+
+-- ค่าตั๋วตามประเภทตั๋ว ปรับจากราคาที่นั่ง (หลังใช้กฎราคา) ใช้แถวที่เจาะจงที่สุด:
+-- สาขา + ประเภทที่นั่ง > สาขา > ประเภทที่นั่ง > ทุกสาขา ถ้าไม่มีแถวเลยใช้ราคาที่นั่งตามเดิม
+CREATE TABLE ticket_fares (
+    fare_id SERIAL PRIMARY KEY,
+    ticket_type VARCHAR(20) NOT NULL REFERENCES ticket_types(ticket_type) ON DELETE CASCADE,
+    cinema_id INTEGER REFERENCES cinemas(cinema_id) ON DELETE CASCADE,
+    seat_type VARCHAR(50) CHECK (seat_type IN ('standard', 'premium', 'couple', 'vip')),
+    adjustment_type VARCHAR(20) NOT NULL CHECK (adjustment_type IN ('percentage', 'fixed', 'override')),
+    adjustment_value DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_ticket_fares_scope ON ticket_fares(ticket_type, COALESCE(cinema_id, 0), COALESCE(seat_type, ''));
+
+-- สินค้าหน้าโรง (ขนม เครื่องดื่ม คอมโบ) แยกตามสาขา พร้อมจำนวนคงเหลือ
+CREATE TABLE concessions (
+    concession_id SERIAL PRIMARY KEY,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(cinema_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('snack', 'drink', 'combo')),
+    price DECIMAL(10, 2) NOT NULL CHECK (price >= 0),
+    stock_quantity INTEGER NOT NULL DEFAULT 0 CHECK (stock_quantity >= 0),
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_concessions_cinema_id ON concessions(cinema_id);
+
+-- สินค้าที่สั่งพร้อมการจอง ราคาต่อหน่วยบันทึกไว้ ณ เวลาจอง
+CREATE TABLE booking_items (
+    booking_item_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    concession_id INTEGER NOT NULL REFERENCES concessions(concession_id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(booking_id, concession_id)
+);
+
+-- รายชื่อรอที่นั่งของรอบฉายที่เต็ม เมื่อมีที่นั่งคืน คิวแรกที่จำนวนที่นั่งพอจะได้การจองที่ถือที่นั่งไว้ให้ชั่วคราว (offered)
+-- waiting -> offered -> fulfilled (จ่ายแล้ว) / expired (ไม่จ่ายทันเวลาหรือยกเลิกการจองที่ได้รับ) หรือ cancelled (ออกจากคิวเอง)
+CREATE TABLE waitlist_entries (
+    entry_id SERIAL PRIMARY KEY,
+    showtime_id INTEGER NOT NULL REFERENCES showtimes(showtime_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    seat_count INTEGER NOT NULL CHECK (seat_count BETWEEN 1 AND 10),
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'offered', 'fulfilled', 'expired', 'cancelled')),
+    booking_id INTEGER REFERENCES bookings(booking_id) ON DELETE SET NULL,
+    offered_at TIMESTAMP,
+    offer_expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_waitlist_entries_queue ON waitlist_entries(showtime_id, entry_id) WHERE status = 'waiting';
+CREATE UNIQUE INDEX idx_waitlist_entries_active_user ON waitlist_entries(showtime_id, user_id) WHERE status IN ('waiting', 'offered');
+
+-- การแจ้งเตือนในแอปของผู้ใช้
+CREATE TABLE notifications (
+    notification_id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC);
+
 -- =====================================================
 -- ส่วนที่ 2: ข้อมูลผู้ใช้งาน (USERS)
 -- =====================================================
@@ -508,12 +919,74 @@ VALUES
   ('A Quiet Place Part II', 'ครอบครัวแอ๊บบอตต้องออกเดินทางเผชิญหน้ากับความน่าสะพรึงกลัวของโลกภายนอก', 97, ARRAY['Horror', 'Sci-Fi'], 'อังกฤษ', 'ไทย', '/uploads/posters/a_quiet_place_part_2.jpg', '2021-05-28', TRUE);
 
 -- =====================================================
--- ส่วนที่ 7: ข้อมูลรอบฉาย (SHOWTIMES)
--- แต่ละสาขาฉาย 3-4 เรื่อง
+-- ส่วนที่ 7: รอบฉายพิเศษ (ลงก่อนรอบปกติ รอบปกติที่ทับจะถูกข้าม)
+-- =====================================================
+
+-- Zootopia 2 รอบพิเศษที่ IMAX
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
+SELECT 
+    (SELECT movie_id FROM movies WHERE title = 'Zootopia 2'),
+    t.theater_id,
+    d::DATE,
+    times.st::TIME,
+    (times.st::TIME + INTERVAL '100 minutes')::TIME,
+    480.00,
+    TRUE
+FROM 
+    theaters t
+    CROSS JOIN generate_series('2025-12-01'::DATE, '2025-12-06'::DATE, '1 day') d
+    CROSS JOIN (VALUES ('09:00'), ('11:30'), ('14:00'), ('16:30'), ('19:00'), ('21:30')) AS times(st)
+WHERE 
+    t.theater_type = 'imax'
+ON CONFLICT DO NOTHING;
+
+-- My Boo 2 รอบดึก (Midnight Screening)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
+SELECT 
+    (SELECT movie_id FROM movies WHERE title = 'My Boo 2'),
+    t.theater_id,
+    d::DATE,
+    '23:59'::TIME,
+    '01:44'::TIME,
+    250.00,
+    TRUE
+FROM 
+    theaters t
+    JOIN cinemas c ON t.cinema_id = c.cinema_id
+    CROSS JOIN generate_series('2025-12-05'::DATE, '2025-12-06'::DATE, '1 day') d
+WHERE 
+    t.theater_name = 'โรง 1'
+    AND c.city = 'Bangkok'
+ON CONFLICT DO NOTHING;
+
+-- 4 Tigers รอบพิเศษนครปฐม
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
+SELECT 
+    (SELECT movie_id FROM movies WHERE title = '4 เสือ (4 Tigers)'),
+    t.theater_id,
+    d::DATE,
+    times.st::TIME,
+    (times.st::TIME + INTERVAL '140 minutes')::TIME,
+    250.00,
+    TRUE
+FROM 
+    theaters t
+    JOIN cinemas c ON t.cinema_id = c.cinema_id
+    CROSS JOIN generate_series('2025-12-01'::DATE, '2025-12-06'::DATE, '1 day') d
+    CROSS JOIN (VALUES ('13:00'), ('16:30'), ('20:00')) AS times(st)
+WHERE 
+    t.theater_name = 'โรง 2'
+    AND c.city = 'Nakhon Pathom'
+ON CONFLICT DO NOTHING;
+
+-- =====================================================
+-- ส่วนที่ 8: ข้อมูลรอบฉาย (SHOWTIMES)
+-- แต่ละสาขาฉาย 3-4 เรื่อง แต่ละโรงเลือกเรื่องตามลำดับ md5 ของ (โรง, เวลา, เรื่อง)
+-- รอบที่ทับรอบก่อนหน้าในโรงเดียวกัน (showtimes_no_overlap) จะถูกข้ามด้วย ON CONFLICT DO NOTHING
 -- =====================================================
 
 -- Central Nakhon Pathom: ฉาย 4 เรื่อง (My Boo 2, Zootopia 2, 4 Tigers, Top Gun)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -521,7 +994,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     200.00,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -532,10 +1004,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('My Boo 2', 'Zootopia 2', '4 เสือ (4 Tigers)', 'Top Gun: Maverick')
     ) m
-WHERE c.address LIKE '%Central Nakhon Pathom%';
+WHERE c.address LIKE '%Central Nakhon Pathom%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Lotus Nakhon Pathom: ฉาย 4 เรื่อง (The Gunman, Dune, Oppenheimer, Everything Everywhere)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -543,7 +1017,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     200.00,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -554,10 +1027,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('The Gunman', 'Dune: Part One', 'Oppenheimer', 'Everything Everywhere All at Once')
     ) m
-WHERE c.address LIKE '%Lotus Nakhon Pathom%';
+WHERE c.address LIKE '%Lotus Nakhon Pathom%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Lotus Salaya: ฉาย 4 เรื่อง (Zootopia 2, My Boo 2, Extraction 2, Godzilla)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -565,7 +1040,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 280.00 ELSE 200.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -576,10 +1050,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', 'My Boo 2', 'Extraction 2', 'Godzilla Minus One')
     ) m
-WHERE c.address LIKE '%Lotus Salaya%';
+WHERE c.address LIKE '%Lotus Salaya%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Lotus Sampran: ฉาย 3 เรื่อง (4 Tigers, Top Gun, Black Panther)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -587,7 +1063,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 280.00 ELSE 200.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -598,10 +1073,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('4 เสือ (4 Tigers)', 'Top Gun: Maverick', 'Black Panther: Wakanda Forever')
     ) m
-WHERE c.address LIKE '%Lotus Sampran%';
+WHERE c.address LIKE '%Lotus Sampran%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Lotus Kamphaeng Saen: ฉาย 3 เรื่อง (Zootopia 2, My Boo 2, Cruella)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -609,7 +1086,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     180.00,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -620,10 +1096,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', 'My Boo 2', 'Cruella')
     ) m
-WHERE c.address LIKE '%Lotus Kamphaeng Saen%';
+WHERE c.address LIKE '%Lotus Kamphaeng Saen%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Big C Ommoi: ฉาย 3 เรื่อง (Parasite, A Quiet Place, The Gunman)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -631,7 +1109,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     180.00,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -642,10 +1119,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Parasite', 'A Quiet Place Part II', 'The Gunman')
     ) m
-WHERE c.address LIKE '%Big C Ommoi%';
+WHERE c.address LIKE '%Big C Ommoi%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Siam Paragon: ฉาย 4 เรื่อง (Zootopia 2, Dune, Oppenheimer, Top Gun)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -658,7 +1137,6 @@ SELECT
         WHEN t.theater_type = 'premium' THEN 350.00
         ELSE 280.00
     END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -669,10 +1147,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', 'Dune: Part One', 'Oppenheimer', 'Top Gun: Maverick')
     ) m
-WHERE c.address LIKE '%Siam Paragon%';
+WHERE c.address LIKE '%Siam Paragon%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Sukhumvit-Ekamai: ฉาย 4 เรื่อง (My Boo 2, 4 Tigers, Extraction 2, Everything Everywhere)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -680,7 +1160,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 300.00 ELSE 220.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -691,10 +1170,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('My Boo 2', '4 เสือ (4 Tigers)', 'Extraction 2', 'Everything Everywhere All at Once')
     ) m
-WHERE c.address LIKE '%Sukhumvit-Ekamai%';
+WHERE c.address LIKE '%Sukhumvit-Ekamai%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Ratchayothin: ฉาย 4 เรื่อง (Zootopia 2, Godzilla, Black Panther, Cruella)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -702,7 +1183,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 300.00 ELSE 220.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -713,10 +1193,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', 'Godzilla Minus One', 'Black Panther: Wakanda Forever', 'Cruella')
     ) m
-WHERE c.address LIKE '%Ratchayothin%';
+WHERE c.address LIKE '%Ratchayothin%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Esplanade: ฉาย 4 เรื่อง (My Boo 2, The Gunman, Parasite, A Quiet Place)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -724,7 +1206,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 300.00 ELSE 220.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -735,10 +1216,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('My Boo 2', 'The Gunman', 'Parasite', 'A Quiet Place Part II')
     ) m
-WHERE c.address LIKE '%Esplanade%';
+WHERE c.address LIKE '%Esplanade%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Central Pinklao: ฉาย 3 เรื่อง (Zootopia 2, 4 Tigers, Top Gun)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -746,7 +1229,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 280.00 ELSE 200.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -757,10 +1239,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', '4 เสือ (4 Tigers)', 'Top Gun: Maverick')
     ) m
-WHERE c.address LIKE '%Central Pinklao%';
+WHERE c.address LIKE '%Central Pinklao%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Central World: ฉาย 4 เรื่อง (Dune, Oppenheimer, Everything Everywhere, Godzilla)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -768,7 +1252,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'vip' THEN 700.00 ELSE 250.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -779,10 +1262,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Dune: Part One', 'Oppenheimer', 'Everything Everywhere All at Once', 'Godzilla Minus One')
     ) m
-WHERE c.address LIKE '%Central World%';
+WHERE c.address LIKE '%Central World%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- MBK Center: ฉาย 3 เรื่อง (My Boo 2, Zootopia 2, Cruella)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -790,7 +1275,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     200.00,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -801,10 +1285,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('My Boo 2', 'Zootopia 2', 'Cruella')
     ) m
-WHERE c.address LIKE '%MBK Center%';
+WHERE c.address LIKE '%MBK Center%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Central Ladprao: ฉาย 4 เรื่อง (4 Tigers, Extraction 2, Black Panther, The Gunman)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -812,7 +1298,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 300.00 ELSE 220.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -823,10 +1308,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('4 เสือ (4 Tigers)', 'Extraction 2', 'Black Panther: Wakanda Forever', 'The Gunman')
     ) m
-WHERE c.address LIKE '%Central Ladprao%';
+WHERE c.address LIKE '%Central Ladprao%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Central Rama 9: ฉาย 4 เรื่อง (Zootopia 2, Top Gun, Parasite, A Quiet Place)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -834,7 +1321,6 @@ SELECT
     times.st::TIME,
     (times.st::TIME + (m.duration || ' minutes')::INTERVAL)::TIME,
     CASE WHEN t.theater_type = 'premium' THEN 300.00 ELSE 220.00 END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -845,10 +1331,12 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Zootopia 2', 'Top Gun: Maverick', 'Parasite', 'A Quiet Place Part II')
     ) m
-WHERE c.address LIKE '%Central Rama 9%';
+WHERE c.address LIKE '%Central Rama 9%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- Icon Siam: ฉาย 4 เรื่อง (Dune, Oppenheimer, My Boo 2, Everything Everywhere)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
+INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, is_active)
 SELECT 
     m.movie_id,
     t.theater_id,
@@ -860,7 +1348,6 @@ SELECT
         WHEN t.theater_type = 'premium' THEN 400.00
         ELSE 300.00
     END,
-    t.total_seats,
     TRUE
 FROM 
     cinemas c
@@ -871,65 +1358,39 @@ FROM
         SELECT movie_id, duration FROM movies 
         WHERE title IN ('Dune: Part One', 'Oppenheimer', 'My Boo 2', 'Everything Everywhere All at Once')
     ) m
-WHERE c.address LIKE '%Icon Siam%';
+WHERE c.address LIKE '%Icon Siam%'
+ORDER BY t.theater_id, d, times.st, md5(t.theater_id || ':' || times.st || ':' || m.movie_id)
+ON CONFLICT DO NOTHING;
 
 -- =====================================================
--- ส่วนที่ 8: รอบฉายพิเศษ
+-- ส่วนที่ 9: ประเภทตั๋วและค่าตั๋ว (TICKET TYPES)
 -- =====================================================
 
--- Zootopia 2 รอบพิเศษที่ IMAX
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
-SELECT 
-    (SELECT movie_id FROM movies WHERE title = 'Zootopia 2'),
-    t.theater_id,
-    d::DATE,
-    times.st::TIME,
-    (times.st::TIME + INTERVAL '100 minutes')::TIME,
-    480.00,
-    t.total_seats,
-    TRUE
-FROM 
-    theaters t
-    CROSS JOIN generate_series('2025-12-01'::DATE, '2025-12-06'::DATE, '1 day') d
-    CROSS JOIN (VALUES ('09:00'), ('11:30'), ('14:00'), ('16:30'), ('19:00'), ('21:30')) AS times(st)
-WHERE 
-    t.theater_type = 'imax';
+INSERT INTO ticket_types (ticket_type, name, min_age, max_age)
+VALUES
+  ('adult', 'ผู้ใหญ่', NULL, NULL),
+  ('child', 'เด็ก (ไม่เกิน 12 ปี)', NULL, 12),
+  ('senior', 'ผู้สูงอายุ (60 ปีขึ้นไป)', 60, NULL),
+  ('student', 'นักเรียน/นักศึกษา', NULL, NULL);
 
--- My Boo 2 รอบดึก (Midnight Screening)
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
-SELECT 
-    (SELECT movie_id FROM movies WHERE title = 'My Boo 2'),
-    t.theater_id,
-    d::DATE,
-    '23:59'::TIME,
-    '01:44'::TIME,
-    250.00,
-    t.total_seats,
-    TRUE
-FROM 
-    theaters t
-    JOIN cinemas c ON t.cinema_id = c.cinema_id
-    CROSS JOIN generate_series('2025-12-05'::DATE, '2025-12-06'::DATE, '1 day') d
-WHERE 
-    t.theater_name = 'โรง 1'
-    AND c.city = 'Bangkok';
+INSERT INTO ticket_fares (ticket_type, adjustment_type, adjustment_value)
+VALUES
+  ('child', 'percentage', -30),
+  ('senior', 'percentage', -30),
+  ('student', 'percentage', -20);
 
--- 4 Tigers รอบพิเศษนครปฐม
-INSERT INTO showtimes (movie_id, theater_id, show_date, show_time, end_time, price, available_seats, is_active)
-SELECT 
-    (SELECT movie_id FROM movies WHERE title = '4 เสือ (4 Tigers)'),
-    t.theater_id,
-    d::DATE,
-    times.st::TIME,
-    (times.st::TIME + INTERVAL '140 minutes')::TIME,
-    250.00,
-    t.total_seats,
-    TRUE
-FROM 
-    theaters t
-    JOIN cinemas c ON t.cinema_id = c.cinema_id
-    CROSS JOIN generate_series('2025-12-01'::DATE, '2025-12-06'::DATE, '1 day') d
-    CROSS JOIN (VALUES ('13:00'), ('16:30'), ('20:00')) AS times(st)
-WHERE 
-    t.theater_name = 'โรง 2'
-    AND c.city = 'Nakhon Pathom';
\ No newline at end of file
+-- =====================================================
+-- ส่วนที่ 10: สินค้าหน้าโรง (CONCESSIONS)
+-- =====================================================
+
+INSERT INTO concessions (cinema_id, name, description, category, price, stock_quantity)
+SELECT c.cinema_id, p.name, p.description, p.category, p.price, p.stock_quantity
+FROM cinemas c
+CROSS JOIN (VALUES
+  ('ป๊อปคอร์น (M)', 'ป๊อปคอร์นรสเนย ขนาดกลาง', 'snack', 89.00, 200),
+  ('ป๊อปคอร์น (L)', 'ป๊อปคอร์นรสเนย ขนาดใหญ่', 'snack', 119.00, 200),
+  ('น้ำอัดลม (M)', 'น้ำอัดลม 22 ออนซ์', 'drink', 59.00, 300),
+  ('น้ำเปล่า', 'น้ำดื่ม 600 มล.', 'drink', 25.00, 300),
+  ('คอมโบเดี่ยว', 'ป๊อปคอร์น (M) + น้ำอัดลม (M)', 'combo', 139.00, 150),
+  ('คอมโบคู่', 'ป๊อปคอร์น (L) + น้ำอัดลม (M) 2 แก้ว', 'combo', 219.00, 100)
+) AS p(name, description, category, price, stock_quantity);